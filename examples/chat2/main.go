@@ -213,15 +213,23 @@ func main() {
 		q := store.Query{
 			ContentTopics: []string{*contentTopicFlag},
 		}
-		response, err := wakuNode.Store().Query(tCtx, q,
+		it, err := wakuNode.Store().QueryAll(tCtx, q,
 			store.WithAutomaticRequestId(),
 			store.WithPeer(*storeNodeId),
-			store.WithPaging(true, 0))
+			store.WithPaging(true, 0),
+			store.WithMaxMessages(store.MaxPageSize))
 
 		if err != nil {
 			ui.displayMessage("Could not query storenode: " + err.Error())
-		} else {
-			chat.displayMessages(response.Messages)
+			return
+		}
+
+		for it.Next() {
+			chat.displayMessages(it.Page().Messages)
+		}
+
+		if err := it.Err(); err != nil {
+			ui.displayMessage("Could not query storenode: " + err.Error())
 		}
 	}()
 
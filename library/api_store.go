@@ -27,7 +27,8 @@ import (
 //			"pubsubTopic" ...,
 //      }
 //		"forward": true, // sort order
-//  }
+//  },
+//  "maxMessages": 200 // optional. Retrieve pages until this number of messages is reached
 // }
 // If the message length is greater than 0, this function should be executed again, setting  the `cursor` attribute with the cursor returned in the response
 // peerID should contain the ID of a peer supporting the store protocol. Use NULL to automatically select a node
@@ -37,3 +38,20 @@ func waku_store_query(queryJSON *C.char, peerID *C.char, ms C.int) *C.char {
 	response := mobile.StoreQuery(C.GoString(queryJSON), C.GoString(peerID), int(ms))
 	return C.CString(response)
 }
+
+//export waku_store_lookup
+// Look up messages by their hash using waku store protocol.
+// lookupJSON must contain a valid json string with the following format:
+// {
+//  "hashes": ["0x...", ...], // hex encoded hashes, as returned when publishing a message
+//  "presenceOnly": false // optional. Only return whether the messages are stored
+// }
+// The response contains a "found" array indicating for each hash whether the message
+// is stored, and unless presenceOnly is true, the "messages" that were found
+// peerID should contain the ID of a peer supporting the store protocol. Use NULL to automatically select a node
+// If ms is greater than 0, the lookup must happen before the timeout
+// (in milliseconds) is reached, or an error will be returned
+func waku_store_lookup(lookupJSON *C.char, peerID *C.char, ms C.int) *C.char {
+	response := mobile.StoreLookup(C.GoString(lookupJSON), C.GoString(peerID), int(ms))
+	return C.CString(response)
+}
@@ -4,6 +4,7 @@ import (
 	"C"
 	"encoding/json"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/status-im/go-waku/waku/v2/protocol/pb"
 	"github.com/status-im/go-waku/waku/v2/protocol/store"
 )
@@ -26,6 +27,7 @@ type storeMessagesArgs struct {
 	StartTime      int64              `json:"startTime,omitempty"`
 	EndTime        int64              `json:"endTime,omitempty"`
 	PagingOptions  storePagingOptions `json:"pagingOptions,omitempty"`
+	MaxMessages    int                `json:"maxMessages,omitempty"`
 }
 
 type storeMessagesReply struct {
@@ -49,6 +51,7 @@ func StoreQuery(queryJSON string, peerID string, ms int) string {
 		store.WithAutomaticRequestId(),
 		store.WithPaging(args.PagingOptions.Forward, args.PagingOptions.PageSize),
 		store.WithCursor(args.PagingOptions.Cursor),
+		store.WithMaxMessages(args.MaxMessages),
 	}
 
 	if peerID != "" {
@@ -73,7 +76,7 @@ func StoreQuery(queryJSON string, peerID string, ms int) string {
 		ctx = context.Background()
 	}
 
-	res, err := wakuNode.Store().Query(
+	it, err := wakuNode.Store().QueryAll(
 		ctx,
 		store.Query{
 			Topic:         args.Topic,
@@ -88,12 +91,108 @@ func StoreQuery(queryJSON string, peerID string, ms int) string {
 		reply.Error = err.Error()
 		return prepareJSONResponse(reply, nil)
 	}
-	reply.Messages = res.Messages
+
+	for it.Next() {
+		reply.Messages = append(reply.Messages, it.Page().Messages...)
+
+		// A single page is returned unless a max number of messages is specified
+		if args.MaxMessages == 0 {
+			break
+		}
+	}
+
+	if err := it.Err(); err != nil {
+		reply.Error = err.Error()
+		return prepareJSONResponse(reply, nil)
+	}
+
 	reply.PagingInfo = storePagingOptions{
 		PageSize: args.PagingOptions.PageSize,
-		Cursor:   res.Cursor(),
 		Forward:  args.PagingOptions.Forward,
 	}
 
+	if it.Page() != nil {
+		reply.PagingInfo.Cursor = it.Page().Cursor()
+	}
+
+	return prepareJSONResponse(reply, nil)
+}
+
+type storeLookupArgs struct {
+	Hashes       []string `json:"hashes"`
+	PresenceOnly bool     `json:"presenceOnly,omitempty"`
+}
+
+type storeLookupReply struct {
+	Found    []bool            `json:"found"`
+	Messages []*pb.WakuMessage `json:"messages,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+func StoreLookup(lookupJSON string, peerID string, ms int) string {
+	if wakuNode == nil {
+		return makeJSONResponse(errWakuNodeNotReady)
+	}
+
+	var args storeLookupArgs
+	err := json.Unmarshal([]byte(lookupJSON), &args)
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+
+	var hashes [][]byte
+	for _, h := range args.Hashes {
+		hash, err := hexutil.Decode(h)
+		if err != nil {
+			return makeJSONResponse(err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	options := []store.HistoryRequestOption{
+		store.WithAutomaticRequestId(),
+	}
+
+	if peerID != "" {
+		p, err := peer.Decode(peerID)
+		if err != nil {
+			return makeJSONResponse(err)
+		}
+		options = append(options, store.WithPeer(p))
+	} else {
+		options = append(options, store.WithAutomaticPeerSelection())
+	}
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+
+	if ms > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), time.Duration(int(ms))*time.Millisecond)
+		defer cancel()
+	} else {
+		ctx = context.Background()
+	}
+
+	reply := storeLookupReply{}
+
+	if args.PresenceOnly {
+		reply.Found, err = wakuNode.Store().HasMessages(ctx, hashes, options...)
+		if err != nil {
+			reply.Error = err.Error()
+		}
+		return prepareJSONResponse(reply, nil)
+	}
+
+	result, err := wakuNode.Store().LookupMessages(ctx, hashes, options...)
+	if err != nil {
+		reply.Error = err.Error()
+		return prepareJSONResponse(reply, nil)
+	}
+
+	reply.Found = result.Found
+	for _, env := range result.Envelopes {
+		reply.Messages = append(reply.Messages, env.Message())
+	}
+
 	return prepareJSONResponse(reply, nil)
 }
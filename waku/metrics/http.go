@@ -53,6 +53,11 @@ func NewMetricsServer(address string, port int, log *zap.Logger) *Server {
 		metrics.MessageView,
 		metrics.FilterSubscriptionsView,
 		metrics.StoreErrorTypesView,
+		metrics.StoreRetentionDeletionsView,
+		metrics.StoreQueueLengthView,
+		metrics.StoreBatchSizeView,
+		metrics.StoreWriteDurationView,
+		metrics.StoreSyncMessagesView,
 		metrics.LightpushErrorTypesView,
 		metrics.StoreMessagesView,
 		metrics.PeersView,
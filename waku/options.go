@@ -57,7 +57,26 @@ type StoreOptions struct {
 	ShouldResume         bool
 	RetentionMaxSeconds  int
 	RetentionMaxMessages int
+	RetentionMaxBytes    int
+	RetentionRules       cli.StringSlice
+	EncryptionKeyFile    string
+	WriteBatchSize       int
+	WriteBatchWindow     time.Duration
+	AllowedPubsubTopics  cli.StringSlice
+	DeniedPubsubTopics   cli.StringSlice
+	AllowedContentTopics cli.StringSlice
+	DeniedContentTopics  cli.StringSlice
 	Nodes                cli.StringSlice
+	SyncInterval         time.Duration
+	SyncWindow           time.Duration
+	SyncPubsubTopics     cli.StringSlice
+	SyncNodes            cli.StringSlice
+	RateLimit            float64
+	RateBurst            int
+	MaxConcurrentQueries int
+	MaxQueryWindow       time.Duration
+	MaxContentFilters    int
+	MaxPageSize          uint64
 }
 
 // SwapOptions are settings used for configuring the swap protocol
@@ -96,6 +115,9 @@ type RPCServerOptions struct {
 	Address string
 	Admin   bool
 	Private bool
+	// AdminTokenFile is the path of a file with the token required by the
+	// admin methods that modify the node, like the deletion of stored messages
+	AdminTokenFile string
 }
 
 type WSOptions struct {
@@ -121,6 +143,7 @@ type Options struct {
 	KeepAlive        int
 	UseDB            bool
 	DBPath           string
+	DBURL            string
 	AdvertiseAddress string
 	Version          bool
 	ShowAddresses    bool
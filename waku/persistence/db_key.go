@@ -4,6 +4,8 @@ import (
 	"crypto/sha256"
 	"encoding/binary"
 	"errors"
+
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
 )
 
 const (
@@ -48,3 +50,11 @@ func NewDBKey(timestamp uint64, pubsubTopic string, digest []byte) *DBKey {
 
 	return &k
 }
+
+// legacyDigest returns the digest used in the cursors of the messages before the
+// canonical message hash was used as digest. It only covers the content topic and
+// the payload, so it's only used to resolve cursors issued by older versions
+func legacyDigest(msg *pb.WakuMessage) []byte {
+	hash := sha256.Sum256(append([]byte(msg.ContentTopic), msg.Payload...))
+	return hash[:]
+}
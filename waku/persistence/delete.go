@@ -0,0 +1,528 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"go.uber.org/zap"
+)
+
+// ErrDeletedMessage is returned when a message is not stored because it was deleted
+// with a tombstone
+var ErrDeletedMessage = errors.New("message was deleted")
+
+// ErrNoDeletionCriteria is returned when a deletion does not select any message, to
+// avoid wiping the store by mistake
+var ErrNoDeletionCriteria = errors.New("no deletion criteria")
+
+// deleteHashesChunkSize is the maximum number of hashes deleted by a single statement,
+// to stay below the limit of parameters of the database
+const deleteHashesChunkSize = 500
+
+// auditDeletion logs the deletion of stored messages by an operator. Retention
+// deletions are not logged here
+func auditDeletion(log *zap.Logger, deleted int64, tombstone bool, criteria ...zap.Field) {
+	log.Named("audit").Info("deleted messages", append(criteria, zap.Int64("count", deleted), zap.Bool("tombstone", tombstone))...)
+}
+
+// DeleteByHashes removes the stored messages whose hash is in a list, and returns the
+// number of messages removed. If tombstone is set, the hashes are kept so the messages
+// are not stored again, even if they were not stored yet
+func (d *DBStore) DeleteByHashes(hashes [][]byte, tombstone bool) (int64, error) {
+	if len(hashes) == 0 {
+		return 0, nil
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	for start := 0; start < len(hashes); start += deleteHashesChunkSize {
+		end := start + deleteHashesChunkSize
+		if end > len(hashes) {
+			end = len(hashes)
+		}
+		chunk := hashes[start:end]
+
+		placeholders := make([]string, len(chunk))
+		parameters := make([]interface{}, len(chunk))
+		for i, h := range chunk {
+			placeholders[i] = "?"
+			parameters[i] = h
+		}
+
+		n, err := d.deleteInTx(tx, fmt.Sprintf("messageHash IN (%s)", strings.Join(placeholders, ", ")), parameters, tombstone, chunk)
+		if err != nil {
+			_ = tx.Rollback()
+			return 0, err
+		}
+		deleted += n
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	if tombstone {
+		atomic.StoreInt32(&d.hasTombstones, 1)
+	}
+
+	auditDeletion(d.log, deleted, tombstone, zap.Int("hashes", len(hashes)))
+
+	return deleted, nil
+}
+
+// DeleteByContentTopic removes every stored message with a content topic, and returns
+// the number of messages removed. If tombstone is set, their hashes are kept so the
+// messages are not stored again
+func (d *DBStore) DeleteByContentTopic(contentTopic string, tombstone bool) (int64, error) {
+	if contentTopic == "" {
+		return 0, ErrNoDeletionCriteria
+	}
+
+	deleted, err := d.deleteWhere("contentTopic = ?", []interface{}{d.contentTopicColumn(contentTopic)}, tombstone, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	auditDeletion(d.log, deleted, tombstone, zap.String("contentTopic", contentTopic))
+
+	return deleted, nil
+}
+
+// DeleteByTimeRange removes the stored messages whose sender timestamp is between
+// startTime and endTime, both included, and returns the number of messages removed.
+// A time of 0 leaves the range open on that side, but at least one of them must be
+// set. If tombstone is set, their hashes are kept so the messages are not stored again
+func (d *DBStore) DeleteByTimeRange(startTime int64, endTime int64, tombstone bool) (int64, error) {
+	if startTime == 0 && endTime == 0 {
+		return 0, ErrNoDeletionCriteria
+	}
+
+	var conditions []string
+	var parameters []interface{}
+	if startTime != 0 {
+		conditions = append(conditions, "senderTimestamp >= ?")
+		parameters = append(parameters, startTime)
+	}
+	if endTime != 0 {
+		conditions = append(conditions, "senderTimestamp <= ?")
+		parameters = append(parameters, endTime)
+	}
+
+	deleted, err := d.deleteWhere(strings.Join(conditions, " AND "), parameters, tombstone, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	auditDeletion(d.log, deleted, tombstone, zap.Int64("startTime", startTime), zap.Int64("endTime", endTime))
+
+	return deleted, nil
+}
+
+// deleteWhere removes the messages that match a SQL condition in a single transaction,
+// after adding a tombstone for each of them and for the hashes of a list, if requested
+func (d *DBStore) deleteWhere(condition string, parameters []interface{}, tombstone bool, hashes [][]byte) (int64, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := d.deleteInTx(tx, condition, parameters, tombstone, hashes)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	if tombstone {
+		atomic.StoreInt32(&d.hasTombstones, 1)
+	}
+
+	return deleted, nil
+}
+
+// deleteInTx removes the stored messages that match a SQL condition within a
+// transaction, adding their tombstones first if tombstone is set
+func (d *DBStore) deleteInTx(tx *sql.Tx, condition string, parameters []interface{}, tombstone bool, hashes [][]byte) (int64, error) {
+	if tombstone {
+		err := d.insertTombstones(tx, condition, parameters, hashes)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	result, err := tx.Exec(d.dialect.Rebind("DELETE FROM message WHERE "+condition), parameters...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// insertTombstones adds a tombstone for each hash of a list, or, if it's nil, for each
+// message that matches a SQL condition
+func (d *DBStore) insertTombstones(tx *sql.Tx, condition string, parameters []interface{}, hashes [][]byte) error {
+	now := utils.GetUnixEpoch()
+	if hashes == nil {
+		_, err := tx.Exec(d.dialect.Rebind(fmt.Sprintf("INSERT INTO tombstone (messageHash, deletedAt) SELECT messageHash, CAST(? AS BIGINT) FROM message WHERE %s AND messageHash IS NOT NULL ON CONFLICT DO NOTHING", condition)), append([]interface{}{now}, parameters...)...)
+		return err
+	}
+
+	for _, h := range hashes {
+		_, err := tx.Exec(d.dialect.Rebind("INSERT INTO tombstone (messageHash, deletedAt) VALUES (?, ?) ON CONFLICT DO NOTHING"), h, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadTombstones checks whether messages were deleted with a tombstone, so the
+// tombstones are only looked up when storing messages if there are any
+func (d *DBStore) loadTombstones() error {
+	var exists bool
+	err := d.db.QueryRow("SELECT EXISTS(SELECT 1 FROM tombstone)").Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		atomic.StoreInt32(&d.hasTombstones, 1)
+	}
+
+	return nil
+}
+
+// Tombstoned returns, for each hash of a list, whether its message was deleted with
+// a tombstone
+func (d *DBStore) Tombstoned(hashes [][]byte) ([]bool, error) {
+	result := make([]bool, len(hashes))
+	if len(hashes) == 0 || atomic.LoadInt32(&d.hasTombstones) == 0 {
+		return result, nil
+	}
+
+	placeholders := make([]string, len(hashes))
+	parameters := make([]interface{}, len(hashes))
+	for i, h := range hashes {
+		placeholders[i] = "?"
+		parameters[i] = h
+	}
+
+	rows, err := d.readDB.Query(d.dialect.Rebind(fmt.Sprintf("SELECT messageHash FROM tombstone WHERE messageHash IN (%s)", strings.Join(placeholders, ", "))), parameters...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deleted := make(map[string]struct{})
+	for rows.Next() {
+		var h []byte
+		if err := rows.Scan(&h); err != nil {
+			return nil, err
+		}
+		deleted[string(h)] = struct{}{}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, h := range hashes {
+		_, result[i] = deleted[string(h)]
+	}
+
+	return result, nil
+}
+
+// rejectDeleted sets ErrDeletedMessage as the error of the messages of a list that
+// were deleted with a tombstone, unless they already have an error
+func (d *DBStore) rejectDeleted(envs []*protocol.Envelope, errs []error) error {
+	if atomic.LoadInt32(&d.hasTombstones) == 0 {
+		return nil
+	}
+
+	hashes := make([][]byte, len(envs))
+	for i, env := range envs {
+		hashes[i] = env.Hash()
+	}
+
+	deleted, err := d.Tombstoned(hashes)
+	if err != nil {
+		return err
+	}
+
+	for i := range envs {
+		if deleted[i] && errs[i] == nil {
+			errs[i] = ErrDeletedMessage
+		}
+	}
+
+	return nil
+}
+
+// DeleteByHashes removes the stored messages whose hash is in a list, and returns the
+// number of messages removed. If tombstone is set, the hashes are kept so the messages
+// are not stored again, even if they were not stored yet
+func (m *MemoryStore) DeleteByHashes(hashes [][]byte, tombstone bool) (int64, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	var deleted int64
+	for _, h := range hashes {
+		if msg, ok := m.byHash[string(h)]; ok {
+			m.remove(msg)
+			deleted++
+		}
+		if tombstone {
+			m.addTombstone(h)
+		}
+	}
+
+	auditDeletion(m.log, deleted, tombstone, zap.Int("hashes", len(hashes)))
+
+	return deleted, nil
+}
+
+// DeleteByContentTopic removes every stored message with a content topic, and returns
+// the number of messages removed. If tombstone is set, their hashes are kept so the
+// messages are not stored again
+func (m *MemoryStore) DeleteByContentTopic(contentTopic string, tombstone bool) (int64, error) {
+	if contentTopic == "" {
+		return 0, ErrNoDeletionCriteria
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	messages := append([]*memoryMessage{}, m.byContentTopic[contentTopic]...)
+	m.removeMessages(messages, tombstone)
+
+	auditDeletion(m.log, int64(len(messages)), tombstone, zap.String("contentTopic", contentTopic))
+
+	return int64(len(messages)), nil
+}
+
+// DeleteByTimeRange removes the stored messages whose sender timestamp is between
+// startTime and endTime, both included, and returns the number of messages removed.
+// A time of 0 leaves the range open on that side, but at least one of them must be
+// set. If tombstone is set, their hashes are kept so the messages are not stored again
+func (m *MemoryStore) DeleteByTimeRange(startTime int64, endTime int64, tombstone bool) (int64, error) {
+	if startTime == 0 && endTime == 0 {
+		return 0, ErrNoDeletionCriteria
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	var messages []*memoryMessage
+	for _, msg := range m.messages {
+		if (startTime == 0 || msg.Message.Timestamp >= startTime) && (endTime == 0 || msg.Message.Timestamp <= endTime) {
+			messages = append(messages, msg)
+		}
+	}
+	m.removeMessages(messages, tombstone)
+
+	auditDeletion(m.log, int64(len(messages)), tombstone, zap.Int64("startTime", startTime), zap.Int64("endTime", endTime))
+
+	return int64(len(messages)), nil
+}
+
+func (m *MemoryStore) removeMessages(messages []*memoryMessage, tombstone bool) {
+	for _, msg := range messages {
+		m.remove(msg)
+		if tombstone {
+			m.addTombstone(msg.hash)
+		}
+	}
+}
+
+func (m *MemoryStore) addTombstone(hash []byte) {
+	if m.tombstones == nil {
+		m.tombstones = make(map[string]struct{})
+	}
+	m.tombstones[string(hash)] = struct{}{}
+}
+
+// Tombstoned returns, for each hash of a list, whether its message was deleted with
+// a tombstone
+func (m *MemoryStore) Tombstoned(hashes [][]byte) ([]bool, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	result := make([]bool, len(hashes))
+	for i, h := range hashes {
+		_, result[i] = m.tombstones[string(h)]
+	}
+	return result, nil
+}
+
+// DeleteByHashes removes the stored messages whose hash is in a list, and returns the
+// number of messages removed. If tombstone is set, the hashes are kept so the messages
+// are not stored again, even if they were not stored yet
+func (l *LevelDBStore) DeleteByHashes(hashes [][]byte, tombstone bool) (int64, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	var dbKeys [][]byte
+	for _, h := range hashes {
+		dbKey, err := l.db.Get(keyWithPrefix(levelDBMessageHashPrefix, h), nil)
+		if err == leveldb.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		dbKeys = append(dbKeys, dbKey)
+	}
+
+	deleted, err := l.deleteMessages(dbKeys, tombstone, hashes)
+	if err != nil {
+		return 0, err
+	}
+
+	auditDeletion(l.log, deleted, tombstone, zap.Int("hashes", len(hashes)))
+
+	return deleted, nil
+}
+
+// DeleteByContentTopic removes every stored message with a content topic, and returns
+// the number of messages removed. If tombstone is set, their hashes are kept so the
+// messages are not stored again
+func (l *LevelDBStore) DeleteByContentTopic(contentTopic string, tombstone bool) (int64, error) {
+	if contentTopic == "" {
+		return 0, ErrNoDeletionCriteria
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	prefix := keyWithPrefix(levelDBContentTopicPrefix, topicHash(contentTopic))
+	dbKeys, err := l.keysInRange(util.BytesPrefix(prefix), len(prefix))
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := l.deleteMessages(dbKeys, tombstone, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	auditDeletion(l.log, deleted, tombstone, zap.String("contentTopic", contentTopic))
+
+	return deleted, nil
+}
+
+// DeleteByTimeRange removes the stored messages whose sender timestamp is between
+// startTime and endTime, both included, and returns the number of messages removed.
+// A time of 0 leaves the range open on that side, but at least one of them must be
+// set. If tombstone is set, their hashes are kept so the messages are not stored again
+func (l *LevelDBStore) DeleteByTimeRange(startTime int64, endTime int64, tombstone bool) (int64, error) {
+	if startTime == 0 && endTime == 0 {
+		return 0, ErrNoDeletionCriteria
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	// DBKeys start with the sender timestamp
+	rng := util.BytesPrefix(levelDBMessagePrefix)
+	rng.Start = keyWithPrefix(levelDBMessagePrefix, receiverTimeBytes(startTime))
+	if endTime != 0 {
+		rng.Limit = keyWithPrefix(levelDBMessagePrefix, receiverTimeBytes(endTime+1))
+	}
+
+	dbKeys, err := l.keysInRange(rng, len(levelDBMessagePrefix))
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := l.deleteMessages(dbKeys, tombstone, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	auditDeletion(l.log, deleted, tombstone, zap.Int64("startTime", startTime), zap.Int64("endTime", endTime))
+
+	return deleted, nil
+}
+
+// keysInRange returns the DBKeys at the end of the keys of a range, after the
+// first prefixLength bytes
+func (l *LevelDBStore) keysInRange(rng *util.Range, prefixLength int) ([][]byte, error) {
+	iter := l.db.NewIterator(rng, nil)
+	defer iter.Release()
+
+	var result [][]byte
+	for iter.Next() {
+		result = append(result, append([]byte{}, iter.Key()[prefixLength:]...))
+	}
+
+	return result, iter.Error()
+}
+
+// deleteMessages removes the messages of a list of DBKeys with a single write, after
+// adding a tombstone for each of them and for the hashes of a list, if requested
+func (l *LevelDBStore) deleteMessages(dbKeys [][]byte, tombstone bool, hashes [][]byte) (int64, error) {
+	now := receiverTimeBytes(utils.GetUnixEpoch())
+
+	batch := new(leveldb.Batch)
+	for _, dbKey := range dbKeys {
+		if tombstone {
+			storedMsg, err := l.getStoredMessage(dbKey)
+			if err != nil {
+				return 0, err
+			}
+			batch.Put(keyWithPrefix(levelDBTombstonePrefix, protocol.NewEnvelope(storedMsg.Message, storedMsg.ReceiverTime, storedMsg.PubsubTopic).Hash()), now)
+		}
+
+		err := l.deleteBatch(batch, dbKey)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if tombstone {
+		for _, h := range hashes {
+			batch.Put(keyWithPrefix(levelDBTombstonePrefix, h), now)
+		}
+	}
+
+	err := l.db.Write(batch, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	l.count -= len(dbKeys)
+
+	return int64(len(dbKeys)), nil
+}
+
+// Tombstoned returns, for each hash of a list, whether its message was deleted with
+// a tombstone
+func (l *LevelDBStore) Tombstoned(hashes [][]byte) ([]bool, error) {
+	result := make([]bool, len(hashes))
+	for i, h := range hashes {
+		var err error
+		result[i], err = l.db.Has(keyWithPrefix(levelDBTombstonePrefix, h), nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
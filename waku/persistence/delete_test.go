@@ -0,0 +1,152 @@
+package persistence
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelete(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+
+	dbStore, err := NewDBStore(utils.Logger(), WithDB(db))
+	require.NoError(t, err)
+	defer dbStore.Stop()
+
+	levelDBStore, err := NewLevelDBStore(utils.Logger(), WithLevelDBPath(t.TempDir()))
+	require.NoError(t, err)
+	defer levelDBStore.Stop()
+
+	providers := map[string]MessageProvider{
+		"db":      dbStore,
+		"memory":  NewMemoryStore(utils.Logger()),
+		"leveldb": levelDBStore,
+	}
+
+	for name, p := range providers {
+		t.Run(name, func(t *testing.T) {
+			var envs []*protocol.Envelope
+			for i := 1; i <= 10; i++ {
+				contentTopic := "even"
+				if i%2 == 1 {
+					contentTopic = "odd"
+				}
+				env := protocol.NewEnvelope(tests.CreateWakuMessage(contentTopic, int64(i*10)), utils.GetUnixEpoch(), "test")
+				envs = append(envs, env)
+			}
+			for _, err := range p.PutBatch(envs[:9]) {
+				require.NoError(t, err)
+			}
+
+			timestamps := func() []int64 {
+				var result []int64
+				err := p.Iterate(&pb.HistoryQuery{}, func(msg StoredMessage) error {
+					result = append(result, msg.Message.Timestamp)
+					return nil
+				})
+				require.NoError(t, err)
+				return result
+			}
+
+			// The last message is not stored yet, but its tombstone prevents storing it
+			deleted, err := p.DeleteByHashes([][]byte{envs[0].Hash(), envs[9].Hash()}, true)
+			require.NoError(t, err)
+			require.Equal(t, int64(1), deleted)
+			require.Equal(t, []int64{20, 30, 40, 50, 60, 70, 80, 90}, timestamps())
+			require.ErrorIs(t, p.Put(envs[9]), ErrDeletedMessage)
+			require.Equal(t, []error{ErrDeletedMessage, ErrDuplicateMessage}, p.PutBatch([]*protocol.Envelope{envs[0], envs[1]}))
+
+			deleted, err = p.DeleteByContentTopic("even", false)
+			require.NoError(t, err)
+			require.Equal(t, int64(4), deleted)
+			require.Equal(t, []int64{30, 50, 70, 90}, timestamps())
+
+			// Messages deleted without a tombstone can be stored again
+			require.NoError(t, p.Put(envs[1]))
+
+			deleted, err = p.DeleteByTimeRange(20, 50, true)
+			require.NoError(t, err)
+			require.Equal(t, int64(3), deleted)
+			require.Equal(t, []int64{70, 90}, timestamps())
+
+			deleted, err = p.DeleteByTimeRange(80, 0, false)
+			require.NoError(t, err)
+			require.Equal(t, int64(1), deleted)
+			require.Equal(t, []int64{70}, timestamps())
+
+			tombstoned, err := p.Tombstoned([][]byte{envs[0].Hash(), envs[1].Hash(), envs[2].Hash(), envs[3].Hash(), envs[6].Hash()})
+			require.NoError(t, err)
+			require.Equal(t, []bool{true, true, true, false, false}, tombstoned)
+
+			_, err = p.DeleteByTimeRange(0, 0, false)
+			require.ErrorIs(t, err, ErrNoDeletionCriteria)
+			_, err = p.DeleteByContentTopic("", false)
+			require.ErrorIs(t, err, ErrNoDeletionCriteria)
+		})
+	}
+}
+
+func TestDeleteTombstonesPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+
+	open := func() *DBStore {
+		db, err := sql.Open("sqlite3", path)
+		require.NoError(t, err)
+		dbStore, err := NewDBStore(utils.Logger(), WithDB(db))
+		require.NoError(t, err)
+		return dbStore
+	}
+
+	env := protocol.NewEnvelope(tests.CreateWakuMessage("test", 1), utils.GetUnixEpoch(), "test")
+
+	dbStore := open()
+	require.NoError(t, dbStore.Put(env))
+	deleted, err := dbStore.DeleteByHashes([][]byte{env.Hash()}, true)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+	dbStore.Stop()
+
+	dbStore = open()
+	defer dbStore.Stop()
+	require.ErrorIs(t, dbStore.Put(env), ErrDeletedMessage)
+}
+
+func TestDeleteByHashesChunks(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+
+	dbStore, err := NewDBStore(utils.Logger(), WithDB(db))
+	require.NoError(t, err)
+	defer dbStore.Stop()
+
+	// More hashes than a single statement deletes
+	var envs []*protocol.Envelope
+	var hashes [][]byte
+	for i := 1; i <= 2*deleteHashesChunkSize+1; i++ {
+		env := protocol.NewEnvelope(tests.CreateWakuMessage("test", int64(i)), utils.GetUnixEpoch(), "test")
+		envs = append(envs, env)
+		hashes = append(hashes, env.Hash())
+	}
+	for _, err := range dbStore.PutBatch(envs[1:]) {
+		require.NoError(t, err)
+	}
+
+	deleted, err := dbStore.DeleteByHashes(hashes, true)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(envs)-1), deleted)
+
+	stored, err := dbStore.GetAll()
+	require.NoError(t, err)
+	require.Empty(t, stored)
+
+	tombstoned, err := dbStore.Tombstoned([][]byte{hashes[0], hashes[len(hashes)-1]})
+	require.NoError(t, err)
+	require.Equal(t, []bool{true, true}, tombstoned)
+}
@@ -0,0 +1,120 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+
+	"github.com/status-im/go-waku/waku/persistence/migrations"
+)
+
+// Dialect contains the behavior of a DBStore that is specific to the database
+// used to store the messages
+type Dialect interface {
+	// Setup configures a DB connection before the migrations are applied
+	Setup(db *sql.DB) error
+	// Migrate creates or updates the tables used by a DBStore
+	Migrate(db *sql.DB) error
+	// ReadDB returns the connection pool used by the queries that only read messages,
+	// which can be the same DB used to write them
+	ReadDB(db *sql.DB) (*sql.DB, error)
+	// Rebind replaces the `?` placeholders of a query by the ones used by the database
+	Rebind(query string) string
+	// NoLimit returns the value of a LIMIT clause that does not limit the number of rows
+	NoLimit() string
+}
+
+// SQLiteReadConns is the maximum number of connections used to read the messages
+// of a SQLite DB stored in a file
+const SQLiteReadConns = 4
+
+// dsnConnector opens connections with a driver and a data source name
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// SQLiteDialect is the Dialect used by default in a DBStore
+type SQLiteDialect struct{}
+
+// sqliteFile returns the path of the file of a SQLite DB, which is empty for in-memory DBs
+func sqliteFile(db *sql.DB) (string, error) {
+	var seq string
+	var name string
+	var file string
+	err := db.QueryRow("PRAGMA database_list").Scan(&seq, &name, &file)
+	return file, err
+}
+
+// Setup disables concurrent access to the DB, and enables WAL mode
+func (SQLiteDialect) Setup(db *sql.DB) error {
+	// Disable concurrent writes as not supported by the driver
+	db.SetMaxOpenConns(1)
+
+	file, err := sqliteFile(db)
+	if err != nil {
+		return err
+	}
+
+	// readers do not block writers and faster i/o operations
+	// https://www.sqlite.org/draft/wal.html
+	// must be set after db is encrypted
+	var mode string
+	err = db.QueryRow("PRAGMA journal_mode=WAL").Scan(&mode)
+	if err != nil {
+		return err
+	}
+	if mode != WALMode && file != "" {
+		return fmt.Errorf("unable to set journal_mode to WAL. actual mode %s", mode)
+	}
+
+	return nil
+}
+
+// Migrate applies the SQLite migrations
+func (SQLiteDialect) Migrate(db *sql.DB) error {
+	return migrations.Migrate(db)
+}
+
+// ReadDB opens a separate pool of read-only connections to the file of the DB, so
+// queries are not blocked by writes, which is possible thanks to WAL mode. The DB
+// itself is returned for in-memory DBs, since they can't be shared by connections
+func (SQLiteDialect) ReadDB(db *sql.DB) (*sql.DB, error) {
+	file, err := sqliteFile(db)
+	if err != nil {
+		return nil, err
+	}
+
+	if file == "" {
+		return db, nil
+	}
+
+	// The path is escaped, since `?`, `#` and `%` have a special meaning in URIs
+	dsn := (&url.URL{Scheme: "file", Path: file, RawQuery: "mode=ro"}).String()
+
+	// The driver of the DB is used, since it may be registered with a different name
+	readDB := sql.OpenDB(dsnConnector{dsn: dsn, driver: db.Driver()})
+	readDB.SetMaxOpenConns(SQLiteReadConns)
+
+	return readDB, nil
+}
+
+// Rebind returns the query unchanged, since SQLite supports `?` placeholders
+func (SQLiteDialect) Rebind(query string) string {
+	return query
+}
+
+// NoLimit returns -1, which is interpreted by SQLite as no limit
+func (SQLiteDialect) NoLimit() string {
+	return "-1"
+}
@@ -0,0 +1,388 @@
+package persistence
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"go.uber.org/zap"
+)
+
+// EncryptionKeyLength is the length in bytes of the keys used to encrypt a DBStore
+const EncryptionKeyLength = 32
+
+var (
+	// ErrInvalidEncryptionKey is returned when an encryption key does not have the
+	// right length, or it's not the key used to encrypt the messages of a DB
+	ErrInvalidEncryptionKey = errors.New("invalid encryption key")
+
+	// ErrEncryptionKeyRequired is returned when a DB with encrypted messages is used
+	// without specifying the encryption key
+	ErrEncryptionKeyRequired = errors.New("the messages are encrypted, an encryption key is required")
+)
+
+// encryptionKeyCheckName is the name of the row of the store_metadata table that
+// is used to verify the encryption key of a DB
+const encryptionKeyCheckName = "encryptionKeyCheck"
+
+// WithEncryptionKey is a DBOption that encrypts the stored messages with AES-256-GCM.
+// Content topics and pubsub topics are replaced by a keyed hash, so the messages can
+// still be queried by topic. Timestamps are kept in plaintext, since they are used to
+// sort the messages and to query them by time. Messages stored in plaintext are
+// encrypted when the DBStore is created
+func WithEncryptionKey(key []byte) DBOption {
+	return func(d *DBStore) error {
+		c, err := newMessageCipher(key)
+		if err != nil {
+			return err
+		}
+		d.cipher = c
+		return nil
+	}
+}
+
+// messageCipher encrypts the messages of a DBStore, and calculates the keyed hash of
+// their topics. Both use keys derived from the encryption key of the DB
+type messageCipher struct {
+	aead     cipher.AEAD
+	indexKey []byte
+}
+
+func deriveKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+func newMessageCipher(key []byte) (*messageCipher, error) {
+	if len(key) != EncryptionKeyLength {
+		return nil, fmt.Errorf("%w: it must be %d bytes long", ErrInvalidEncryptionKey, EncryptionKeyLength)
+	}
+
+	block, err := aes.NewCipher(deriveKey(key, "go-waku store encryption"))
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &messageCipher{
+		aead:     aead,
+		indexKey: deriveKey(key, "go-waku store index"),
+	}, nil
+}
+
+// seal encrypts data, prepending the random nonce to the ciphertext
+func (c *messageCipher) seal(data []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return c.aead.Seal(nonce, nonce, data, nil), nil
+}
+
+func (c *messageCipher) open(data []byte) ([]byte, error) {
+	if len(data) < c.aead.NonceSize() {
+		return nil, ErrInvalidEncryptionKey
+	}
+
+	nonce := data[:c.aead.NonceSize()]
+	result, err := c.aead.Open(nil, nonce, data[c.aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrInvalidEncryptionKey
+	}
+
+	return result, nil
+}
+
+// topicIndex returns the hex encoded keyed hash stored instead of a topic
+func (c *messageCipher) topicIndex(kind string, topic string) string {
+	mac := hmac.New(sha256.New, c.indexKey)
+	mac.Write([]byte(kind))
+	mac.Write([]byte{0})
+	mac.Write([]byte(topic))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// keyCheck returns a value stored in the DB to verify that the right key is used
+func (c *messageCipher) keyCheck() []byte {
+	return deriveKey(c.indexKey, "key check")
+}
+
+// pubsubTopicColumn returns the value of the pubsubTopic column of a message
+func (d *DBStore) pubsubTopicColumn(pubsubTopic string) string {
+	if d.cipher == nil {
+		return pubsubTopic
+	}
+	return d.cipher.topicIndex("pubsubTopic", pubsubTopic)
+}
+
+// contentTopicColumn returns the value of the contentTopic column of a message
+func (d *DBStore) contentTopicColumn(contentTopic string) string {
+	if d.cipher == nil {
+		return contentTopic
+	}
+	return d.cipher.topicIndex("contentTopic", contentTopic)
+}
+
+// dbKey returns the id of a message. The hash of the pubsub topic it contains is
+// calculated from the value of the pubsubTopic column
+func (d *DBStore) dbKey(senderTime int64, pubsubTopic string, digest []byte) *DBKey {
+	return NewDBKey(uint64(senderTime), d.pubsubTopicColumn(pubsubTopic), digest)
+}
+
+// messageColumns contains the values of the columns of a message that depend on
+// whether the DB is encrypted
+type messageColumns struct {
+	id           []byte
+	contentTopic string
+	pubsubTopic  string
+	payload      []byte
+	rawMessage   []byte
+}
+
+// encodeMessage returns the values of the columns used to store a message. When the
+// DB is encrypted, the message and its pubsub topic are only stored in rawMessage
+func (d *DBStore) encodeMessage(msg *pb.WakuMessage, pubsubTopic string, digest []byte) (messageColumns, error) {
+	result := messageColumns{
+		id:           d.dbKey(msg.Timestamp, pubsubTopic, digest).Bytes(),
+		contentTopic: d.contentTopicColumn(msg.ContentTopic),
+		pubsubTopic:  d.pubsubTopicColumn(pubsubTopic),
+	}
+
+	if d.cipher == nil {
+		// The serialized message is stored so every field, including the proof and any
+		// field unknown to this version, is returned as it was received
+		rawMessage, err := msg.Marshal()
+		if err != nil {
+			return messageColumns{}, err
+		}
+		result.payload = msg.Payload
+		result.rawMessage = rawMessage
+		return result, nil
+	}
+
+	data, err := (&pb.StoredWakuMessage{Message: msg, PubsubTopic: pubsubTopic}).Marshal()
+	if err != nil {
+		return messageColumns{}, err
+	}
+
+	result.rawMessage, err = d.cipher.seal(data)
+	if err != nil {
+		return messageColumns{}, err
+	}
+
+	return result, nil
+}
+
+// decryptMessage decrypts the rawMessage column of an encrypted DB, returning the
+// message and its pubsub topic
+func (d *DBStore) decryptMessage(rawMessage []byte) (*pb.WakuMessage, string, error) {
+	data, err := d.cipher.open(rawMessage)
+	if err != nil {
+		return nil, "", err
+	}
+
+	storedMsg := new(pb.StoredWakuMessage)
+	err = storedMsg.Unmarshal(data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if storedMsg.Message == nil {
+		storedMsg.Message = new(pb.WakuMessage)
+	}
+
+	return storedMsg.Message, storedMsg.PubsubTopic, nil
+}
+
+// setupEncryption verifies that the encryption key is the one used to encrypt the
+// messages of the DB, and encrypts them if they are stored in plaintext
+func (d *DBStore) setupEncryption() error {
+	var keyCheck []byte
+	err := d.db.QueryRow(d.dialect.Rebind("SELECT value FROM store_metadata WHERE name = ?"), encryptionKeyCheckName).Scan(&keyCheck)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	encrypted := err == nil
+	switch {
+	case !encrypted && d.cipher == nil:
+		return nil
+	case encrypted && d.cipher == nil:
+		return ErrEncryptionKeyRequired
+	case encrypted:
+		if !hmac.Equal(keyCheck, d.cipher.keyCheck()) {
+			return ErrInvalidEncryptionKey
+		}
+		return nil
+	default:
+		c := d.cipher
+		d.cipher = nil
+		return d.reencrypt(c)
+	}
+}
+
+// RotateEncryptionKey encrypts the stored messages with a new key. If the key is nil,
+// the messages are decrypted and stored in plaintext
+func (d *DBStore) RotateEncryptionKey(newKey []byte) error {
+	var c *messageCipher
+	if newKey != nil {
+		var err error
+		c, err = newMessageCipher(newKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	return d.reencrypt(c)
+}
+
+// reencryptPageSize is the number of messages read at a time while they are
+// reencrypted, which bounds the memory used to reencrypt a DB
+const reencryptPageSize = IterationPageSize
+
+// reencrypt replaces, in a single transaction, the columns of every message by the
+// ones calculated with a different cipher, which is nil to store them in plaintext.
+// The messages are read and updated in pages. The ids change with the cipher, so the
+// messages are sorted by hash, which does not change. The messages stored by older
+// versions, without a hash, are updated last: they get one and are not read again
+func (d *DBStore) reencrypt(c *messageCipher) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(d.dialect.Rebind("UPDATE message SET id = ?, contentTopic = ?, pubsubTopic = ?, payload = ?, rawMessage = ?, messageHash = ? WHERE id = ? AND pubsubTopic = ?"))
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	// Only used to calculate the columns with the new cipher
+	target := &DBStore{cipher: c}
+
+	count := 0
+	lastHash := []byte{}
+	for {
+		n, last, err := d.reencryptPage(tx, stmt, target, "WHERE messageHash > ? ORDER BY messageHash", lastHash)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		count += n
+		if n < reencryptPageSize {
+			break
+		}
+		lastHash = last
+	}
+
+	for {
+		n, _, err := d.reencryptPage(tx, stmt, target, "WHERE messageHash IS NULL ORDER BY id")
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		count += n
+		if n < reencryptPageSize {
+			break
+		}
+	}
+
+	_, err = tx.Exec(d.dialect.Rebind("DELETE FROM store_metadata WHERE name = ?"), encryptionKeyCheckName)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if c != nil {
+		_, err = tx.Exec(d.dialect.Rebind("INSERT INTO store_metadata (name, value) VALUES (?, ?)"), encryptionKeyCheckName, c.keyCheck())
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	d.cipher = c
+
+	d.log.Info("stored messages reencrypted", zap.Bool("encrypted", c != nil), zap.Int("count", count))
+
+	return nil
+}
+
+// reencryptPage reads up to reencryptPageSize messages selected by a condition, and
+// updates them with the columns calculated by target. The rows are read before they
+// are updated, since the transaction may only have a connection. It returns the
+// number of messages updated and the hash stored in the last one
+func (d *DBStore) reencryptPage(tx *sql.Tx, stmt *sql.Stmt, target *DBStore, condition string, parameters ...interface{}) (int, []byte, error) {
+	type reencryptedMessage struct {
+		id          []byte
+		pubsubTopic string
+		hash        []byte
+		columns     messageColumns
+	}
+
+	sqlQuery := fmt.Sprintf("SELECT id, receiverTimestamp, senderTimestamp, contentTopic, pubsubTopic, payload, version, rawMessage, messageHash FROM message %s LIMIT ?", condition)
+	rows, err := tx.Query(d.dialect.Rebind(sqlQuery), append(parameters, reencryptPageSize)...)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var storedHash []byte
+	messages := make([]reencryptedMessage, 0, reencryptPageSize)
+	for rows.Next() {
+		record, err := d.scanStoredMessage(rows, &storedHash)
+		if err != nil {
+			rows.Close()
+			return 0, nil, err
+		}
+
+		// The hash is calculated since it's not set yet for messages stored by older versions
+		hash := protocol.NewEnvelope(record.Message, record.ReceiverTime, record.PubsubTopic).Hash()
+		columns, err := target.encodeMessage(record.Message, record.PubsubTopic, hash)
+		if err != nil {
+			rows.Close()
+			return 0, nil, err
+		}
+
+		messages = append(messages, reencryptedMessage{
+			id:          record.ID,
+			pubsubTopic: d.pubsubTopicColumn(record.PubsubTopic),
+			hash:        hash,
+			columns:     columns,
+		})
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+
+	for _, m := range messages {
+		_, err := stmt.Exec(m.columns.id, m.columns.contentTopic, m.columns.pubsubTopic, m.columns.payload, m.columns.rawMessage, m.hash, m.id, m.pubsubTopic)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	return len(messages), storedHash, nil
+}
@@ -0,0 +1,161 @@
+package persistence
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreEncryption(t *testing.T) {
+	for _, b := range backends() {
+		t.Run(b.name, func(t *testing.T) {
+			db := b.newDB(t)
+			key := bytes.Repeat([]byte{1}, EncryptionKeyLength)
+			newKey := bytes.Repeat([]byte{2}, EncryptionKeyLength)
+
+			store, err := NewDBStore(utils.Logger(), WithDB(db), WithDialect(b.dialect))
+			require.NoError(t, err)
+
+			put := func(store *DBStore, contentTopic string, pubsubTopic string, ts int64) {
+				require.NoError(t, store.Put(protocol.NewEnvelope(tests.CreateWakuMessage(contentTopic, ts), ts, pubsubTopic)))
+			}
+			put(store, "a", "test", 10)
+			put(store, "b", "test", 20)
+
+			// The messages stored in plaintext are encrypted
+			store, err = NewDBStore(utils.Logger(), WithDB(db), WithDialect(b.dialect), WithEncryptionKey(key))
+			require.NoError(t, err)
+			put(store, "a", "other", 30)
+			put(store, "b", "test", 40)
+
+			query := func(store *DBStore, query *pb.HistoryQuery) []int64 {
+				query.PagingInfo = &pb.PagingInfo{PageSize: 10, Direction: pb.PagingInfo_FORWARD}
+				res, err := store.Query(query)
+				require.NoError(t, err)
+				var timestamps []int64
+				for _, r := range res {
+					timestamps = append(timestamps, r.Message.Timestamp)
+				}
+				return timestamps
+			}
+
+			require.Equal(t, []int64{10, 20, 30, 40}, query(store, &pb.HistoryQuery{}))
+			require.Equal(t, []int64{10, 20, 40}, query(store, &pb.HistoryQuery{PubsubTopic: "test"}))
+			require.Equal(t, []int64{20, 40}, query(store, &pb.HistoryQuery{ContentFilters: []*pb.ContentFilter{{ContentTopic: "b"}}}))
+			require.Equal(t, []int64{20, 30}, query(store, &pb.HistoryQuery{StartTime: 15, EndTime: 35}))
+
+			stats, err := store.Stats(&pb.HistoryQuery{})
+			require.NoError(t, err)
+			require.Len(t, stats.ContentTopics, 2)
+			require.Equal(t, "a", stats.ContentTopics[0].ContentTopic)
+			require.Equal(t, uint64(2), stats.ContentTopics[1].Count)
+
+			_, err = NewDBStore(utils.Logger(), WithDB(db), WithDialect(b.dialect))
+			require.ErrorIs(t, err, ErrEncryptionKeyRequired)
+
+			_, err = NewDBStore(utils.Logger(), WithDB(db), WithDialect(b.dialect), WithEncryptionKey(newKey))
+			require.ErrorIs(t, err, ErrInvalidEncryptionKey)
+
+			require.NoError(t, store.RotateEncryptionKey(newKey))
+			store, err = NewDBStore(utils.Logger(), WithDB(db), WithDialect(b.dialect), WithEncryptionKey(newKey))
+			require.NoError(t, err)
+			require.Equal(t, []int64{30}, query(store, &pb.HistoryQuery{PubsubTopic: "other"}))
+
+			// The messages are decrypted when the key is removed
+			require.NoError(t, store.RotateEncryptionKey(nil))
+			store, err = NewDBStore(utils.Logger(), WithDB(db), WithDialect(b.dialect))
+			require.NoError(t, err)
+			require.Equal(t, []int64{10, 20, 40}, query(store, &pb.HistoryQuery{PubsubTopic: "test"}))
+		})
+	}
+}
+
+func TestStoreEncryptionMultiplePages(t *testing.T) {
+	for _, b := range backends() {
+		t.Run(b.name, func(t *testing.T) {
+			db := b.newDB(t)
+
+			store, err := NewDBStore(utils.Logger(), WithDB(db), WithDialect(b.dialect))
+			require.NoError(t, err)
+
+			count := 2*reencryptPageSize + 1
+			var envs []*protocol.Envelope
+			for i := 1; i <= count; i++ {
+				envs = append(envs, protocol.NewEnvelope(tests.CreateWakuMessage(fmt.Sprintf("%d", i%3), int64(i)), int64(i), "test"))
+			}
+			for _, err := range store.PutBatch(envs) {
+				require.NoError(t, err)
+			}
+
+			// Messages stored by older versions don't have a hash
+			for i := 1; i <= 3; i++ {
+				legacyMsg := tests.CreateWakuMessage("legacy", int64(count+i))
+				rawMessage, err := legacyMsg.Marshal()
+				require.NoError(t, err)
+				_, err = db.Exec(b.dialect.Rebind("INSERT INTO message (id, receiverTimestamp, senderTimestamp, contentTopic, pubsubTopic, payload, version, rawMessage) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"),
+					NewDBKey(uint64(legacyMsg.Timestamp), "test", []byte{byte(i)}).Bytes(), legacyMsg.Timestamp, legacyMsg.Timestamp, "legacy", "test", legacyMsg.Payload, 0, rawMessage)
+				require.NoError(t, err)
+			}
+
+			checkMessages := func(store *DBStore) {
+				res, err := store.GetAll()
+				require.NoError(t, err)
+				require.Len(t, res, count+3)
+				for i, r := range res {
+					require.Equal(t, int64(i+1), r.Message.Timestamp)
+				}
+			}
+
+			key := bytes.Repeat([]byte{1}, EncryptionKeyLength)
+			store, err = NewDBStore(utils.Logger(), WithDB(db), WithDialect(b.dialect), WithEncryptionKey(key))
+			require.NoError(t, err)
+			checkMessages(store)
+
+			newKey := bytes.Repeat([]byte{2}, EncryptionKeyLength)
+			require.NoError(t, store.RotateEncryptionKey(newKey))
+			store, err = NewDBStore(utils.Logger(), WithDB(db), WithDialect(b.dialect), WithEncryptionKey(newKey))
+			require.NoError(t, err)
+			checkMessages(store)
+
+			require.NoError(t, store.RotateEncryptionKey(nil))
+			store, err = NewDBStore(utils.Logger(), WithDB(db), WithDialect(b.dialect))
+			require.NoError(t, err)
+			checkMessages(store)
+		})
+	}
+}
+
+func TestStoreEncryptionAtRest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+
+	store, err := NewDBStore(utils.Logger(), WithDB(db), WithEncryptionKey(bytes.Repeat([]byte{1}, EncryptionKeyLength)))
+	require.NoError(t, err)
+
+	msg := &pb.WakuMessage{Payload: []byte("secret payload"), ContentTopic: "/secret/content/topic", Timestamp: 1}
+	require.NoError(t, store.Put(protocol.NewEnvelope(msg, 1, "/secret/pubsub/topic")))
+	store.Stop()
+	require.NoError(t, db.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "secret")
+
+	_, err = NewDBStore(utils.Logger(), WithDB(NewMock()),
+		WithEncryptionKey(bytes.Repeat([]byte{1}, EncryptionKeyLength)),
+		WithRetentionRules(RetentionRule{Name: "telemetry", ContentTopicPrefix: "/telemetry/"}))
+	require.ErrorIs(t, err, ErrInvalidRetentionRule)
+
+	_, err = NewDBStore(utils.Logger(), WithDB(NewMock()), WithEncryptionKey([]byte{1}))
+	require.ErrorIs(t, err, ErrInvalidEncryptionKey)
+}
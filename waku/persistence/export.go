@@ -0,0 +1,232 @@
+package persistence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-msgio/protoio"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/utils"
+)
+
+// ExportFormat is the format of the files used to export and import stored messages
+type ExportFormat string
+
+const (
+	// FormatJSONL stores a JSON object per line, containing the message, its pubsub
+	// topic and its receiver time, with the same fields as pb.StoredWakuMessage
+	FormatJSONL ExportFormat = "jsonl"
+	// FormatProtobuf stores length-delimited pb.StoredWakuMessage, as written by protoio
+	FormatProtobuf ExportFormat = "protobuf"
+)
+
+// importBatchSize is the number of messages stored at a time by Import
+const importBatchSize = 500
+
+// MaxImportRecordSize is the maximum size of a record of a protobuf file, so a corrupt
+// file can't make the import allocate large buffers. It's the maximum size of a relayed
+// message, plus room for the pubsub topic and receiver time of the stored message
+const MaxImportRecordSize = pubsub.DefaultMaxMessageSize + 64*1024
+
+// ErrUnknownExportFormat is returned when a format is not one of the supported ExportFormat
+var ErrUnknownExportFormat = errors.New("unknown export format")
+
+// MessageWriter writes stored messages to a file
+type MessageWriter interface {
+	Write(msg StoredMessage) error
+}
+
+// MessageReader reads the messages of a file, returning io.EOF after the last one
+type MessageReader interface {
+	Read() (*pb.StoredWakuMessage, error)
+}
+
+type jsonlWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *jsonlWriter) Write(msg StoredMessage) error {
+	return w.encoder.Encode(&pb.StoredWakuMessage{Message: msg.Message, PubsubTopic: msg.PubsubTopic, ReceiverTime: msg.ReceiverTime})
+}
+
+type jsonlReader struct {
+	decoder *json.Decoder
+}
+
+func (r *jsonlReader) Read() (*pb.StoredWakuMessage, error) {
+	msg := new(pb.StoredWakuMessage)
+	err := r.decoder.Decode(msg)
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+type protobufWriter struct {
+	writer protoio.WriteCloser
+}
+
+func (w *protobufWriter) Write(msg StoredMessage) error {
+	return w.writer.WriteMsg(&pb.StoredWakuMessage{Message: msg.Message, PubsubTopic: msg.PubsubTopic, ReceiverTime: msg.ReceiverTime})
+}
+
+type protobufReader struct {
+	reader protoio.ReadCloser
+}
+
+func (r *protobufReader) Read() (*pb.StoredWakuMessage, error) {
+	msg := new(pb.StoredWakuMessage)
+	err := r.reader.ReadMsg(msg)
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// NewMessageWriter returns a MessageWriter that writes messages with a format
+func NewMessageWriter(w io.Writer, format ExportFormat) (MessageWriter, error) {
+	switch format {
+	case FormatJSONL:
+		return &jsonlWriter{encoder: json.NewEncoder(w)}, nil
+	case FormatProtobuf:
+		return &protobufWriter{writer: protoio.NewDelimitedWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownExportFormat, format)
+	}
+}
+
+// NewMessageReader returns a MessageReader that reads messages with a format
+func NewMessageReader(r io.Reader, format ExportFormat) (MessageReader, error) {
+	switch format {
+	case FormatJSONL:
+		return &jsonlReader{decoder: json.NewDecoder(r)}, nil
+	case FormatProtobuf:
+		return &protobufReader{reader: protoio.NewDelimitedReader(r, MaxImportRecordSize)}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownExportFormat, format)
+	}
+}
+
+// Export writes the messages that match the filters of a history query, in the order
+// of its paging info, and returns the number of messages written
+func Export(provider MessageProvider, query *pb.HistoryQuery, w MessageWriter) (int, error) {
+	count := 0
+	err := provider.Iterate(query, func(msg StoredMessage) error {
+		err := w.Write(msg)
+		if err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// ImportResult contains the number of messages read by Import, by outcome
+type ImportResult struct {
+	// Imported is the number of messages stored
+	Imported int `json:"imported"`
+	// Duplicates is the number of messages that were already stored
+	Duplicates int `json:"duplicates"`
+	// Deleted is the number of messages that were deleted with a tombstone
+	Deleted int `json:"deleted"`
+	// Filtered is the number of messages that don't match the filters of the query
+	Filtered int `json:"filtered"`
+}
+
+// matchesQuery returns whether a message matches the pubsub topic, content topics and
+// time range of a history query
+func matchesQuery(msg *pb.StoredWakuMessage, query *pb.HistoryQuery) bool {
+	if query.PubsubTopic != "" && msg.PubsubTopic != query.PubsubTopic {
+		return false
+	}
+
+	if query.StartTime != 0 && msg.Message.Timestamp < query.StartTime {
+		return false
+	}
+
+	if query.EndTime != 0 && msg.Message.Timestamp > query.EndTime {
+		return false
+	}
+
+	if len(query.ContentFilters) == 0 {
+		return true
+	}
+
+	for _, cf := range query.ContentFilters {
+		if cf.ContentTopic == msg.Message.ContentTopic {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Import stores the messages read from a MessageReader that match the filters of a
+// history query. Messages that were already stored, or deleted with a tombstone, are
+// skipped, so a file can be imported more than once. Messages without a receiver time
+// are stored as received now
+func Import(provider MessageProvider, r MessageReader, query *pb.HistoryQuery) (ImportResult, error) {
+	var result ImportResult
+	var batch []*protocol.Envelope
+
+	storeBatch := func() error {
+		for _, err := range provider.PutBatch(batch) {
+			switch err {
+			case nil:
+				result.Imported++
+			case ErrDuplicateMessage:
+				result.Duplicates++
+			case ErrDeletedMessage:
+				result.Deleted++
+			default:
+				return err
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		msg, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return result, err
+		}
+
+		if msg.Message == nil {
+			msg.Message = new(pb.WakuMessage)
+		}
+
+		if !matchesQuery(msg, query) {
+			result.Filtered++
+			continue
+		}
+
+		receiverTime := msg.ReceiverTime
+		if receiverTime == 0 {
+			receiverTime = utils.GetUnixEpoch()
+		}
+
+		batch = append(batch, protocol.NewEnvelope(msg.Message, receiverTime, msg.PubsubTopic))
+		if len(batch) == importBatchSize {
+			if err := storeBatch(); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	if len(batch) != 0 {
+		if err := storeBatch(); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
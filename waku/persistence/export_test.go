@@ -0,0 +1,113 @@
+package persistence
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-pubsub"
+
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImport(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+
+	src, err := NewDBStore(utils.Logger(), WithDB(db))
+	require.NoError(t, err)
+	defer src.Stop()
+
+	for i := 1; i <= 10; i++ {
+		contentTopic := "even"
+		if i%2 == 1 {
+			contentTopic = "odd"
+		}
+		require.NoError(t, src.Put(protocol.NewEnvelope(tests.CreateWakuMessage(contentTopic, int64(i*10)), int64(i*10+1), "test")))
+	}
+
+	for _, format := range []ExportFormat{FormatJSONL, FormatProtobuf} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewMessageWriter(&buf, format)
+			require.NoError(t, err)
+
+			count, err := Export(src, &pb.HistoryQuery{}, w)
+			require.NoError(t, err)
+			require.Equal(t, 10, count)
+
+			dst := NewMemoryStore(utils.Logger())
+			data := buf.Bytes()
+
+			r, err := NewMessageReader(bytes.NewReader(data), format)
+			require.NoError(t, err)
+			result, err := Import(dst, r, &pb.HistoryQuery{ContentFilters: []*pb.ContentFilter{{ContentTopic: "odd"}}, EndTime: 75})
+			require.NoError(t, err)
+			require.Equal(t, ImportResult{Imported: 4, Filtered: 6}, result)
+
+			// Importing the same file again only stores the messages that were filtered
+			r, err = NewMessageReader(bytes.NewReader(data), format)
+			require.NoError(t, err)
+			result, err = Import(dst, r, &pb.HistoryQuery{})
+			require.NoError(t, err)
+			require.Equal(t, ImportResult{Imported: 6, Duplicates: 4}, result)
+
+			expected, err := src.GetAll()
+			require.NoError(t, err)
+			imported, err := dst.GetAll()
+			require.NoError(t, err)
+			require.Len(t, imported, len(expected))
+			for i := range expected {
+				require.Equal(t, expected[i].Message, imported[i].Message)
+				require.Equal(t, expected[i].PubsubTopic, imported[i].PubsubTopic)
+				require.Equal(t, expected[i].ReceiverTime, imported[i].ReceiverTime)
+			}
+
+			// Messages deleted with a tombstone are skipped, and the import goes on
+			dst = NewMemoryStore(utils.Logger())
+			_, err = dst.DeleteByHashes([][]byte{pb.MessageHash(expected[0].PubsubTopic, expected[0].Message)}, true)
+			require.NoError(t, err)
+
+			r, err = NewMessageReader(bytes.NewReader(data), format)
+			require.NoError(t, err)
+			result, err = Import(dst, r, &pb.HistoryQuery{})
+			require.NoError(t, err)
+			require.Equal(t, ImportResult{Imported: 9, Deleted: 1}, result)
+		})
+	}
+
+	_, err = NewMessageWriter(&bytes.Buffer{}, "xml")
+	require.ErrorIs(t, err, ErrUnknownExportFormat)
+}
+
+func TestImportRecordSize(t *testing.T) {
+	// A record whose length prefix is larger than the limit is rejected before reading it
+	var buf bytes.Buffer
+	length := make([]byte, binary.MaxVarintLen64)
+	buf.Write(length[:binary.PutUvarint(length, MaxImportRecordSize+1)])
+	buf.Write(make([]byte, 16))
+
+	r, err := NewMessageReader(&buf, FormatProtobuf)
+	require.NoError(t, err)
+	_, err = r.Read()
+	require.Error(t, err)
+
+	// Records of the maximum size of a relayed message are accepted
+	buf.Reset()
+	w, err := NewMessageWriter(&buf, FormatProtobuf)
+	require.NoError(t, err)
+	msg := tests.CreateWakuMessage("test", 1)
+	msg.Payload = make([]byte, pubsub.DefaultMaxMessageSize)
+	require.NoError(t, w.Write(StoredMessage{Message: msg, PubsubTopic: "test", ReceiverTime: 1}))
+
+	r, err = NewMessageReader(&buf, FormatProtobuf)
+	require.NoError(t, err)
+	stored, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, msg.Payload, stored.Message.Payload)
+}
@@ -0,0 +1,200 @@
+package persistence
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+)
+
+// IterationPageSize is the number of messages read at a time by Iterate, unless the
+// query specifies a page size
+const IterationPageSize = 1000
+
+// ErrStopIteration can be returned by the function called for each message by
+// Iterate to stop the iteration without an error
+var ErrStopIteration = errors.New("stop iteration")
+
+// IterateFunc is called by Iterate for each message, in order
+type IterateFunc func(msg StoredMessage) error
+
+// iterationQuery returns a copy of a history query whose paging info is forward, and
+// has a page size of IterationPageSize, unless they are specified
+func iterationQuery(query *pb.HistoryQuery) *pb.HistoryQuery {
+	result := &pb.HistoryQuery{
+		PubsubTopic:    query.PubsubTopic,
+		ContentFilters: query.ContentFilters,
+		StartTime:      query.StartTime,
+		EndTime:        query.EndTime,
+		PagingInfo:     &pb.PagingInfo{PageSize: IterationPageSize, Direction: pb.PagingInfo_FORWARD},
+	}
+
+	if query.PagingInfo != nil {
+		result.PagingInfo.Direction = query.PagingInfo.Direction
+		result.PagingInfo.Cursor = query.PagingInfo.Cursor
+		if query.PagingInfo.PageSize != 0 {
+			result.PagingInfo.PageSize = query.PagingInfo.PageSize
+		}
+	}
+
+	return result
+}
+
+// iterate reads the messages that match a query in pages, and calls fn for each of
+// them. The first page is read with the cursor of the query, and the following ones
+// after the id of the last message read, which may have been deleted since then
+func iterate(query *pb.HistoryQuery, fn IterateFunc, page func(q *pb.HistoryQuery, lastID []byte) ([]StoredMessage, error)) error {
+	q := iterationQuery(query)
+
+	var lastID []byte
+	for {
+		messages, err := page(q, lastID)
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range messages {
+			err := fn(msg)
+			if err == ErrStopIteration {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if uint64(len(messages)) < q.PagingInfo.PageSize {
+			return nil
+		}
+
+		lastID = messages[len(messages)-1].ID
+	}
+}
+
+// Iterate calls fn for each stored message that matches the filters of a history
+// query, sorted by sender timestamp in the direction of its paging info, or from the
+// oldest if it's not specified. The messages are read in pages of the size of the
+// paging info, and no connection to the DB is used while fn is called
+func (d *DBStore) Iterate(query *pb.HistoryQuery, fn IterateFunc) error {
+	return iterate(query, fn, func(q *pb.HistoryQuery, lastID []byte) ([]StoredMessage, error) {
+		if lastID == nil && q.PagingInfo.Cursor != nil {
+			var err error
+			lastID, err = d.cursorID(q.PagingInfo.Cursor)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return d.iterationPage(q, lastID)
+	})
+}
+
+// iterationPage returns the messages that match a history query and follow the
+// message with the id lastID, sorted by id, which starts with the sender timestamp
+func (d *DBStore) iterationPage(query *pb.HistoryQuery, lastID []byte) ([]StoredMessage, error) {
+	conditions, parameters := d.queryConditions(query)
+
+	backward := query.PagingInfo.Direction == pb.PagingInfo_BACKWARD
+	if lastID != nil {
+		if backward {
+			conditions = append(conditions, "id < ?")
+		} else {
+			conditions = append(conditions, "id > ?")
+		}
+		parameters = append(parameters, lastID)
+	}
+
+	conditionStr := ""
+	if len(conditions) != 0 {
+		conditionStr = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	orderDirection := "ASC"
+	if backward {
+		orderDirection = "DESC"
+	}
+
+	sqlQuery := fmt.Sprintf("SELECT id, receiverTimestamp, senderTimestamp, contentTopic, pubsubTopic, payload, version, rawMessage FROM message %s ORDER BY id %s LIMIT ?", conditionStr, orderDirection)
+	rows, err := d.readDB.Query(d.dialect.Rebind(sqlQuery), append(parameters, query.PagingInfo.PageSize)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []StoredMessage
+	for rows.Next() {
+		record, err := d.GetStoredMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, record)
+	}
+
+	return result, rows.Err()
+}
+
+// Iterate calls fn for each stored message that matches the filters of a history
+// query, sorted by sender timestamp in the direction of its paging info, or from the
+// oldest if it's not specified. The messages are read in pages of the size of the
+// paging info, and the store is not locked while fn is called
+func (m *MemoryStore) Iterate(query *pb.HistoryQuery, fn IterateFunc) error {
+	return iterate(query, fn, func(q *pb.HistoryQuery, lastID []byte) ([]StoredMessage, error) {
+		m.RLock()
+		defer m.RUnlock()
+
+		if lastID == nil && q.PagingInfo.Cursor != nil {
+			var err error
+			lastID, err = m.cursorKey(q.PagingInfo.Cursor)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return m.iterationPage(q, lastID), nil
+	})
+}
+
+// iterationPage returns the messages that match a history query and follow the key
+// lastID, which is not required to be the key of a stored message, sorted by key like
+// the ids of DBStore.iterationPage
+func (m *MemoryStore) iterationPage(query *pb.HistoryQuery, lastID []byte) []StoredMessage {
+	backward := query.PagingInfo.Direction == pb.PagingInfo_BACKWARD
+	pageSize := int(query.PagingInfo.PageSize)
+
+	// The indexes are sorted by pubsub topic within each sender time, and the keys by
+	// the hash of the pubsub topic, so every message with the sender time of the last
+	// one is retrieved before sorting them by key
+	result := collectPage(m.candidates(query, lastID, backward), m.matcher(query, lastID, backward), pageSize)
+	sort.Slice(result, func(i, j int) bool {
+		if backward {
+			return bytes.Compare(result[i].key, result[j].key) > 0
+		}
+		return bytes.Compare(result[i].key, result[j].key) < 0
+	})
+	if len(result) > pageSize {
+		result = result[:pageSize]
+	}
+
+	storedMessages := make([]StoredMessage, len(result))
+	for i, msg := range result {
+		storedMessages[i] = msg.StoredMessage
+	}
+
+	return storedMessages
+}
+
+// Iterate calls fn for each stored message that matches the filters of a history
+// query, sorted by sender timestamp in the direction of its paging info, or from the
+// oldest if it's not specified. The messages are read in pages of the size of the
+// paging info
+func (l *LevelDBStore) Iterate(query *pb.HistoryQuery, fn IterateFunc) error {
+	return iterate(query, fn, func(q *pb.HistoryQuery, lastID []byte) ([]StoredMessage, error) {
+		if lastID == nil {
+			return l.Query(q)
+		}
+		return l.queryAfter(q, lastID)
+	})
+}
@@ -0,0 +1,118 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIterate(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+
+	dbStore, err := NewDBStore(utils.Logger(), WithDB(db))
+	require.NoError(t, err)
+	defer dbStore.Stop()
+
+	levelDBStore, err := NewLevelDBStore(utils.Logger(), WithLevelDBPath(t.TempDir()))
+	require.NoError(t, err)
+	defer levelDBStore.Stop()
+
+	providers := map[string]interface {
+		Put(env *protocol.Envelope) error
+		Iterate(query *pb.HistoryQuery, fn IterateFunc) error
+		GetAll() ([]StoredMessage, error)
+	}{
+		"db":      dbStore,
+		"memory":  NewMemoryStore(utils.Logger()),
+		"leveldb": levelDBStore,
+	}
+
+	for name, p := range providers {
+		t.Run(name, func(t *testing.T) {
+			for i := 1; i <= 25; i++ {
+				contentTopic := "even"
+				if i%2 == 1 {
+					contentTopic = "odd"
+				}
+				require.NoError(t, p.Put(protocol.NewEnvelope(tests.CreateWakuMessage(contentTopic, int64(i)), utils.GetUnixEpoch(), "test")))
+			}
+
+			timestamps := func(query *pb.HistoryQuery) []int64 {
+				var result []int64
+				err := p.Iterate(query, func(msg StoredMessage) error {
+					result = append(result, msg.Message.Timestamp)
+					return nil
+				})
+				require.NoError(t, err)
+				return result
+			}
+
+			all := timestamps(&pb.HistoryQuery{PagingInfo: &pb.PagingInfo{PageSize: 10, Direction: pb.PagingInfo_FORWARD}})
+			require.Len(t, all, 25)
+			for i, ts := range all {
+				require.Equal(t, int64(i+1), ts)
+			}
+
+			backward := timestamps(&pb.HistoryQuery{
+				ContentFilters: []*pb.ContentFilter{{ContentTopic: "even"}},
+				PagingInfo:     &pb.PagingInfo{PageSize: 5, Direction: pb.PagingInfo_BACKWARD},
+			})
+			require.Equal(t, []int64{24, 22, 20, 18, 16, 14, 12, 10, 8, 6, 4, 2}, backward)
+
+			// Iterate reads every message from the oldest if the paging info is not specified
+			require.Equal(t, all, timestamps(&pb.HistoryQuery{}))
+
+			res, err := p.GetAll()
+			require.NoError(t, err)
+			require.Len(t, res, 25)
+
+			count := 0
+			err = p.Iterate(&pb.HistoryQuery{}, func(msg StoredMessage) error {
+				count++
+				if count == 3 {
+					return ErrStopIteration
+				}
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, 3, count)
+
+			errTest := errors.New("test")
+			err = p.Iterate(&pb.HistoryQuery{}, func(msg StoredMessage) error {
+				return errTest
+			})
+			require.ErrorIs(t, err, errTest)
+		})
+	}
+
+	// The iteration continues if the last message read is deleted
+	count := 0
+	err = dbStore.Iterate(&pb.HistoryQuery{PagingInfo: &pb.PagingInfo{PageSize: 4, Direction: pb.PagingInfo_FORWARD}}, func(msg StoredMessage) error {
+		count++
+		_, err := db.Exec("DELETE FROM message WHERE id = ?", msg.ID)
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, 25, count)
+
+	memoryStore := NewMemoryStore(utils.Logger())
+	for i := 1; i <= 25; i++ {
+		require.NoError(t, memoryStore.Put(protocol.NewEnvelope(tests.CreateWakuMessage("test", int64(i)), utils.GetUnixEpoch(), "test")))
+	}
+
+	count = 0
+	err = memoryStore.Iterate(&pb.HistoryQuery{PagingInfo: &pb.PagingInfo{PageSize: 4, Direction: pb.PagingInfo_BACKWARD}}, func(msg StoredMessage) error {
+		count++
+		_, err := memoryStore.DeleteByHashes([][]byte{pb.MessageHash(msg.PubsubTopic, msg.Message)}, false)
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, 25, count)
+}
@@ -0,0 +1,711 @@
+package persistence
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"go.uber.org/zap"
+)
+
+// Prefixes of the keyspaces used by LevelDBStore. Messages are stored in the
+// message keyspace using their DBKey as key, and every other keyspace is an
+// index whose keys end with the DBKey of the message
+var (
+	levelDBMessagePrefix      = []byte{'m'}
+	levelDBPubsubTopicPrefix  = []byte{'p'}
+	levelDBContentTopicPrefix = []byte{'c'}
+	levelDBReceiverTimePrefix = []byte{'r'}
+	levelDBMessageHashPrefix  = []byte{'h'}
+	levelDBTombstonePrefix    = []byte{'t'}
+)
+
+// levelDBVersionKey stores the version of the layout of the keys of a LevelDBStore.
+// Version 1 uses the canonical message hash as digest of the DBKeys
+var levelDBVersionKey = []byte{'v'}
+
+const levelDBVersion = 1
+
+// LevelDBStore is a MessageProvider that stores the messages in a LevelDB database.
+// Messages are sorted by their DBKey: sender time, pubsub topic hash and digest
+type LevelDBStore struct {
+	sync.Mutex
+
+	db  *leveldb.DB
+	log *zap.Logger
+
+	maxMessages int
+	maxDuration time.Duration
+
+	count int
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// LevelDBOption is an optional setting that can be used to configure the LevelDBStore
+type LevelDBOption func(*LevelDBStore) error
+
+// WithLevelDB is a LevelDBOption that lets you use any LevelDB database with a LevelDBStore
+func WithLevelDB(db *leveldb.DB) LevelDBOption {
+	return func(l *LevelDBStore) error {
+		l.db = db
+		return nil
+	}
+}
+
+// WithLevelDBPath is a LevelDBOption that opens the LevelDB database stored in a path
+func WithLevelDBPath(path string) LevelDBOption {
+	return func(l *LevelDBStore) error {
+		db, err := leveldb.OpenFile(path, &opt.Options{OpenFilesCacheCapacity: 3})
+		if err != nil {
+			return err
+		}
+		l.db = db
+		return nil
+	}
+}
+
+// WithLevelDBRetentionPolicy is a LevelDBOption that limits the number of messages
+// stored and how long they are kept, according to their receiver time
+func WithLevelDBRetentionPolicy(maxMessages int, maxDuration time.Duration) LevelDBOption {
+	return func(l *LevelDBStore) error {
+		l.maxMessages = maxMessages
+		l.maxDuration = maxDuration
+		return nil
+	}
+}
+
+// NewLevelDBStore creates a new LevelDB store using the database specified via options.
+// It will clean up records according to the retention policy used
+func NewLevelDBStore(log *zap.Logger, options ...LevelDBOption) (*LevelDBStore, error) {
+	result := new(LevelDBStore)
+	result.log = log.Named("leveldbstore")
+	result.quit = make(chan struct{})
+
+	for _, opt := range options {
+		err := opt(result)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := result.migrate()
+	if err != nil {
+		return nil, err
+	}
+
+	iter := result.db.NewIterator(util.BytesPrefix(levelDBMessagePrefix), nil)
+	for iter.Next() {
+		result.count++
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+
+	err = result.cleanOlderRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	result.wg.Add(1)
+	go result.checkForOlderRecords(10 * time.Second)
+
+	return result, nil
+}
+
+// migrate rebuilds the keys of the messages stored with an older version of the
+// LevelDBStore, in a single batch
+func (l *LevelDBStore) migrate() error {
+	version := 0
+	value, err := l.db.Get(levelDBVersionKey, nil)
+	if err != nil && err != leveldb.ErrNotFound {
+		return err
+	}
+	if len(value) == 4 {
+		version = int(binary.BigEndian.Uint32(value))
+	}
+
+	if version >= levelDBVersion {
+		return nil
+	}
+
+	batch := new(leveldb.Batch)
+	for _, prefix := range [][]byte{levelDBPubsubTopicPrefix, levelDBContentTopicPrefix, levelDBReceiverTimePrefix, levelDBMessageHashPrefix} {
+		iter := l.db.NewIterator(util.BytesPrefix(prefix), nil)
+		for iter.Next() {
+			batch.Delete(append([]byte{}, iter.Key()...))
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return err
+		}
+	}
+
+	migrated := 0
+	iter := l.db.NewIterator(util.BytesPrefix(levelDBMessagePrefix), nil)
+	for iter.Next() {
+		storedMsg, err := decodeLevelDBMessage(iter.Key()[len(levelDBMessagePrefix):], iter.Value())
+		if err != nil {
+			iter.Release()
+			return err
+		}
+
+		batch.Delete(append([]byte{}, iter.Key()...))
+		putBatch(batch, protocol.NewEnvelope(storedMsg.Message, storedMsg.ReceiverTime, storedMsg.PubsubTopic), iter.Value())
+		migrated++
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+
+	value = make([]byte, 4)
+	binary.BigEndian.PutUint32(value, levelDBVersion)
+	batch.Put(levelDBVersionKey, value)
+
+	err = l.db.Write(batch, nil)
+	if err != nil {
+		return err
+	}
+
+	if migrated > 0 {
+		l.log.Info("migrated stored messages", zap.Int("version", levelDBVersion), zap.Int("count", migrated))
+	}
+
+	return nil
+}
+
+func keyWithPrefix(prefix []byte, parts ...[]byte) []byte {
+	key := append([]byte{}, prefix...)
+	for _, p := range parts {
+		key = append(key, p...)
+	}
+	return key
+}
+
+func topicHash(topic string) []byte {
+	hash := sha256.Sum256([]byte(topic))
+	return hash[:]
+}
+
+func receiverTimeBytes(receiverTime int64) []byte {
+	b := make([]byte, TimestampLength)
+	binary.BigEndian.PutUint64(b, uint64(receiverTime))
+	return b
+}
+
+// Put inserts a WakuMessage into the DB, along with the keys of every index
+func (l *LevelDBStore) Put(env *protocol.Envelope) error {
+	l.Lock()
+	defer l.Unlock()
+
+	cursor := env.Index()
+	dbKey := NewDBKey(uint64(cursor.SenderTime), env.PubsubTopic(), cursor.Digest).Bytes()
+
+	messageKey := keyWithPrefix(levelDBMessagePrefix, dbKey)
+	exists, err := l.db.Has(messageKey, nil)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrDuplicateMessage
+	}
+
+	deleted, err := l.db.Has(keyWithPrefix(levelDBTombstonePrefix, env.Hash()), nil)
+	if err != nil {
+		return err
+	}
+	if deleted {
+		return ErrDeletedMessage
+	}
+
+	value, err := (&pb.StoredWakuMessage{
+		Message:      env.Message(),
+		PubsubTopic:  env.PubsubTopic(),
+		ReceiverTime: cursor.ReceiverTime,
+	}).Marshal()
+	if err != nil {
+		return err
+	}
+
+	batch := new(leveldb.Batch)
+	putBatch(batch, env, value)
+
+	err = l.db.Write(batch, nil)
+	if err != nil {
+		return err
+	}
+
+	l.count++
+
+	return nil
+}
+
+// PutBatch inserts a list of WakuMessages into the DB with a single write, and returns
+// the error of each message
+func (l *LevelDBStore) PutBatch(envs []*protocol.Envelope) []error {
+	l.Lock()
+	defer l.Unlock()
+
+	errs := make([]error, len(envs))
+	batch := new(leveldb.Batch)
+	inBatch := make(map[string]struct{})
+	for i, env := range envs {
+		cursor := env.Index()
+		dbKey := NewDBKey(uint64(cursor.SenderTime), env.PubsubTopic(), cursor.Digest).Bytes()
+
+		messageKey := keyWithPrefix(levelDBMessagePrefix, dbKey)
+		exists, err := l.db.Has(messageKey, nil)
+		if _, ok := inBatch[string(messageKey)]; ok || exists {
+			err = ErrDuplicateMessage
+		}
+		if err == nil {
+			var deleted bool
+			deleted, err = l.db.Has(keyWithPrefix(levelDBTombstonePrefix, env.Hash()), nil)
+			if deleted {
+				err = ErrDeletedMessage
+			}
+		}
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+
+		value, err := (&pb.StoredWakuMessage{
+			Message:      env.Message(),
+			PubsubTopic:  env.PubsubTopic(),
+			ReceiverTime: cursor.ReceiverTime,
+		}).Marshal()
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+
+		putBatch(batch, env, value)
+		inBatch[string(messageKey)] = struct{}{}
+	}
+
+	err := l.db.Write(batch, nil)
+	for i := range envs {
+		if errs[i] == nil {
+			errs[i] = err
+		}
+	}
+
+	if err == nil {
+		l.count += len(inBatch)
+	}
+
+	return errs
+}
+
+// putBatch adds to a batch a serialized pb.StoredWakuMessage and its index keys
+func putBatch(batch *leveldb.Batch, env *protocol.Envelope, value []byte) {
+	cursor := env.Index()
+	dbKey := NewDBKey(uint64(cursor.SenderTime), env.PubsubTopic(), cursor.Digest).Bytes()
+
+	batch.Put(keyWithPrefix(levelDBMessagePrefix, dbKey), value)
+	batch.Put(keyWithPrefix(levelDBPubsubTopicPrefix, topicHash(env.PubsubTopic()), dbKey), nil)
+	batch.Put(keyWithPrefix(levelDBContentTopicPrefix, topicHash(env.Message().ContentTopic), dbKey), nil)
+	batch.Put(keyWithPrefix(levelDBReceiverTimePrefix, receiverTimeBytes(cursor.ReceiverTime), dbKey), nil)
+	batch.Put(keyWithPrefix(levelDBMessageHashPrefix, env.Hash()), dbKey)
+}
+
+func (l *LevelDBStore) getStoredMessage(dbKey []byte) (StoredMessage, error) {
+	value, err := l.db.Get(keyWithPrefix(levelDBMessagePrefix, dbKey), nil)
+	if err != nil {
+		return StoredMessage{}, err
+	}
+
+	return decodeLevelDBMessage(dbKey, value)
+}
+
+func decodeLevelDBMessage(dbKey []byte, value []byte) (StoredMessage, error) {
+	storedMsg := new(pb.StoredWakuMessage)
+	err := storedMsg.Unmarshal(value)
+	if err != nil {
+		return StoredMessage{}, err
+	}
+
+	return StoredMessage{
+		ID:           append([]byte{}, dbKey...),
+		PubsubTopic:  storedMsg.PubsubTopic,
+		ReceiverTime: storedMsg.ReceiverTime,
+		Message:      storedMsg.Message,
+	}, nil
+}
+
+// deleteBatch adds to a batch the deletion of a message and its index keys
+func (l *LevelDBStore) deleteBatch(batch *leveldb.Batch, dbKey []byte) error {
+	storedMsg, err := l.getStoredMessage(dbKey)
+	if err != nil {
+		return err
+	}
+
+	batch.Delete(keyWithPrefix(levelDBMessagePrefix, dbKey))
+	batch.Delete(keyWithPrefix(levelDBPubsubTopicPrefix, topicHash(storedMsg.PubsubTopic), dbKey))
+	batch.Delete(keyWithPrefix(levelDBContentTopicPrefix, topicHash(storedMsg.Message.ContentTopic), dbKey))
+	batch.Delete(keyWithPrefix(levelDBReceiverTimePrefix, receiverTimeBytes(storedMsg.ReceiverTime), dbKey))
+	batch.Delete(keyWithPrefix(levelDBMessageHashPrefix, protocol.NewEnvelope(storedMsg.Message, storedMsg.ReceiverTime, storedMsg.PubsubTopic).Hash()))
+
+	return nil
+}
+
+// deleteOldest removes up to limit messages whose receiver time is older than
+// maxReceiverTime, starting from the oldest one. It returns the number of deleted messages
+func (l *LevelDBStore) deleteOldest(maxReceiverTime int64, limit int) (int, error) {
+	rng := util.BytesPrefix(levelDBReceiverTimePrefix)
+	rng.Limit = keyWithPrefix(levelDBReceiverTimePrefix, receiverTimeBytes(maxReceiverTime))
+
+	iter := l.db.NewIterator(rng, nil)
+	defer iter.Release()
+
+	batch := new(leveldb.Batch)
+	deleted := 0
+	for iter.Next() && (limit <= 0 || deleted < limit) {
+		dbKey := iter.Key()[len(levelDBReceiverTimePrefix)+TimestampLength:]
+		err := l.deleteBatch(batch, dbKey)
+		if err != nil {
+			return 0, err
+		}
+		deleted++
+	}
+
+	if err := iter.Error(); err != nil {
+		return 0, err
+	}
+
+	err := l.db.Write(batch, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	l.count -= deleted
+
+	return deleted, nil
+}
+
+func (l *LevelDBStore) cleanOlderRecords() error {
+	l.Lock()
+	defer l.Unlock()
+
+	l.log.Debug("Cleaning older records...")
+
+	// Delete older messages
+	if l.maxDuration > 0 {
+		start := time.Now()
+		deleted, err := l.deleteOldest(utils.GetUnixEpochFrom(time.Now().Add(-l.maxDuration)), 0)
+		if err != nil {
+			return err
+		}
+		l.log.Debug("deleting older records from the DB", zap.Duration("duration", time.Since(start)), zap.Int("count", deleted))
+	}
+
+	// Limit number of records to a max N
+	if l.maxMessages > 0 && l.count > l.maxMessages {
+		start := time.Now()
+		deleted, err := l.deleteOldest(int64(^uint64(0)>>1), l.count-l.maxMessages)
+		if err != nil {
+			return err
+		}
+		l.log.Debug("deleting excess records from the DB", zap.Duration("duration", time.Since(start)), zap.Int("count", deleted))
+	}
+
+	return nil
+}
+
+func (l *LevelDBStore) checkForOlderRecords(t time.Duration) {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(t)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.quit:
+			return
+		case <-ticker.C:
+			err := l.cleanOlderRecords()
+			if err != nil {
+				l.log.Error("cleaning older records", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Stop closes the LevelDB database
+func (l *LevelDBStore) Stop() {
+	l.quit <- struct{}{}
+	l.wg.Wait()
+	l.db.Close()
+}
+
+// levelDBCursor iterates over the DBKeys of a keyspace in a given direction
+type levelDBCursor struct {
+	iter     iterator.Iterator
+	prefix   []byte
+	backward bool
+	valid    bool
+}
+
+func newLevelDBCursor(db *leveldb.DB, prefix []byte, start []byte, end []byte, backward bool) *levelDBCursor {
+	rng := &util.Range{Start: keyWithPrefix(prefix, start)}
+	if end != nil {
+		rng.Limit = keyWithPrefix(prefix, end)
+	} else {
+		rng.Limit = util.BytesPrefix(prefix).Limit
+	}
+
+	c := &levelDBCursor{
+		iter:     db.NewIterator(rng, nil),
+		prefix:   prefix,
+		backward: backward,
+	}
+
+	if backward {
+		c.valid = c.iter.Last()
+	} else {
+		c.valid = c.iter.First()
+	}
+
+	return c
+}
+
+func (c *levelDBCursor) dbKey() []byte {
+	return c.iter.Key()[len(c.prefix):]
+}
+
+func (c *levelDBCursor) next() {
+	if c.backward {
+		c.valid = c.iter.Prev()
+	} else {
+		c.valid = c.iter.Next()
+	}
+}
+
+// nextKey returns an slice that sorts immediately after key
+func nextKey(key []byte) []byte {
+	return append(append([]byte{}, key...), 0)
+}
+
+// cursorKey returns the DBKey of the message a cursor points to. Cursors issued before
+// the canonical message hash was used as digest are resolved by comparing their digest
+// with the legacy digest of the messages with the same sender time and pubsub topic
+func (l *LevelDBStore) cursorKey(cursor *pb.Index) ([]byte, error) {
+	dbKey := NewDBKey(uint64(cursor.SenderTime), cursor.PubsubTopic, cursor.Digest).Bytes()
+	exists, err := l.db.Has(keyWithPrefix(levelDBMessagePrefix, dbKey), nil)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return dbKey, nil
+	}
+
+	iter := l.db.NewIterator(util.BytesPrefix(keyWithPrefix(levelDBMessagePrefix, dbKey[:TimestampLength+PubsubTopicLength])), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		record, err := decodeLevelDBMessage(iter.Key()[len(levelDBMessagePrefix):], iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(legacyDigest(record.Message), cursor.Digest) {
+			return record.ID, nil
+		}
+	}
+
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+
+	return nil, ErrInvalidCursor
+}
+
+// Query retrieves the messages that match a history query, using the content topic
+// or pubsub topic index when the query filters by them
+func (l *LevelDBStore) Query(query *pb.HistoryQuery) ([]StoredMessage, error) {
+	start := time.Now()
+	defer func() {
+		l.log.Info("loading records from the DB", zap.Duration("duration", time.Since(start)))
+	}()
+
+	var cursorKey []byte
+	if query.PagingInfo.Cursor != nil {
+		var err error
+		cursorKey, err = l.cursorKey(query.PagingInfo.Cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return l.queryAfter(query, cursorKey)
+}
+
+// queryAfter retrieves the messages that match a history query and follow the DBKey
+// cursorKey, if it's not nil, which is not required to be the key of a stored message.
+// The cursor of the query is ignored
+func (l *LevelDBStore) queryAfter(query *pb.HistoryQuery, cursorKey []byte) ([]StoredMessage, error) {
+	backward := query.PagingInfo.Direction == pb.PagingInfo_BACKWARD
+
+	// Range of DBKeys to iterate, the upper bound is exclusive
+	var startKey, endKey []byte
+	startKey = []byte{}
+	if query.StartTime != 0 {
+		startKey = NewDBKey(uint64(query.StartTime), "", []byte{}).Bytes()
+	}
+
+	if query.EndTime != 0 {
+		endKey = nextKey(NewDBKey(uint64(query.EndTime), "", []byte{}).Bytes())
+	}
+
+	if cursorKey != nil {
+		if backward {
+			if endKey == nil || bytes.Compare(cursorKey, endKey) < 0 {
+				endKey = cursorKey
+			}
+		} else if bytes.Compare(nextKey(cursorKey), startKey) > 0 {
+			startKey = nextKey(cursorKey)
+		}
+	}
+
+	if endKey != nil && bytes.Compare(startKey, endKey) >= 0 {
+		return nil, nil
+	}
+
+	var cursors []*levelDBCursor
+	filterPubsubTopic := false
+	if len(query.ContentFilters) != 0 {
+		seen := make(map[string]struct{})
+		for _, cf := range query.ContentFilters {
+			if _, ok := seen[cf.ContentTopic]; ok || cf.ContentTopic == "" {
+				continue
+			}
+			seen[cf.ContentTopic] = struct{}{}
+			cursors = append(cursors, newLevelDBCursor(l.db, keyWithPrefix(levelDBContentTopicPrefix, topicHash(cf.ContentTopic)), startKey, endKey, backward))
+		}
+		filterPubsubTopic = query.PubsubTopic != ""
+	} else if query.PubsubTopic != "" {
+		cursors = append(cursors, newLevelDBCursor(l.db, keyWithPrefix(levelDBPubsubTopicPrefix, topicHash(query.PubsubTopic)), startKey, endKey, backward))
+	} else {
+		cursors = append(cursors, newLevelDBCursor(l.db, levelDBMessagePrefix, startKey, endKey, backward))
+	}
+
+	defer func() {
+		for _, c := range cursors {
+			c.iter.Release()
+		}
+	}()
+
+	var pubsubTopicHash []byte
+	if filterPubsubTopic {
+		pubsubTopicHash = topicHash(query.PubsubTopic)
+	}
+
+	var result []StoredMessage
+	for uint64(len(result)) < query.PagingInfo.PageSize {
+		// Merge the cursors, picking the next DBKey in the iteration order
+		var selected *levelDBCursor
+		for _, c := range cursors {
+			if !c.valid {
+				continue
+			}
+			if selected == nil {
+				selected = c
+				continue
+			}
+			cmp := bytes.Compare(c.dbKey(), selected.dbKey())
+			if (!backward && cmp < 0) || (backward && cmp > 0) {
+				selected = c
+			}
+		}
+
+		if selected == nil {
+			break
+		}
+
+		dbKey := append([]byte{}, selected.dbKey()...)
+		selected.next()
+
+		if pubsubTopicHash != nil && !bytes.Equal(dbKey[TimestampLength:TimestampLength+PubsubTopicLength], pubsubTopicHash) {
+			continue
+		}
+
+		record, err := l.getStoredMessage(dbKey)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, record)
+	}
+
+	for _, c := range cursors {
+		if err := c.iter.Error(); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// GetByHashes returns the stored messages whose hash is in a list
+func (l *LevelDBStore) GetByHashes(hashes [][]byte) ([]StoredMessage, error) {
+	var result []StoredMessage
+	for _, h := range hashes {
+		dbKey, err := l.db.Get(keyWithPrefix(levelDBMessageHashPrefix, h), nil)
+		if err == leveldb.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		record, err := l.getStoredMessage(dbKey)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, record)
+	}
+
+	return result, nil
+}
+
+// MostRecentTimestamp returns an unix timestamp with the most recent sender timestamp
+func (l *LevelDBStore) MostRecentTimestamp() (int64, error) {
+	iter := l.db.NewIterator(util.BytesPrefix(levelDBMessagePrefix), nil)
+	defer iter.Release()
+
+	if !iter.Last() {
+		return 0, iter.Error()
+	}
+
+	return int64(binary.BigEndian.Uint64(iter.Key()[len(levelDBMessagePrefix):])), nil
+}
+
+// GetAll returns all the stored WakuMessages. Iterate should be used instead for
+// large DBs, since every message is loaded in memory
+func (l *LevelDBStore) GetAll() ([]StoredMessage, error) {
+	start := time.Now()
+
+	var result []StoredMessage
+	err := l.Iterate(&pb.HistoryQuery{}, func(msg StoredMessage) error {
+		result = append(result, msg)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l.log.Debug("loaded every record from the DB", zap.Int("count", len(result)), zap.Duration("duration", time.Since(start)))
+
+	return result, nil
+}
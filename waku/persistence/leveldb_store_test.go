@@ -0,0 +1,229 @@
+package persistence
+
+import (
+	"bytes"
+	"database/sql"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+func NewMemLevelDB(t *testing.T) *leveldb.DB {
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	require.NoError(t, err)
+	return db
+}
+
+func TestLevelDBStoreMatchesDBStore(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+
+	dbStore, err := NewDBStore(utils.Logger(), WithDB(db))
+	require.NoError(t, err)
+	defer dbStore.Stop()
+
+	levelDBStore, err := NewLevelDBStore(utils.Logger(), WithLevelDB(NewMemLevelDB(t)))
+	require.NoError(t, err)
+	defer levelDBStore.Stop()
+
+	rnd := rand.New(rand.NewSource(1))
+	pubsubTopics := []string{"a", "b", "c"}
+	contentTopics := []string{"1", "2", "3", "4"}
+	var envelopes []*protocol.Envelope
+	for i := 0; i < 300; i++ {
+		msg := tests.CreateWakuMessage(contentTopics[rnd.Intn(len(contentTopics))], int64(rnd.Intn(20)+1))
+		msg.Payload = []byte{byte(i), byte(i >> 8)}
+		env := protocol.NewEnvelope(msg, utils.GetUnixEpoch(), pubsubTopics[rnd.Intn(len(pubsubTopics))])
+		require.NoError(t, dbStore.Put(env))
+		require.NoError(t, levelDBStore.Put(env))
+		envelopes = append(envelopes, env)
+	}
+
+	require.ErrorIs(t, levelDBStore.Put(envelopes[0]), ErrDuplicateMessage)
+
+	res, err := levelDBStore.GetByHashes([][]byte{envelopes[10].Hash(), {1, 2, 3}, envelopes[20].Hash()})
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.Equal(t, envelopes[10].Message(), res[0].Message)
+	require.Equal(t, envelopes[20].Message(), res[1].Message)
+
+	queries := []*pb.HistoryQuery{
+		{},
+		{PubsubTopic: "b"},
+		{ContentFilters: []*pb.ContentFilter{{ContentTopic: "1"}, {ContentTopic: "3"}}},
+		{PubsubTopic: "a", ContentFilters: []*pb.ContentFilter{{ContentTopic: "2"}}},
+		{StartTime: 5, EndTime: 12},
+		{PubsubTopic: "c", StartTime: 3},
+		{EndTime: 7, ContentFilters: []*pb.ContentFilter{{ContentTopic: "4"}}},
+		{ContentFilters: []*pb.ContentFilter{{ContentTopic: ""}}},
+	}
+
+	// Messages with the same sender time are sorted by DBKey instead of by pubsub topic,
+	// so the results are compared after sorting them by DBKey. DBStore cursors can skip
+	// messages with the same sender time, so it's only queried with a single page
+	flatten := func(pages [][]StoredMessage) []StoredMessage {
+		var result []StoredMessage
+		for _, page := range pages {
+			result = append(result, page...)
+		}
+		sort.SliceStable(result, func(i, j int) bool { return bytes.Compare(result[i].ID, result[j].ID) < 0 })
+		return result
+	}
+
+	for _, query := range queries {
+		for _, direction := range []pb.PagingInfo_Direction{pb.PagingInfo_FORWARD, pb.PagingInfo_BACKWARD} {
+			for _, pageSize := range []uint64{1, 7, 100} {
+				query.PagingInfo = &pb.PagingInfo{PageSize: pageSize, Direction: direction}
+				levelDBPages := queryAllPages(t, levelDBStore, query)
+				for i, page := range levelDBPages {
+					if i != len(levelDBPages)-1 {
+						require.Len(t, page, int(pageSize))
+					}
+					for j := 1; j < len(page); j++ {
+						cmp := bytes.Compare(page[j-1].ID, page[j].ID)
+						if direction == pb.PagingInfo_FORWARD {
+							require.Negative(t, cmp)
+						} else {
+							require.Positive(t, cmp)
+						}
+					}
+				}
+				dbQuery := *query
+				dbQuery.PagingInfo = &pb.PagingInfo{PageSize: 1000, Direction: direction}
+				dbResult, err := dbStore.Query(&dbQuery)
+				require.NoError(t, err)
+				require.Equal(t, flatten([][]StoredMessage{dbResult}), flatten(levelDBPages))
+			}
+		}
+	}
+
+	invalidCursor := &pb.HistoryQuery{PagingInfo: &pb.PagingInfo{PageSize: 10, Cursor: &pb.Index{Digest: []byte{1}, SenderTime: 1, PubsubTopic: "a"}}}
+	_, err = levelDBStore.Query(invalidCursor)
+	require.ErrorIs(t, err, ErrInvalidCursor)
+
+	dbTimestamp, err := dbStore.MostRecentTimestamp()
+	require.NoError(t, err)
+	levelDBTimestamp, err := levelDBStore.MostRecentTimestamp()
+	require.NoError(t, err)
+	require.Equal(t, dbTimestamp, levelDBTimestamp)
+
+	res, err = levelDBStore.GetAll()
+	require.NoError(t, err)
+	require.Len(t, res, 300)
+}
+
+func TestLevelDBStoreRetention(t *testing.T) {
+	insertTime := time.Now()
+
+	db := NewMemLevelDB(t)
+	store, err := NewLevelDBStore(utils.Logger(), WithLevelDB(db))
+	require.NoError(t, err)
+
+	for i := 1; i <= 7; i++ {
+		ts := insertTime.Add(time.Duration(i-8) * 10 * time.Second).UnixNano()
+		require.NoError(t, store.Put(protocol.NewEnvelope(tests.CreateWakuMessage(string(rune('0'+i)), ts), ts, "test")))
+	}
+
+	// Creating a store counts the messages already stored, and applies the retention policy
+	store, err = NewLevelDBStore(utils.Logger(), WithLevelDB(db), WithLevelDBRetentionPolicy(3, 55*time.Second))
+	require.NoError(t, err)
+
+	res, err := store.GetAll()
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+	require.Equal(t, "5", res[0].Message.ContentTopic)
+	require.Equal(t, "7", res[2].Message.ContentTopic)
+
+	store.maxMessages = 0
+	store.maxDuration = 25 * time.Second
+	require.NoError(t, store.cleanOlderRecords())
+
+	res, err = store.GetAll()
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.Equal(t, 2, store.count)
+
+	// Index keys are removed along with the messages
+	res, err = store.Query(&pb.HistoryQuery{
+		ContentFilters: []*pb.ContentFilter{{ContentTopic: "5"}},
+		PagingInfo:     &pb.PagingInfo{PageSize: 10},
+	})
+	require.NoError(t, err)
+	require.Len(t, res, 0)
+
+	res, err = store.Query(&pb.HistoryQuery{PubsubTopic: "test", PagingInfo: &pb.PagingInfo{PageSize: 10}})
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+}
+
+func TestLevelDBStoreMigration(t *testing.T) {
+	db := NewMemLevelDB(t)
+
+	// Keys written by a LevelDBStore before the canonical message hash was used as digest
+	var msgs []*pb.WakuMessage
+	batch := new(leveldb.Batch)
+	for i := 1; i <= 3; i++ {
+		msg := tests.CreateWakuMessage("test", int64(i))
+		msgs = append(msgs, msg)
+
+		value, err := (&pb.StoredWakuMessage{Message: msg, PubsubTopic: "test", ReceiverTime: int64(i)}).Marshal()
+		require.NoError(t, err)
+		rawMessage, err := msg.Marshal()
+		require.NoError(t, err)
+
+		dbKey := NewDBKey(uint64(i), "test", legacyDigest(msg)).Bytes()
+		batch.Put(keyWithPrefix(levelDBMessagePrefix, dbKey), value)
+		batch.Put(keyWithPrefix(levelDBPubsubTopicPrefix, topicHash("test"), dbKey), nil)
+		batch.Put(keyWithPrefix(levelDBContentTopicPrefix, topicHash("test"), dbKey), nil)
+		batch.Put(keyWithPrefix(levelDBReceiverTimePrefix, receiverTimeBytes(int64(i)), dbKey), nil)
+		batch.Put(keyWithPrefix(levelDBMessageHashPrefix, pb.Hash(rawMessage)), dbKey)
+	}
+	require.NoError(t, db.Write(batch, nil))
+
+	store, err := NewLevelDBStore(utils.Logger(), WithLevelDB(db), WithLevelDBRetentionPolicy(3, 0))
+	require.NoError(t, err)
+	require.Equal(t, 3, store.count)
+
+	var hashes [][]byte
+	for i, msg := range msgs {
+		hashes = append(hashes, protocol.NewEnvelope(msg, int64(i+1), "test").Hash())
+	}
+	res, err := store.GetByHashes(hashes)
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+
+	res, err = store.Query(&pb.HistoryQuery{ContentFilters: []*pb.ContentFilter{{ContentTopic: "test"}}, PagingInfo: &pb.PagingInfo{PageSize: 10}})
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+
+	// Cursors issued before the migration are still accepted
+	legacyCursor := &pb.Index{Digest: legacyDigest(msgs[0]), ReceiverTime: 1, SenderTime: 1, PubsubTopic: "test"}
+	res, err = store.Query(&pb.HistoryQuery{PagingInfo: &pb.PagingInfo{PageSize: 10, Cursor: legacyCursor, Direction: pb.PagingInfo_FORWARD}})
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.Equal(t, int64(2), res[0].Message.Timestamp)
+
+	// The migration is only applied once
+	store, err = NewLevelDBStore(utils.Logger(), WithLevelDB(db))
+	require.NoError(t, err)
+	res, err = store.GetAll()
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+
+	iter := db.NewIterator(nil, nil)
+	keys := 0
+	for iter.Next() {
+		keys++
+	}
+	iter.Release()
+	require.Equal(t, 3*5+1, keys)
+}
@@ -0,0 +1,514 @@
+package persistence
+
+import (
+	"bytes"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"go.uber.org/zap"
+)
+
+// ErrDuplicateMessage is returned when a message with the same key was already stored
+var ErrDuplicateMessage = errors.New("message already stored")
+
+// MemoryStore is a MessageProvider that keeps the messages in memory, using
+// ordered indexes by sender time, pubsub topic and content topic. Its cursors
+// behave exactly like the ones from DBStore, including the ones with a legacy digest
+type MemoryStore struct {
+	sync.RWMutex
+
+	log *zap.Logger
+
+	maxMessages int
+	maxDuration time.Duration
+	maxBytes    int
+
+	// messages sorted by sender time, pubsub topic and key
+	messages       []*memoryMessage
+	byPubsubTopic  map[string][]*memoryMessage
+	byContentTopic map[string][]*memoryMessage
+	byKey          map[string]*memoryMessage
+	byHash         map[string]*memoryMessage
+	// messages sorted by receiver time, used to evict the oldest messages
+	byReceiverTime []*memoryMessage
+	totalBytes     int
+	// hashes of the messages deleted with a tombstone
+	tombstones map[string]struct{}
+}
+
+type memoryMessage struct {
+	key  []byte
+	hash []byte
+	size int
+	StoredMessage
+}
+
+// MemoryStoreOption is an optional setting that can be used to configure the MemoryStore
+type MemoryStoreOption func(*MemoryStore)
+
+// WithMemoryRetentionPolicy is a MemoryStoreOption that limits the number of messages kept
+// in memory, how long they are kept, and the total size in bytes of the stored messages.
+// The oldest messages, according to their receiver time, are evicted first.
+// A value of 0 disables the corresponding limit
+func WithMemoryRetentionPolicy(maxMessages int, maxDuration time.Duration, maxBytes int) MemoryStoreOption {
+	return func(m *MemoryStore) {
+		m.maxMessages = maxMessages
+		m.maxDuration = maxDuration
+		m.maxBytes = maxBytes
+	}
+}
+
+// NewMemoryStore creates a new MemoryStore
+func NewMemoryStore(log *zap.Logger, options ...MemoryStoreOption) *MemoryStore {
+	result := new(MemoryStore)
+	result.log = log.Named("memorystore")
+	result.byPubsubTopic = make(map[string][]*memoryMessage)
+	result.byContentTopic = make(map[string][]*memoryMessage)
+	result.byKey = make(map[string]*memoryMessage)
+	result.byHash = make(map[string]*memoryMessage)
+
+	for _, opt := range options {
+		opt(result)
+	}
+
+	return result
+}
+
+// lessMessage returns true if a must be sorted before b in ascending order, which
+// is the same order used by DBStore: sender time, pubsub topic and key
+func lessMessage(a *memoryMessage, b *memoryMessage) bool {
+	if a.Message.Timestamp != b.Message.Timestamp {
+		return a.Message.Timestamp < b.Message.Timestamp
+	}
+	if a.PubsubTopic != b.PubsubTopic {
+		return a.PubsubTopic < b.PubsubTopic
+	}
+	return bytes.Compare(a.key, b.key) < 0
+}
+
+func insertSorted(list []*memoryMessage, msg *memoryMessage, less func(a *memoryMessage, b *memoryMessage) bool) []*memoryMessage {
+	i := sort.Search(len(list), func(i int) bool { return less(msg, list[i]) })
+	list = append(list, nil)
+	copy(list[i+1:], list[i:])
+	list[i] = msg
+	return list
+}
+
+func removeSorted(list []*memoryMessage, msg *memoryMessage, less func(a *memoryMessage, b *memoryMessage) bool) []*memoryMessage {
+	i := sort.Search(len(list), func(i int) bool { return !less(list[i], msg) })
+	for ; i < len(list); i++ {
+		if list[i] == msg {
+			copy(list[i:], list[i+1:])
+			list[len(list)-1] = nil
+			return list[:len(list)-1]
+		}
+	}
+	return list
+}
+
+func lessReceiverTime(a *memoryMessage, b *memoryMessage) bool {
+	return a.ReceiverTime < b.ReceiverTime
+}
+
+// Put inserts a WakuMessage into the store
+func (m *MemoryStore) Put(env *protocol.Envelope) error {
+	m.Lock()
+	defer m.Unlock()
+
+	cursor := env.Index()
+	dbKey := NewDBKey(uint64(cursor.SenderTime), env.PubsubTopic(), cursor.Digest)
+	if _, ok := m.byKey[string(dbKey.Bytes())]; ok {
+		return ErrDuplicateMessage
+	}
+
+	if _, ok := m.tombstones[string(env.Hash())]; ok {
+		return ErrDeletedMessage
+	}
+
+	msg := &memoryMessage{
+		key:  dbKey.Bytes(),
+		hash: env.Hash(),
+		size: env.Size(),
+		StoredMessage: StoredMessage{
+			ID:           dbKey.Bytes(),
+			PubsubTopic:  env.PubsubTopic(),
+			ReceiverTime: cursor.ReceiverTime,
+			Message:      env.Message(),
+		},
+	}
+
+	m.messages = insertSorted(m.messages, msg, lessMessage)
+	m.byPubsubTopic[msg.PubsubTopic] = insertSorted(m.byPubsubTopic[msg.PubsubTopic], msg, lessMessage)
+	m.byContentTopic[msg.Message.ContentTopic] = insertSorted(m.byContentTopic[msg.Message.ContentTopic], msg, lessMessage)
+	m.byReceiverTime = insertSorted(m.byReceiverTime, msg, lessReceiverTime)
+	m.byKey[string(msg.key)] = msg
+	m.byHash[string(msg.hash)] = msg
+	m.totalBytes += msg.size
+
+	m.evict()
+
+	return nil
+}
+
+// PutBatch inserts a list of WakuMessages into the store, and returns the error of each message
+func (m *MemoryStore) PutBatch(envs []*protocol.Envelope) []error {
+	errs := make([]error, len(envs))
+	for i, env := range envs {
+		errs[i] = m.Put(env)
+	}
+	return errs
+}
+
+func (m *MemoryStore) remove(msg *memoryMessage) {
+	m.messages = removeSorted(m.messages, msg, lessMessage)
+
+	m.byPubsubTopic[msg.PubsubTopic] = removeSorted(m.byPubsubTopic[msg.PubsubTopic], msg, lessMessage)
+	if len(m.byPubsubTopic[msg.PubsubTopic]) == 0 {
+		delete(m.byPubsubTopic, msg.PubsubTopic)
+	}
+
+	m.byContentTopic[msg.Message.ContentTopic] = removeSorted(m.byContentTopic[msg.Message.ContentTopic], msg, lessMessage)
+	if len(m.byContentTopic[msg.Message.ContentTopic]) == 0 {
+		delete(m.byContentTopic, msg.Message.ContentTopic)
+	}
+
+	m.byReceiverTime = removeSorted(m.byReceiverTime, msg, lessReceiverTime)
+	delete(m.byKey, string(msg.key))
+	delete(m.byHash, string(msg.hash))
+	m.totalBytes -= msg.size
+}
+
+// evict removes the oldest messages until the retention policy is satisfied
+func (m *MemoryStore) evict() {
+	removed := 0
+
+	if m.maxDuration > 0 {
+		minReceiverTime := utils.GetUnixEpochFrom(time.Now().Add(-m.maxDuration))
+		for len(m.byReceiverTime) != 0 && m.byReceiverTime[0].ReceiverTime < minReceiverTime {
+			m.remove(m.byReceiverTime[0])
+			removed++
+		}
+	}
+
+	for len(m.byReceiverTime) != 0 && ((m.maxMessages > 0 && len(m.byReceiverTime) > m.maxMessages) || (m.maxBytes > 0 && m.totalBytes > m.maxBytes)) {
+		m.remove(m.byReceiverTime[0])
+		removed++
+	}
+
+	if removed != 0 {
+		m.log.Debug("evicted messages", zap.Int("count", removed))
+	}
+}
+
+// minReceiverTime returns the receiver time of the oldest message that is not expired.
+// Reads skip the expired messages, which are only evicted when a message is stored
+func (m *MemoryStore) minReceiverTime() int64 {
+	if m.maxDuration <= 0 {
+		return 0
+	}
+	return utils.GetUnixEpochFrom(time.Now().Add(-m.maxDuration))
+}
+
+// mergedMessages iterates over several lists of messages sorted by lessMessage in a
+// single sorted sequence, in ascending or descending order, by merging them as they
+// are read. Each list starts at a position, and the ones with messages left are kept
+// in a heap ordered by their next message
+type mergedMessages struct {
+	lists    [][]*memoryMessage
+	pos      []int
+	backward bool
+	order    []int
+}
+
+func newMergedMessages(lists [][]*memoryMessage, start func(list []*memoryMessage) int, backward bool) *mergedMessages {
+	h := &mergedMessages{lists: lists, pos: make([]int, len(lists)), backward: backward}
+	for i, list := range lists {
+		h.pos[i] = start(list)
+		if h.pos[i] >= 0 && h.pos[i] < len(list) {
+			h.order = append(h.order, i)
+		}
+	}
+	heap.Init(h)
+	return h
+}
+
+func (h *mergedMessages) Len() int { return len(h.order) }
+
+func (h *mergedMessages) Less(i, j int) bool {
+	a := h.lists[h.order[i]][h.pos[h.order[i]]]
+	b := h.lists[h.order[j]][h.pos[h.order[j]]]
+	if h.backward {
+		return lessMessage(b, a)
+	}
+	return lessMessage(a, b)
+}
+
+func (h *mergedMessages) Swap(i, j int) { h.order[i], h.order[j] = h.order[j], h.order[i] }
+
+func (h *mergedMessages) Push(x interface{}) { h.order = append(h.order, x.(int)) }
+
+func (h *mergedMessages) Pop() interface{} {
+	x := h.order[len(h.order)-1]
+	h.order = h.order[:len(h.order)-1]
+	return x
+}
+
+// next returns the next message of the sequence, or nil once every list is exhausted
+func (h *mergedMessages) next() *memoryMessage {
+	if len(h.order) == 0 {
+		return nil
+	}
+
+	i := h.order[0]
+	msg := h.lists[i][h.pos[i]]
+	if h.backward {
+		h.pos[i]--
+	} else {
+		h.pos[i]++
+	}
+
+	if h.pos[i] < 0 || h.pos[i] >= len(h.lists[i]) {
+		heap.Pop(h)
+	} else {
+		heap.Fix(h, 0)
+	}
+
+	return msg
+}
+
+// collectPage returns at least pageSize messages of a sequence that match, if there are
+// enough, and every other matching message with the same sender time as the last one,
+// so the page can be sorted in a different order within each sender time
+func collectPage(candidates *mergedMessages, matches func(msg *memoryMessage) bool, pageSize int) []*memoryMessage {
+	var result []*memoryMessage
+	for msg := candidates.next(); msg != nil; msg = candidates.next() {
+		if len(result) >= pageSize && msg.Message.Timestamp != result[len(result)-1].Message.Timestamp {
+			break
+		}
+		if matches(msg) {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+// cursorKey returns the key of the message a cursor points to. Cursors issued before
+// the canonical message hash was used as digest are resolved by comparing their digest
+// with the legacy digest of the messages with the same sender time and pubsub topic
+func (m *MemoryStore) cursorKey(cursor *pb.Index) ([]byte, error) {
+	key := NewDBKey(uint64(cursor.SenderTime), cursor.PubsubTopic, cursor.Digest).Bytes()
+	if _, ok := m.byKey[string(key)]; ok {
+		return key, nil
+	}
+
+	i := sort.Search(len(m.messages), func(i int) bool {
+		msg := m.messages[i]
+		return msg.Message.Timestamp > cursor.SenderTime || (msg.Message.Timestamp == cursor.SenderTime && msg.PubsubTopic >= cursor.PubsubTopic)
+	})
+	for ; i < len(m.messages); i++ {
+		msg := m.messages[i]
+		if msg.Message.Timestamp != cursor.SenderTime || msg.PubsubTopic != cursor.PubsubTopic {
+			break
+		}
+		if bytes.Equal(legacyDigest(msg.Message), cursor.Digest) {
+			return msg.key, nil
+		}
+	}
+
+	return nil, ErrInvalidCursor
+}
+
+// candidates returns the messages that might match a history query, in ascending or
+// descending order, starting from the sender time of cursorKey if it's not nil. The
+// messages of the content topics of the query are read from their indexes, which are
+// merged in order
+func (m *MemoryStore) candidates(query *pb.HistoryQuery, cursorKey []byte, backward bool) *mergedMessages {
+	lists := [][]*memoryMessage{m.messages}
+	if len(query.ContentFilters) != 0 {
+		contentTopics := make(map[string]struct{})
+		lists = nil
+		for _, cf := range query.ContentFilters {
+			if _, ok := contentTopics[cf.ContentTopic]; ok || cf.ContentTopic == "" {
+				continue
+			}
+			contentTopics[cf.ContentTopic] = struct{}{}
+			lists = append(lists, m.byContentTopic[cf.ContentTopic])
+		}
+	} else if query.PubsubTopic != "" {
+		lists = [][]*memoryMessage{m.byPubsubTopic[query.PubsubTopic]}
+	}
+
+	// Keys start with the sender time, so no message before the cursor sender time can
+	// match, or after it when the order is descending
+	return newMergedMessages(lists, func(list []*memoryMessage) int {
+		if cursorKey == nil {
+			if backward {
+				return len(list) - 1
+			}
+			return 0
+		}
+
+		cursorTime := int64(binary.BigEndian.Uint64(cursorKey))
+		if backward {
+			return sort.Search(len(list), func(i int) bool { return list[i].Message.Timestamp > cursorTime }) - 1
+		}
+		return sort.Search(len(list), func(i int) bool { return list[i].Message.Timestamp >= cursorTime })
+	}, backward)
+}
+
+// matcher returns a function that checks whether a message matches the filters of a
+// history query, and follows cursorKey, if it's not nil, in the direction of the query
+func (m *MemoryStore) matcher(query *pb.HistoryQuery, cursorKey []byte, backward bool) func(msg *memoryMessage) bool {
+	var startKey, endKey []byte
+	if query.StartTime != 0 {
+		startKey = NewDBKey(uint64(query.StartTime), "", []byte{}).Bytes()
+	}
+
+	if query.EndTime != 0 {
+		endKey = NewDBKey(uint64(query.EndTime), "", []byte{}).Bytes()
+	}
+
+	// Expired messages must not be returned even if no message was inserted recently
+	minReceiverTime := m.minReceiverTime()
+
+	return func(msg *memoryMessage) bool {
+		if msg.ReceiverTime < minReceiverTime {
+			return false
+		}
+		if query.PubsubTopic != "" && msg.PubsubTopic != query.PubsubTopic {
+			return false
+		}
+		if startKey != nil && bytes.Compare(msg.key, startKey) < 0 {
+			return false
+		}
+		if endKey != nil && bytes.Compare(msg.key, endKey) > 0 {
+			return false
+		}
+		if cursorKey != nil {
+			cmp := bytes.Compare(msg.key, cursorKey)
+			if (!backward && cmp <= 0) || (backward && cmp >= 0) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Query retrieves the messages that match a history query
+func (m *MemoryStore) Query(query *pb.HistoryQuery) ([]StoredMessage, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	var cursorKey []byte
+	backward := query.PagingInfo.Direction == pb.PagingInfo_BACKWARD
+	if query.PagingInfo.Cursor != nil {
+		var err error
+		cursorKey, err = m.cursorKey(query.PagingInfo.Cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	pageSize := int(query.PagingInfo.PageSize)
+	if pageSize == 0 {
+		return nil, nil
+	}
+
+	candidates := m.candidates(query, cursorKey, backward)
+	matches := m.matcher(query, cursorKey, backward)
+
+	var result []*memoryMessage
+	if !backward {
+		for msg := candidates.next(); msg != nil && len(result) < pageSize; msg = candidates.next() {
+			if matches(msg) {
+				result = append(result, msg)
+			}
+		}
+	} else {
+		// Messages with the same sender time are sorted by ascending pubsub topic and
+		// descending key, so every message with the sender time of the last one is
+		// retrieved before sorting them
+		result = collectPage(candidates, matches, pageSize)
+		sort.SliceStable(result, func(i, j int) bool {
+			a, b := result[i], result[j]
+			if a.Message.Timestamp != b.Message.Timestamp {
+				return a.Message.Timestamp > b.Message.Timestamp
+			}
+			if a.PubsubTopic != b.PubsubTopic {
+				return a.PubsubTopic < b.PubsubTopic
+			}
+			return bytes.Compare(a.key, b.key) > 0
+		})
+		if len(result) > pageSize {
+			result = result[:pageSize]
+		}
+	}
+
+	storedMessages := make([]StoredMessage, len(result))
+	for i, msg := range result {
+		storedMessages[i] = msg.StoredMessage
+	}
+
+	return storedMessages, nil
+}
+
+// GetByHashes returns the stored messages whose hash is in a list
+func (m *MemoryStore) GetByHashes(hashes [][]byte) ([]StoredMessage, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	minReceiverTime := m.minReceiverTime()
+
+	var result []StoredMessage
+	for _, h := range hashes {
+		if msg, ok := m.byHash[string(h)]; ok && msg.ReceiverTime >= minReceiverTime {
+			result = append(result, msg.StoredMessage)
+		}
+	}
+
+	return result, nil
+}
+
+// MostRecentTimestamp returns an unix timestamp with the most recent senderTimestamp
+// in the message table
+func (m *MemoryStore) MostRecentTimestamp() (int64, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	if len(m.messages) == 0 {
+		return 0, nil
+	}
+
+	return m.messages[len(m.messages)-1].Message.Timestamp, nil
+}
+
+// GetAll returns all the stored WakuMessages
+func (m *MemoryStore) GetAll() ([]StoredMessage, error) {
+	var result []StoredMessage
+	err := m.Iterate(&pb.HistoryQuery{}, func(msg StoredMessage) error {
+		result = append(result, msg)
+		return nil
+	})
+	return result, err
+}
+
+// Stop removes every message from the store
+func (m *MemoryStore) Stop() {
+	m.Lock()
+	defer m.Unlock()
+
+	m.messages = nil
+	m.byPubsubTopic = make(map[string][]*memoryMessage)
+	m.byContentTopic = make(map[string][]*memoryMessage)
+	m.byKey = make(map[string]*memoryMessage)
+	m.byHash = make(map[string]*memoryMessage)
+	m.byReceiverTime = nil
+	m.totalBytes = 0
+}
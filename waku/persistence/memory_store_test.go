@@ -0,0 +1,179 @@
+package persistence
+
+import (
+	"database/sql"
+	"math/rand"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3" // Blank import to register the sqlite3 driver
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// queryAllPages retrieves every page of a query, following the cursor of each page
+func queryAllPages(t *testing.T, p MessageProvider, query *pb.HistoryQuery) [][]StoredMessage {
+	var pages [][]StoredMessage
+	q := *query
+	pagingInfo := *query.PagingInfo
+	q.PagingInfo = &pagingInfo
+	for {
+		page, err := p.Query(&q)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			return pages
+		}
+		pages = append(pages, page)
+
+		last := page[len(page)-1]
+		q.PagingInfo.Cursor = protocol.NewEnvelope(last.Message, last.ReceiverTime, last.PubsubTopic).Index()
+	}
+}
+
+// iterateAll returns every message read by Iterate
+func iterateAll(t *testing.T, p MessageProvider, query *pb.HistoryQuery) []StoredMessage {
+	var result []StoredMessage
+	err := p.Iterate(query, func(msg StoredMessage) error {
+		result = append(result, msg)
+		return nil
+	})
+	require.NoError(t, err)
+	return result
+}
+
+func TestMemoryStoreMatchesDBStore(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+
+	dbStore, err := NewDBStore(utils.Logger(), WithDB(db))
+	require.NoError(t, err)
+	defer dbStore.Stop()
+
+	memoryStore := NewMemoryStore(utils.Logger())
+	defer memoryStore.Stop()
+
+	// Few distinct timestamps and topics, so ties in the ordering are common
+	rnd := rand.New(rand.NewSource(1))
+	pubsubTopics := []string{"a", "b", "c"}
+	contentTopics := []string{"1", "2", "3", "4"}
+	var envelopes []*protocol.Envelope
+	for i := 0; i < 300; i++ {
+		msg := tests.CreateWakuMessage(contentTopics[rnd.Intn(len(contentTopics))], int64(rnd.Intn(20)+1))
+		msg.Payload = []byte{byte(i), byte(i >> 8)}
+		env := protocol.NewEnvelope(msg, utils.GetUnixEpoch(), pubsubTopics[rnd.Intn(len(pubsubTopics))])
+		require.NoError(t, dbStore.Put(env))
+		require.NoError(t, memoryStore.Put(env))
+		envelopes = append(envelopes, env)
+	}
+
+	require.ErrorIs(t, memoryStore.Put(envelopes[0]), ErrDuplicateMessage)
+
+	res, err := memoryStore.GetByHashes([][]byte{envelopes[10].Hash(), {1, 2, 3}, envelopes[20].Hash()})
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.Equal(t, envelopes[10].Message(), res[0].Message)
+	require.Equal(t, envelopes[20].Message(), res[1].Message)
+	require.Error(t, dbStore.Put(envelopes[0]))
+
+	queries := []*pb.HistoryQuery{
+		{},
+		{PubsubTopic: "b"},
+		{ContentFilters: []*pb.ContentFilter{{ContentTopic: "1"}, {ContentTopic: "3"}}},
+		{PubsubTopic: "a", ContentFilters: []*pb.ContentFilter{{ContentTopic: "2"}}},
+		{StartTime: 5, EndTime: 12},
+		{PubsubTopic: "c", StartTime: 3},
+		{EndTime: 7, ContentFilters: []*pb.ContentFilter{{ContentTopic: "4"}}},
+		{ContentFilters: []*pb.ContentFilter{{ContentTopic: ""}}},
+		{StartTime: 4, ContentFilters: []*pb.ContentFilter{{ContentTopic: "2"}, {ContentTopic: "4"}, {ContentTopic: "2"}, {ContentTopic: "1"}}},
+	}
+
+	for _, query := range queries {
+		for _, direction := range []pb.PagingInfo_Direction{pb.PagingInfo_FORWARD, pb.PagingInfo_BACKWARD} {
+			for _, pageSize := range []uint64{1, 7, 100} {
+				query.PagingInfo = &pb.PagingInfo{PageSize: pageSize, Direction: direction}
+				require.Equal(t, queryAllPages(t, dbStore, query), queryAllPages(t, memoryStore, query))
+				require.Equal(t, iterateAll(t, dbStore, query), iterateAll(t, memoryStore, query))
+			}
+		}
+	}
+
+	invalidCursor := &pb.HistoryQuery{PagingInfo: &pb.PagingInfo{PageSize: 10, Cursor: &pb.Index{Digest: []byte{1}, SenderTime: 1, PubsubTopic: "a"}}}
+	_, err = memoryStore.Query(invalidCursor)
+	require.ErrorIs(t, err, ErrInvalidCursor)
+
+	// Cursors with the digest used before the canonical message hash are accepted
+	legacyEnv := envelopes[10]
+	legacyCursor := &pb.Index{Digest: legacyDigest(legacyEnv.Message()), SenderTime: legacyEnv.Message().Timestamp, PubsubTopic: legacyEnv.PubsubTopic()}
+	legacyQuery := &pb.HistoryQuery{PagingInfo: &pb.PagingInfo{PageSize: 10, Cursor: legacyCursor}}
+	res, err = memoryStore.Query(legacyQuery)
+	require.NoError(t, err)
+	legacyQuery.PagingInfo.Cursor = legacyEnv.Index()
+	expected, err := memoryStore.Query(legacyQuery)
+	require.NoError(t, err)
+	require.NotEmpty(t, res)
+	require.Equal(t, expected, res)
+
+	dbTimestamp, err := dbStore.MostRecentTimestamp()
+	require.NoError(t, err)
+	memoryTimestamp, err := memoryStore.MostRecentTimestamp()
+	require.NoError(t, err)
+	require.Equal(t, dbTimestamp, memoryTimestamp)
+}
+
+func TestMemoryStoreRetention(t *testing.T) {
+	insertTime := time.Now()
+
+	store := NewMemoryStore(utils.Logger(), WithMemoryRetentionPolicy(3, 45*time.Second, 0))
+	for i := 1; i <= 7; i++ {
+		ts := insertTime.Add(time.Duration(i-8) * 10 * time.Second).UnixNano()
+		require.NoError(t, store.Put(protocol.NewEnvelope(tests.CreateWakuMessage(string(rune('0'+i)), ts), ts, "test")))
+	}
+
+	// Only the 3 most recent messages are kept
+	res, err := store.GetAll()
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+	require.Equal(t, "5", res[0].Message.ContentTopic)
+	require.Equal(t, "7", res[2].Message.ContentTopic)
+
+	// Messages older than the max duration are removed
+	store = NewMemoryStore(utils.Logger(), WithMemoryRetentionPolicy(0, 45*time.Second, 0))
+	for i := 1; i <= 7; i++ {
+		ts := insertTime.Add(time.Duration(i-8) * 10 * time.Second).UnixNano()
+		require.NoError(t, store.Put(protocol.NewEnvelope(tests.CreateWakuMessage(string(rune('0'+i)), ts), ts, "test")))
+	}
+
+	res, err = store.GetAll()
+	require.NoError(t, err)
+	require.Len(t, res, 4)
+	require.Equal(t, "4", res[0].Message.ContentTopic)
+
+	// Messages that expire while no message is stored are not returned, although they
+	// are only evicted by the next insertion
+	expiredHash := pb.MessageHash(res[0].PubsubTopic, res[0].Message)
+	store.maxDuration = 25 * time.Second
+	res, err = store.GetAll()
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.Equal(t, "6", res[0].Message.ContentTopic)
+	require.Len(t, store.messages, 4)
+
+	res, err = store.GetByHashes([][]byte{expiredHash})
+	require.NoError(t, err)
+	require.Empty(t, res)
+
+	// The total size of the messages is limited
+	env := protocol.NewEnvelope(tests.CreateWakuMessage("test", 1), 1, "test")
+	store = NewMemoryStore(utils.Logger(), WithMemoryRetentionPolicy(0, 0, 2*env.Size()))
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("test", int64(i)), int64(i), "test")))
+	}
+
+	res, err = store.GetAll()
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.Equal(t, int64(2), res[0].Message.Timestamp)
+}
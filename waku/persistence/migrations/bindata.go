@@ -1,7 +1,19 @@
 // Code generated by go-bindata. DO NOT EDIT.
 // sources:
-// 1_messages.down.sql (0)
+// 1_messages.down.sql (124B)
 // 1_messages.up.sql (464B)
+// 2_raw_message.down.sql (44B)
+// 2_raw_message.up.sql (48B)
+// 3_message_hash.down.sql (88B)
+// 3_message_hash.up.sql (122B)
+// 4_canonical_message_hash.down.sql (115B)
+// 4_canonical_message_hash.up.sql (324B)
+// 5_store_metadata.down.sql (37B)
+// 5_store_metadata.up.sql (196B)
+// 6_query_indexes.down.sql (117B)
+// 6_query_indexes.up.sql (355B)
+// 7_tombstones.down.sql (32B)
+// 7_tombstones.up.sql (178B)
 // doc.go (74B)
 
 package migrations
@@ -71,7 +83,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var __1_messagesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x01\x00\x00\xff\xff\x00\x00\x00\x00\x00\x00\x00\x00")
+var __1_messagesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\xc8\x4d\x2d\x2e\x4e\x4c\x4f\x8d\x2f\x4e\xcd\x4b\x49\x2d\x0a\xc9\xcc\x4d\x2d\x2e\x49\xcc\x2d\xb0\xe6\xc2\xab\xba\x28\x35\x39\x35\xb3\x0c\x53\x7d\x88\xa3\x93\x8f\x2b\xa6\x7a\x6b\x2e\xc0\x00\xc2\x48\x8c\x05\x7c\x00\x00\x00")
 
 func _1_messagesDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -86,12 +98,12 @@ func _1_messagesDownSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "1_messages.down.sql", size: 0, mode: os.FileMode(0664), modTime: time.Unix(1653588136, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe3, 0xb0, 0xc4, 0x42, 0x98, 0xfc, 0x1c, 0x14, 0x9a, 0xfb, 0xf4, 0xc8, 0x99, 0x6f, 0xb9, 0x24, 0x27, 0xae, 0x41, 0xe4, 0x64, 0x9b, 0x93, 0x4c, 0xa4, 0x95, 0x99, 0x1b, 0x78, 0x52, 0xb8, 0x55}}
+	info := bindataFileInfo{name: "1_messages.down.sql", size: 124, mode: os.FileMode(0664), modTime: time.Unix(1660143692, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xff, 0x4a, 0x8e, 0xa9, 0xd9, 0xa8, 0xa4, 0x73, 0x3a, 0x54, 0xe4, 0x35, 0xfd, 0xea, 0x87, 0x4c, 0xa, 0x5c, 0xc0, 0xc9, 0xe7, 0x8, 0x8c, 0x6f, 0x60, 0x9e, 0x54, 0x77, 0x59, 0xd0, 0x2b, 0xfe}}
 	return a, nil
 }
 
-var __1_messagesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x90\x41\x4f\x83\x40\x10\x85\xcf\xec\xaf\x98\x23\x24\x1c\xbc\x73\x82\xb2\xd5\x89\xeb\xae\x59\x86\xb4\x3d\x19\x0a\x13\xb3\x89\x2c\x84\xa5\x8d\xfe\x7b\x93\xc6\x1a\x52\x34\x7a\xfe\x76\xdf\x9b\xf7\x6d\xac\xcc\x49\x02\xe5\x85\x92\x80\x5b\xd0\x86\x40\xee\xb1\xa2\x0a\x7a\x0e\xa1\x79\x65\x88\x45\xe4\x3a\x28\x94\x29\x52\x11\x4d\xdc\xb2\x3b\xf3\x44\xae\xe7\x30\x37\xfd\x08\xa8\x49\xde\x4b\x7b\xf9\xa9\x6b\xa5\x52\x11\x05\xf6\xdd\x1f\x4f\xda\xc1\xcf\xec\x67\x1a\x46\xd7\x5e\xb2\x97\x70\x3c\x1d\xc3\xe9\xf8\x0b\x6b\x3e\xde\x86\xe6\xfb\x9e\x33\x4f\xc1\x0d\x7e\x55\x01\xa5\xdc\xe6\xb5\x22\xb8\x4b\x45\xb4\x31\xba\x22\x9b\xa3\xa6\xeb\x28\xf4\x1d\xbf\xc3\xb3\xc5\xa7\xdc\x1e\xe0\x51\x1e\x20\x76\x5d\x0a\x8b\xe2\x44\x24\xb0\x43\x7a\x30\x35\x81\x35\x3b\x2c\x33\x21\xbe\x64\xa1\x2e\xe5\xfe\x67\x59\x2f\xb7\xd3\x8d\xbe\xa2\xf8\x06\x25\xd9\x7f\xf2\xd6\xbe\x17\x89\x2b\x98\x64\x9f\x01\x00\x00\xff\xff\x59\xcd\x67\xb6\xd0\x01\x00\x00")
+var __1_messagesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8c\x90\x41\x4f\x83\x40\x10\x85\xcf\xec\xaf\x98\x23\x24\x1c\xbc\x73\x82\xb2\xd5\x89\xeb\xae\x59\x86\xb4\x3d\x19\x0a\x13\xb3\x89\x2c\x84\xa5\x8d\xfe\x7b\x93\xc6\x1a\x52\x34\x7a\xfe\x76\xdf\x9b\xf7\x6d\xac\xcc\x49\x02\xe5\x85\x92\x80\x5b\xd0\x86\x40\xee\xb1\xa2\x0a\x7a\x0e\xa1\x79\x65\x88\x45\xe4\x3a\x28\x94\x29\x52\x11\x4d\xdc\xb2\x3b\xf3\x44\xae\xe7\x30\x37\xfd\x08\xa8\x49\xde\x4b\x7b\xf9\xa9\x6b\xa5\x52\x11\x05\xf6\xdd\x1f\x4f\xda\xc1\xcf\xec\x67\x1a\x46\xd7\x5e\xb2\x97\x70\x3c\x1d\xc3\xe9\xf8\x0b\x6b\x3e\xde\x86\xe6\xfb\x9e\x33\x4f\xc1\x0d\x7e\x55\x01\xa5\xdc\xe6\xb5\x22\xb8\x4b\x45\xb4\x31\xba\x22\x9b\xa3\xa6\xeb\x28\xf4\x1d\xbf\xc3\xb3\xc5\xa7\xdc\x1e\xe0\x51\x1e\x20\x76\x5d\x0a\x8b\xe2\x44\x24\xb0\x43\x7a\x30\x35\x81\x35\x3b\x2c\x33\x21\xbe\x64\xa1\x2e\xe5\xfe\x67\x59\x2f\xb7\xd3\x8d\xbe\xa2\xf8\x06\x25\xd9\x7f\xf2\xd6\xbe\x17\x89\x2b\x98\x64\x9f\x03\x00\x59\xcd\x67\xb6\xd0\x01\x00\x00")
 
 func _1_messagesUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -106,12 +118,252 @@ func _1_messagesUpSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "1_messages.up.sql", size: 464, mode: os.FileMode(0664), modTime: time.Unix(1653581124, 0)}
+	info := bindataFileInfo{name: "1_messages.up.sql", size: 464, mode: os.FileMode(0664), modTime: time.Unix(1660143692, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x4, 0xd8, 0x47, 0x7b, 0xe, 0x47, 0x2a, 0x4b, 0x48, 0x36, 0x23, 0x93, 0x28, 0xb3, 0x1e, 0x5, 0x76, 0x64, 0x73, 0xb, 0x2b, 0x5b, 0x10, 0x62, 0x36, 0x21, 0x6f, 0xa3, 0x3c, 0xdd, 0xe2, 0xcf}}
 	return a, nil
 }
 
-var _docGo = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x2c\xc9\xb1\x0d\xc4\x20\x0c\x05\xd0\x9e\x29\xfe\x02\xd8\xfd\x6d\xe3\x4b\xac\x2f\x44\x82\x09\x78\x7f\xa5\x49\xfd\xa6\x1d\xdd\xe8\xd8\xcf\x55\x8a\x2a\xe3\x47\x1f\xbe\x2c\x1d\x8c\xfa\x6f\xe3\xb4\x34\xd4\xd9\x89\xbb\x71\x59\xb6\x18\x1b\x35\x20\xa2\x9f\x0a\x03\xa2\xe5\x0d\x00\x00\xff\xff\x60\xcd\x06\xbe\x4a\x00\x00\x00")
+var __2_raw_messageDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\xc8\x4d\x2d\x2e\x4e\x4c\x4f\x55\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\x28\x4a\x2c\xf7\x85\x08\x5b\x73\x01\x06\x00\x40\xec\xb2\x52\x2c\x00\x00\x00")
+
+func _2_raw_messageDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__2_raw_messageDownSql,
+		"2_raw_message.down.sql",
+	)
+}
+
+func _2_raw_messageDownSql() (*asset, error) {
+	bytes, err := _2_raw_messageDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "2_raw_message.down.sql", size: 44, mode: os.FileMode(0664), modTime: time.Unix(1792207422, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xce, 0x7b, 0xa3, 0x84, 0xa2, 0x32, 0x80, 0x8c, 0x5, 0x41, 0x2b, 0x30, 0x7f, 0x15, 0xfb, 0x68, 0x49, 0x3b, 0x48, 0x9c, 0x89, 0xa0, 0x3c, 0xcc, 0xae, 0xf8, 0x8e, 0xbd, 0xd, 0x0, 0x69, 0x7a}}
+	return a, nil
+}
+
+var __2_raw_messageUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\xc8\x4d\x2d\x2e\x4e\x4c\x4f\x55\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x28\x4a\x2c\xf7\x85\x8a\x3a\xf9\xf8\x3b\x59\x73\x01\x06\x00\xc9\xb4\xd1\xe7\x30\x00\x00\x00")
+
+func _2_raw_messageUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__2_raw_messageUpSql,
+		"2_raw_message.up.sql",
+	)
+}
+
+func _2_raw_messageUpSql() (*asset, error) {
+	bytes, err := _2_raw_messageUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "2_raw_message.up.sql", size: 48, mode: os.FileMode(0664), modTime: time.Unix(1792207422, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x43, 0xce, 0x73, 0xc8, 0xa2, 0x9b, 0xe4, 0x9a, 0x0, 0x1e, 0x66, 0x64, 0x29, 0x69, 0xb5, 0x86, 0xa5, 0x71, 0x30, 0x9a, 0xa5, 0x76, 0x26, 0xc6, 0xba, 0x52, 0xfb, 0x4c, 0xaa, 0x4, 0xa2, 0x79}}
+	return a, nil
+}
+
+var __3_message_hashDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\xc8\x4d\x2d\x2e\x4e\x4c\x4f\x8d\x87\xd2\x1e\x89\xc5\x19\xd6\x5c\x5c\x8e\x3e\x21\xae\x41\x0a\x21\x8e\x4e\x3e\xae\x30\x15\x0a\x60\xed\xce\xfe\x3e\xa1\xbe\x7e\x0a\x28\xaa\x01\x03\x00\x24\x39\x27\x97\x58\x00\x00\x00")
+
+func _3_message_hashDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__3_message_hashDownSql,
+		"3_message_hash.down.sql",
+	)
+}
+
+func _3_message_hashDownSql() (*asset, error) {
+	bytes, err := _3_message_hashDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "3_message_hash.down.sql", size: 88, mode: os.FileMode(0664), modTime: time.Unix(1792208544, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xdc, 0xd4, 0x3d, 0x23, 0x39, 0x1b, 0xf6, 0x4e, 0x29, 0xf5, 0xf6, 0x4b, 0xb3, 0x9, 0xc6, 0x23, 0xdd, 0xfb, 0x2, 0x6a, 0x37, 0x4, 0x4c, 0xe6, 0x2b, 0xe8, 0x1f, 0xe7, 0xa4, 0xd3, 0x5c, 0x29}}
+	return a, nil
+}
+
+var __3_message_hashUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\xc8\x4d\x2d\x2e\x4e\x4c\x4f\x55\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x83\x09\x79\x24\x16\x67\x28\x38\xf9\xf8\x3b\x59\x73\x71\x39\x07\xb9\x3a\x86\xb8\x2a\x78\xfa\xb9\xb8\x46\x28\x78\xba\x29\xf8\xf9\x87\x28\xb8\x46\x78\x06\x87\x04\xc3\x54\xc7\x23\xeb\xf2\x87\x1b\xa2\x81\x24\xac\x69\xcd\x05\x18\x00\xb1\x73\x2f\xa7\x7a\x00\x00\x00")
+
+func _3_message_hashUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__3_message_hashUpSql,
+		"3_message_hash.up.sql",
+	)
+}
+
+func _3_message_hashUpSql() (*asset, error) {
+	bytes, err := _3_message_hashUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "3_message_hash.up.sql", size: 122, mode: os.FileMode(0664), modTime: time.Unix(1792208544, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x7f, 0x68, 0x81, 0xd8, 0xd0, 0x2f, 0xd0, 0x16, 0x8a, 0xe6, 0x6e, 0x41, 0x2a, 0x61, 0xd, 0xc4, 0xb6, 0x85, 0xd9, 0x50, 0x70, 0xb3, 0x66, 0x59, 0x7b, 0xdb, 0xfa, 0x19, 0x8a, 0x3, 0x77, 0x29}}
+	return a, nil
+}
+
+var __4_canonical_message_hashDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\xc8\x4d\x2d\x2e\x4e\x4c\x4f\x8d\x87\xd2\x1e\x89\xc5\x19\xd6\x5c\x5c\xce\x41\xae\x8e\x21\xae\x08\xc5\x7e\xfe\x21\x78\x34\x28\xf8\xfb\xc1\x84\x35\x90\x84\x35\xad\xb9\x00\x03\x00\x35\x3b\x9a\x2c\x73\x00\x00\x00")
+
+func _4_canonical_message_hashDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__4_canonical_message_hashDownSql,
+		"4_canonical_message_hash.down.sql",
+	)
+}
+
+func _4_canonical_message_hashDownSql() (*asset, error) {
+	bytes, err := _4_canonical_message_hashDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "4_canonical_message_hash.down.sql", size: 115, mode: os.FileMode(0664), modTime: time.Unix(1792208682, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xb6, 0x82, 0x2, 0x93, 0x44, 0x27, 0x9a, 0x46, 0x76, 0xb, 0xfd, 0xda, 0x9f, 0x3c, 0xd6, 0x54, 0xa9, 0x27, 0xcb, 0x70, 0x36, 0xf7, 0x91, 0xa0, 0x60, 0x8d, 0x45, 0xf5, 0x72, 0x6e, 0x90, 0x7b}}
+	return a, nil
+}
+
+var __4_canonical_message_hashUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x7c\x8d\x41\x6a\xc3\x30\x10\x45\xf7\x3a\xc5\x5f\xb6\x90\xf4\x02\xa6\x8b\xb6\x56\xa9\x21\xc8\x69\x2c\x43\x76\x65\x2c\x4f\x2a\x81\x23\x05\x49\x0e\xe4\xf6\x45\x21\xa6\x59\x65\x35\xf0\xe6\xcd\x9b\xf5\x1a\xda\x32\x0c\xf9\xe0\x9d\xa1\x09\x47\x4e\x89\x7e\x19\x96\x92\x85\x09\x67\x8e\x09\xd9\x32\x4e\xf3\x90\xe6\x01\x39\x9c\x9c\x01\xf9\x11\x7c\xe6\x78\xc1\xc1\xf1\x34\x22\x1c\x40\xcb\xe5\x8b\xb8\x35\x4b\x81\xd3\xea\x2a\x97\x82\x1b\x4b\x89\x32\x4c\xf0\x99\x9c\x2f\xf0\xb8\x02\x45\x46\x64\x43\x93\x99\x27\xca\x3c\x62\xb8\x5c\xf5\xfa\xbd\xcb\x21\xb2\xa8\x77\xed\x16\x8d\xaa\xe5\x1e\xcd\x27\xe4\xbe\xe9\x74\xb7\xfc\xfa\xb9\xcd\x2f\x4a\xb6\x12\xa2\xdf\xd6\x6f\x5a\x2e\x4b\x74\x52\xe3\x4e\xc0\x2b\x54\xbf\xd9\x54\x42\x7c\xec\x64\xf1\x7a\xd5\x7c\xf7\xf2\xbf\xad\x5a\xfd\xa0\x8f\x56\x2d\xf8\xe9\x0e\x3f\x57\xe2\x6f\x00\xf6\x40\x5b\x04\x44\x01\x00\x00")
+
+func _4_canonical_message_hashUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__4_canonical_message_hashUpSql,
+		"4_canonical_message_hash.up.sql",
+	)
+}
+
+func _4_canonical_message_hashUpSql() (*asset, error) {
+	bytes, err := _4_canonical_message_hashUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "4_canonical_message_hash.up.sql", size: 324, mode: os.FileMode(0664), modTime: time.Unix(1792208682, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x34, 0x1c, 0x8, 0xe7, 0x72, 0xd, 0x3e, 0x4a, 0xb4, 0x60, 0x8b, 0x21, 0x65, 0xf3, 0x23, 0x23, 0xa8, 0xfb, 0xdd, 0x8d, 0xff, 0x0, 0x72, 0xc4, 0x17, 0xf, 0x6, 0x39, 0xc2, 0xe3, 0x5d, 0x1e}}
+	return a, nil
+}
+
+var __5_store_metadataDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x2e\xc9\x2f\x4a\x8d\xcf\x4d\x2d\x49\x4c\x49\x2c\x49\xb4\xe6\x02\x0c\x00\xf1\x16\xc7\x72\x25\x00\x00\x00")
+
+func _5_store_metadataDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__5_store_metadataDownSql,
+		"5_store_metadata.down.sql",
+	)
+}
+
+func _5_store_metadataDownSql() (*asset, error) {
+	bytes, err := _5_store_metadataDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "5_store_metadata.down.sql", size: 37, mode: os.FileMode(0664), modTime: time.Unix(1792209261, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xa7, 0x92, 0xdb, 0xb5, 0x8c, 0x11, 0xc4, 0xad, 0x3f, 0x9f, 0x9c, 0x6f, 0xac, 0xbf, 0x97, 0x7c, 0xbc, 0x5f, 0x77, 0x3b, 0xe, 0x21, 0x69, 0x71, 0x30, 0x1c, 0xcb, 0x7b, 0x14, 0x62, 0xea, 0x7a}}
+	return a, nil
+}
+
+var __5_store_metadataUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x24\xcd\xb1\x6e\x83\x30\x10\x87\xf1\x39\x7e\x8a\xff\xd8\x4a\xf1\x13\x74\x82\xca\x95\x50\xd3\xa6\x02\x0f\xc9\x54\x9d\x92\x0b\xb6\x28\x36\xf2\x1d\x54\xbc\x7d\x24\x58\xbf\x6f\xf8\x59\x8b\x8e\x55\x63\xea\x05\xf9\x01\x0d\x0c\xd1\x5c\x18\x1a\x48\x41\x85\x31\xf0\xa4\xf8\x8f\x1a\xb6\x39\xb2\x08\xf5\x2c\x47\xc8\x7c\x0b\x20\xd9\xea\x42\x7f\x33\x63\x16\xbe\x43\xb3\xb1\x16\x0b\x97\xf8\x58\xb7\xc7\xe9\x56\xd6\x49\x63\x4e\x18\x78\x35\xef\xad\xab\xbc\x83\xaf\xea\x93\x43\xf3\x81\xef\xb3\x87\xbb\x34\x9d\xef\x76\xf8\x77\x64\xa5\x3b\x29\xe1\xc5\x1c\x12\x8d\x0c\xef\x2e\x1e\x3f\x6d\xf3\x55\xb5\x57\x7c\xba\xeb\xd1\x1c\x76\xaf\x3e\x9d\x6b\xf3\xfa\x66\x9e\x03\x00\x5a\x28\x44\x1f\xc4\x00\x00\x00")
+
+func _5_store_metadataUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__5_store_metadataUpSql,
+		"5_store_metadata.up.sql",
+	)
+}
+
+func _5_store_metadataUpSql() (*asset, error) {
+	bytes, err := _5_store_metadataUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "5_store_metadata.up.sql", size: 196, mode: os.FileMode(0664), modTime: time.Unix(1792209261, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x27, 0x6f, 0x27, 0x6b, 0x64, 0x56, 0xfc, 0x87, 0x9a, 0x20, 0x34, 0x81, 0x1c, 0xf1, 0x3, 0x3a, 0x57, 0xf9, 0x2d, 0x8e, 0x4, 0xb8, 0xc0, 0x44, 0x2e, 0xa, 0x58, 0x52, 0x35, 0xa2, 0x64, 0xc5}}
+	return a, nil
+}
+
+var __6_query_indexesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\xc8\x4d\x2d\x2e\x4e\x4c\x4f\x8d\x4f\xce\xcf\x2b\x49\xcd\x2b\x09\xc9\x2f\xc8\x4c\x8e\x2f\x4e\xcd\x4b\x49\x2d\x0a\xc9\xcc\x4d\x2d\x2e\x49\xcc\x2d\xb0\xe6\xc2\xab\xb5\xa0\x34\xa9\xb8\x34\x09\x87\x4e\xc0\x00\x92\x2f\x0a\xd7\x75\x00\x00\x00")
+
+func _6_query_indexesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__6_query_indexesDownSql,
+		"6_query_indexes.down.sql",
+	)
+}
+
+func _6_query_indexesDownSql() (*asset, error) {
+	bytes, err := _6_query_indexesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "6_query_indexes.down.sql", size: 117, mode: os.FileMode(0664), modTime: time.Unix(1792209684, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe1, 0x53, 0x4b, 0x7, 0x43, 0xb8, 0x2c, 0x4c, 0x57, 0xf2, 0xa0, 0xe5, 0x1c, 0x8d, 0x66, 0xed, 0x4f, 0x5a, 0x44, 0x55, 0x67, 0x48, 0xae, 0xce, 0xec, 0x28, 0xeb, 0x66, 0x40, 0x37, 0x41, 0xa6}}
+	return a, nil
+}
+
+var __6_query_indexesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8c\x8f\xc1\x4a\xc4\x30\x14\x45\xf7\xf3\x15\x77\xa9\xd0\x7c\x81\x2b\xd1\x08\xd9\x74\xc0\xc9\x62\x76\x25\x6d\x9e\x36\x60\x92\x9a\xf7\x02\xf6\xef\x25\xd6\x42\x11\x44\xb7\xc9\x39\xf7\xf0\x94\x82\x49\x9e\x3e\x88\x51\x99\x3c\xc6\x15\x32\x13\xe6\xc0\x92\xcb\x8a\xf7\x4a\x25\x10\x43\x66\x27\x78\x09\x6f\x42\xe5\xeb\x3f\x12\xb3\x7b\x25\x6e\xfc\x52\x47\xae\x23\x24\x2f\x61\x42\x2e\x27\xa5\x30\xe5\x24\x94\x64\x7b\xeb\xe0\x92\x07\xe7\x22\x4d\x8d\x4d\x61\x4a\xbe\x2d\x85\x48\x2c\x2e\x2e\xa7\x87\x67\x7d\x6f\x35\x4c\xff\xa8\xaf\x30\x4f\xe8\xcf\x16\xfa\x6a\x2e\xf6\xb2\xa7\x86\x2d\x63\xdb\xe2\xb0\xf9\x76\xd7\x71\xee\x77\xec\xe6\x80\x75\xf8\xc1\x75\x08\xfe\xf6\xee\x3f\xb1\xef\x03\xfe\xac\x1d\xb9\xdf\x72\x9f\x03\x00\x5a\x74\x2c\x38\x63\x01\x00\x00")
+
+func _6_query_indexesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__6_query_indexesUpSql,
+		"6_query_indexes.up.sql",
+	)
+}
+
+func _6_query_indexesUpSql() (*asset, error) {
+	bytes, err := _6_query_indexesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "6_query_indexes.up.sql", size: 355, mode: os.FileMode(0664), modTime: time.Unix(1792209684, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x2b, 0xb7, 0x97, 0x24, 0xbc, 0xa4, 0xc7, 0xd3, 0xa5, 0xfc, 0x33, 0xb, 0xd, 0x6a, 0xec, 0x6c, 0xd2, 0x2e, 0x75, 0x8f, 0x9e, 0xde, 0x7b, 0xe1, 0xcf, 0x4d, 0x98, 0x81, 0xd2, 0xbe, 0xf6, 0x66}}
+	return a, nil
+}
+
+var __7_tombstonesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\xc9\xcf\x4d\x2a\x2e\xc9\xcf\x4b\xb5\xe6\x02\x0c\x00\xcd\x87\x5f\x37\x20\x00\x00\x00")
+
+func _7_tombstonesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__7_tombstonesDownSql,
+		"7_tombstones.down.sql",
+	)
+}
+
+func _7_tombstonesDownSql() (*asset, error) {
+	bytes, err := _7_tombstonesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "7_tombstones.down.sql", size: 32, mode: os.FileMode(0664), modTime: time.Unix(1792210495, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x46, 0x74, 0x99, 0xb9, 0x65, 0x97, 0x3c, 0x9f, 0xe1, 0xab, 0xdf, 0xfb, 0xa5, 0xec, 0x98, 0xc5, 0x5e, 0x89, 0x0, 0xb1, 0x3f, 0xe6, 0xe9, 0x84, 0x81, 0x98, 0x6e, 0xc4, 0x58, 0x98, 0x11, 0xb}}
+	return a, nil
+}
+
+var __7_tombstonesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x2c\xcd\xd1\x6a\x83\x30\x14\x06\xe0\x6b\xf3\x14\xff\xe5\x06\xfa\x04\xbb\x8a\x23\xdb\xc2\x32\x1d\x31\x85\x7a\x19\xeb\xa9\x11\x6a\x52\x92\x03\xa5\x6f\x5f\x5a\x7c\x81\xef\x6b\x1a\xfc\xf8\x12\xa8\x20\x9d\xc1\x81\xb0\x51\x29\x7e\xa1\x82\x99\x2e\xc4\x34\x63\xba\xc3\x47\xa4\x2b\x65\xcf\x29\xd7\xb8\x85\xf5\x14\xe0\x33\x21\x26\x46\xe1\x94\x69\x86\x5f\xfc\x1a\xc5\xa7\x55\xd2\x29\x38\xd9\x1a\x05\xfd\x85\xae\x77\x50\x47\x3d\xb8\x01\x9c\xb6\xa9\x70\x8a\x84\x37\x51\xed\xc7\x33\x46\x6b\xfa\x16\xff\x56\xff\x49\x3b\xe2\x57\x8d\xb5\xa8\xf6\x59\x32\x74\xe7\xd4\xb7\xb2\x2f\xa8\x3b\x18\x23\xde\x3f\xc4\x63\x00\x50\xe5\xf9\xff\xb2\x00\x00\x00")
+
+func _7_tombstonesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__7_tombstonesUpSql,
+		"7_tombstones.up.sql",
+	)
+}
+
+func _7_tombstonesUpSql() (*asset, error) {
+	bytes, err := _7_tombstonesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "7_tombstones.up.sql", size: 178, mode: os.FileMode(0664), modTime: time.Unix(1792210495, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x26, 0x36, 0xb, 0xf8, 0x82, 0x47, 0x6, 0x31, 0x53, 0x13, 0x22, 0x62, 0x94, 0x1, 0x38, 0x55, 0x8d, 0xf3, 0xbc, 0xd2, 0xd, 0xe6, 0x28, 0xf6, 0x78, 0x64, 0xf, 0xdb, 0x2a, 0x2b, 0x4d, 0xb5}}
+	return a, nil
+}
+
+var _docGo = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x2c\xc9\xb1\x0d\xc4\x20\x0c\x05\xd0\x9e\x29\xfe\x02\xd8\xfd\x6d\xe3\x4b\xac\x2f\x44\x82\x09\x78\x7f\xa5\x49\xfd\xa6\x1d\xdd\xe8\xd8\xcf\x55\x8a\x2a\xe3\x47\x1f\xbe\x2c\x1d\x8c\xfa\x6f\xe3\xb4\x34\xd4\xd9\x89\xbb\x71\x59\xb6\x18\x1b\x35\x20\xa2\x9f\x0a\x03\xa2\xe5\x1d\x00\x60\xcd\x06\xbe\x4a\x00\x00\x00")
 
 func docGoBytes() ([]byte, error) {
 	return bindataRead(
@@ -126,7 +378,7 @@ func docGo() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "doc.go", size: 74, mode: os.FileMode(0664), modTime: time.Unix(1653581182, 0)}
+	info := bindataFileInfo{name: "doc.go", size: 74, mode: os.FileMode(0664), modTime: time.Unix(1660143692, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xde, 0x7c, 0x28, 0xcd, 0x47, 0xf2, 0xfa, 0x7c, 0x51, 0x2d, 0xd8, 0x38, 0xb, 0xb0, 0x34, 0x9d, 0x4c, 0x62, 0xa, 0x9e, 0x28, 0xc3, 0x31, 0x23, 0xd9, 0xbb, 0x89, 0x9f, 0xa0, 0x89, 0x1f, 0xe8}}
 	return a, nil
 }
@@ -222,9 +474,21 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
-	"1_messages.down.sql": _1_messagesDownSql,
-	"1_messages.up.sql":   _1_messagesUpSql,
-	"doc.go":              docGo,
+	"1_messages.down.sql":               _1_messagesDownSql,
+	"1_messages.up.sql":                 _1_messagesUpSql,
+	"2_raw_message.down.sql":            _2_raw_messageDownSql,
+	"2_raw_message.up.sql":              _2_raw_messageUpSql,
+	"3_message_hash.down.sql":           _3_message_hashDownSql,
+	"3_message_hash.up.sql":             _3_message_hashUpSql,
+	"4_canonical_message_hash.down.sql": _4_canonical_message_hashDownSql,
+	"4_canonical_message_hash.up.sql":   _4_canonical_message_hashUpSql,
+	"5_store_metadata.down.sql":         _5_store_metadataDownSql,
+	"5_store_metadata.up.sql":           _5_store_metadataUpSql,
+	"6_query_indexes.down.sql":          _6_query_indexesDownSql,
+	"6_query_indexes.up.sql":            _6_query_indexesUpSql,
+	"7_tombstones.down.sql":             _7_tombstonesDownSql,
+	"7_tombstones.up.sql":               _7_tombstonesUpSql,
+	"doc.go":                            docGo,
 }
 
 // AssetDebug is true if the assets were built with the debug flag enabled.
@@ -273,6 +537,18 @@ type bintree struct {
 var _bintree = &bintree{nil, map[string]*bintree{
 	"1_messages.down.sql": {_1_messagesDownSql, map[string]*bintree{}},
 	"1_messages.up.sql": {_1_messagesUpSql, map[string]*bintree{}},
+	"2_raw_message.down.sql": {_2_raw_messageDownSql, map[string]*bintree{}},
+	"2_raw_message.up.sql": {_2_raw_messageUpSql, map[string]*bintree{}},
+	"3_message_hash.down.sql": {_3_message_hashDownSql, map[string]*bintree{}},
+	"3_message_hash.up.sql": {_3_message_hashUpSql, map[string]*bintree{}},
+	"4_canonical_message_hash.down.sql": {_4_canonical_message_hashDownSql, map[string]*bintree{}},
+	"4_canonical_message_hash.up.sql": {_4_canonical_message_hashUpSql, map[string]*bintree{}},
+	"5_store_metadata.down.sql": {_5_store_metadataDownSql, map[string]*bintree{}},
+	"5_store_metadata.up.sql": {_5_store_metadataUpSql, map[string]*bintree{}},
+	"6_query_indexes.down.sql": {_6_query_indexesDownSql, map[string]*bintree{}},
+	"6_query_indexes.up.sql": {_6_query_indexesUpSql, map[string]*bintree{}},
+	"7_tombstones.down.sql": {_7_tombstonesDownSql, map[string]*bintree{}},
+	"7_tombstones.up.sql": {_7_tombstonesUpSql, map[string]*bintree{}},
 	"doc.go": {docGo, map[string]*bintree{}},
 }}
 
@@ -0,0 +1,3 @@
+ALTER TABLE message ADD COLUMN messageHash BLOB;
+
+CREATE INDEX IF NOT EXISTS message_messageHash ON message(messageHash);
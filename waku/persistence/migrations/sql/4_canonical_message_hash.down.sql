@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS message_messageHash;
+
+CREATE INDEX IF NOT EXISTS message_messageHash ON message(messageHash);
@@ -0,0 +1,6 @@
+-- Settings of the store that are kept with the messages, such as the value used to
+-- verify the encryption key
+CREATE TABLE IF NOT EXISTS store_metadata (
+	name TEXT PRIMARY KEY,
+	value BLOB
+);
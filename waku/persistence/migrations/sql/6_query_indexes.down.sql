@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS message_contentTopic_senderTimestamp;
+DROP INDEX IF EXISTS message_pubsubTopic_senderTimestamp;
@@ -0,0 +1,596 @@
+// Code generated by go-bindata. DO NOT EDIT.
+// sources:
+// 1_messages.down.sql (124B)
+// 1_messages.up.sql (451B)
+// 2_raw_message.down.sql (54B)
+// 2_raw_message.up.sql (63B)
+// 3_message_hash.down.sql (98B)
+// 3_message_hash.up.sql (137B)
+// 4_canonical_message_hash.down.sql (115B)
+// 4_canonical_message_hash.up.sql (324B)
+// 5_store_metadata.down.sql (37B)
+// 5_store_metadata.up.sql (197B)
+// 6_query_indexes.down.sql (117B)
+// 6_query_indexes.up.sql (355B)
+// 7_tombstones.down.sql (32B)
+// 7_tombstones.up.sql (178B)
+// doc.go (74B)
+
+package migrations
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func bindataRead(data []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewBuffer(data))
+	if err != nil {
+		return nil, fmt.Errorf("read %q: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, gz)
+	clErr := gz.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("read %q: %w", name, err)
+	}
+	if clErr != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+type asset struct {
+	bytes  []byte
+	info   os.FileInfo
+	digest [sha256.Size]byte
+}
+
+type bindataFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (fi bindataFileInfo) Name() string {
+	return fi.name
+}
+func (fi bindataFileInfo) Size() int64 {
+	return fi.size
+}
+func (fi bindataFileInfo) Mode() os.FileMode {
+	return fi.mode
+}
+func (fi bindataFileInfo) ModTime() time.Time {
+	return fi.modTime
+}
+func (fi bindataFileInfo) IsDir() bool {
+	return false
+}
+func (fi bindataFileInfo) Sys() interface{} {
+	return nil
+}
+
+var __1_messagesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\xc8\x4d\x2d\x2e\x4e\x4c\x4f\x8d\x2f\x4e\xcd\x4b\x49\x2d\x0a\xc9\xcc\x4d\x2d\x2e\x49\xcc\x2d\xb0\xe6\xc2\xab\xba\x28\x35\x39\x35\xb3\x0c\x53\x7d\x88\xa3\x93\x8f\x2b\xa6\x7a\x6b\x2e\xc0\x00\xc2\x48\x8c\x05\x7c\x00\x00\x00")
+
+func _1_messagesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1_messagesDownSql,
+		"1_messages.down.sql",
+	)
+}
+
+func _1_messagesDownSql() (*asset, error) {
+	bytes, err := _1_messagesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1_messages.down.sql", size: 124, mode: os.FileMode(0664), modTime: time.Unix(1792207046, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xff, 0x4a, 0x8e, 0xa9, 0xd9, 0xa8, 0xa4, 0x73, 0x3a, 0x54, 0xe4, 0x35, 0xfd, 0xea, 0x87, 0x4c, 0xa, 0x5c, 0xc0, 0xc9, 0xe7, 0x8, 0x8c, 0x6f, 0x60, 0x9e, 0x54, 0x77, 0x59, 0xd0, 0x2b, 0xfe}}
+	return a, nil
+}
+
+var __1_messagesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8c\x90\x41\x4f\x83\x40\x10\x85\xcf\xec\xaf\x98\x23\x24\x1c\xbc\x73\x5a\xda\x69\x33\x11\x17\xb3\x4c\x13\x38\x19\x0a\x13\xb3\x89\x2c\x84\xa5\x8d\xfe\x7b\xa3\xa9\x5a\x5b\x35\x9e\xbf\x37\x6f\xde\x7b\x2b\x8b\x9a\x11\x58\xe7\x05\x02\x6d\xc0\x94\x0c\x58\x53\xc5\x15\x0c\x12\x42\xfb\x28\x10\xab\xc8\xf5\x90\x37\x8c\x3a\x55\xd1\x2c\x9d\xb8\xa3\xcc\xec\x06\x09\x4b\x3b\x4c\x90\xd3\x96\x0c\xbf\x5f\x9a\x5d\x51\xa4\x2a\x0a\xe2\xfb\xbf\x15\xdd\xe8\x17\xf1\x0b\x8f\x93\xeb\x80\xb1\xfe\x06\xa7\xc3\x3e\x1c\xf6\xbf\xb0\xf6\xe5\x69\x6c\xbf\xe2\x1c\x65\x0e\x6e\xf4\x40\x86\x71\x8b\xf6\x53\x0a\x6b\xdc\xe8\x5d\xc1\x70\x93\xaa\x68\x55\x9a\x8a\xad\x7e\xcb\x70\x2a\x45\xbe\x97\x67\xb8\xb7\x74\xa7\x6d\x03\xb7\xd8\x40\xec\xfa\x14\xce\x3e\x27\x2a\xc9\x94\x3a\xcd\x43\x66\x8d\xf5\xcf\xf3\x3c\x5c\x96\x2d\xcd\x07\x8a\x2f\x50\x92\xfd\xc7\xef\x7a\xe0\x33\xc7\x2b\x98\x64\xea\x75\x00\x02\xde\x73\x58\xc3\x01\x00\x00")
+
+func _1_messagesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1_messagesUpSql,
+		"1_messages.up.sql",
+	)
+}
+
+func _1_messagesUpSql() (*asset, error) {
+	bytes, err := _1_messagesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1_messages.up.sql", size: 451, mode: os.FileMode(0664), modTime: time.Unix(1792207046, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x89, 0xb1, 0xa3, 0x8, 0x37, 0xc9, 0x64, 0x36, 0xdf, 0xa6, 0x7b, 0x66, 0x21, 0xb1, 0x27, 0x88, 0x12, 0x1c, 0x1e, 0xf2, 0xc7, 0x91, 0xb2, 0xe9, 0x55, 0x69, 0x5e, 0x29, 0x16, 0xdc, 0x36, 0x5a}}
+	return a, nil
+}
+
+var __2_raw_messageDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\xc8\x4d\x2d\x2e\x4e\x4c\x4f\x55\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x4a\x2c\xf7\x85\x28\xb0\xe6\x02\x0c\x00\x27\xc5\xc9\x2f\x36\x00\x00\x00")
+
+func _2_raw_messageDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__2_raw_messageDownSql,
+		"2_raw_message.down.sql",
+	)
+}
+
+func _2_raw_messageDownSql() (*asset, error) {
+	bytes, err := _2_raw_messageDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "2_raw_message.down.sql", size: 54, mode: os.FileMode(0664), modTime: time.Unix(1792207422, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xf7, 0xac, 0x6, 0x22, 0x7a, 0xb0, 0xb, 0xe5, 0x4, 0xc7, 0x32, 0x93, 0xb1, 0xfa, 0x4b, 0x99, 0x33, 0xbc, 0x87, 0x8f, 0xad, 0x61, 0xca, 0xef, 0xa9, 0x1d, 0x72, 0x63, 0xa2, 0x19, 0x23, 0x69}}
+	return a, nil
+}
+
+var __2_raw_messageUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\xc8\x4d\x2d\x2e\x4e\x4c\x4f\x55\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\xf0\xf3\x0f\x51\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x4a\x2c\xf7\x85\xaa\x71\x8a\x0c\x71\x75\xb4\xe6\x02\x0c\x00\x8b\x6d\x1e\xf2\x3f\x00\x00\x00")
+
+func _2_raw_messageUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__2_raw_messageUpSql,
+		"2_raw_message.up.sql",
+	)
+}
+
+func _2_raw_messageUpSql() (*asset, error) {
+	bytes, err := _2_raw_messageUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "2_raw_message.up.sql", size: 63, mode: os.FileMode(0664), modTime: time.Unix(1792207422, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x7e, 0xce, 0x3b, 0x7a, 0x92, 0xa7, 0xe8, 0x44, 0xcc, 0xf5, 0x43, 0xa9, 0x5f, 0xc0, 0xa0, 0xbe, 0x4c, 0x90, 0x1d, 0x50, 0xbe, 0x6c, 0xb4, 0xac, 0x3c, 0x43, 0x6f, 0xb4, 0x5b, 0x78, 0xbd, 0x13}}
+	return a, nil
+}
+
+var __3_message_hashDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\xc8\x4d\x2d\x2e\x4e\x4c\x4f\x8d\x87\xd2\x1e\x89\xc5\x19\xd6\x5c\x5c\x8e\x3e\x21\xae\x41\x0a\x21\x8e\x4e\x3e\xae\x30\x15\x0a\x60\xed\xce\xfe\x3e\xa1\xbe\x7e\x98\xfa\x21\xfa\x00\x03\x00\x5b\xb0\xf4\x04\x62\x00\x00\x00")
+
+func _3_message_hashDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__3_message_hashDownSql,
+		"3_message_hash.down.sql",
+	)
+}
+
+func _3_message_hashDownSql() (*asset, error) {
+	bytes, err := _3_message_hashDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "3_message_hash.down.sql", size: 98, mode: os.FileMode(0664), modTime: time.Unix(1792208544, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xa6, 0xd7, 0x75, 0x78, 0xe3, 0xd2, 0xa, 0x79, 0xe0, 0xf9, 0xe9, 0xd, 0xb3, 0x99, 0x24, 0xa9, 0x51, 0xb4, 0xcb, 0xeb, 0x6e, 0xb3, 0x92, 0x5c, 0x83, 0xf6, 0x9b, 0xe4, 0x77, 0x78, 0x40, 0xc1}}
+	return a, nil
+}
+
+var __3_message_hashUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\xc8\x4d\x2d\x2e\x4e\x4c\x4f\x55\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\xf0\xf3\x0f\x51\x70\x8d\xf0\x0c\x0e\x09\x86\x29\xf0\x48\x2c\xce\x50\x70\x8a\x0c\x71\x75\xb4\xe6\xe2\x72\x0e\x72\x75\x0c\x71\x55\xf0\xf4\x73\x71\x8d\xc0\xae\x3c\x1e\x59\x9b\xbf\x1f\x4c\x58\x03\x49\x58\xd3\x9a\x0b\x30\x00\x56\x81\x43\x2a\x89\x00\x00\x00")
+
+func _3_message_hashUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__3_message_hashUpSql,
+		"3_message_hash.up.sql",
+	)
+}
+
+func _3_message_hashUpSql() (*asset, error) {
+	bytes, err := _3_message_hashUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "3_message_hash.up.sql", size: 137, mode: os.FileMode(0664), modTime: time.Unix(1792208544, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x47, 0xdf, 0xa1, 0x66, 0xc3, 0x92, 0x0, 0xcc, 0xd9, 0x3a, 0x14, 0x23, 0x8d, 0xf3, 0x68, 0xcd, 0xbd, 0x79, 0x76, 0xa5, 0xc0, 0x36, 0x52, 0x41, 0x30, 0xfa, 0xf7, 0xe0, 0x84, 0xf4, 0x29, 0xc8}}
+	return a, nil
+}
+
+var __4_canonical_message_hashDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\xc8\x4d\x2d\x2e\x4e\x4c\x4f\x8d\x87\xd2\x1e\x89\xc5\x19\xd6\x5c\x5c\xce\x41\xae\x8e\x21\xae\x08\xc5\x7e\xfe\x21\x78\x34\x28\xf8\xfb\xc1\x84\x35\x90\x84\x35\xad\xb9\x00\x03\x00\x35\x3b\x9a\x2c\x73\x00\x00\x00")
+
+func _4_canonical_message_hashDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__4_canonical_message_hashDownSql,
+		"4_canonical_message_hash.down.sql",
+	)
+}
+
+func _4_canonical_message_hashDownSql() (*asset, error) {
+	bytes, err := _4_canonical_message_hashDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "4_canonical_message_hash.down.sql", size: 115, mode: os.FileMode(0664), modTime: time.Unix(1792208682, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xb6, 0x82, 0x2, 0x93, 0x44, 0x27, 0x9a, 0x46, 0x76, 0xb, 0xfd, 0xda, 0x9f, 0x3c, 0xd6, 0x54, 0xa9, 0x27, 0xcb, 0x70, 0x36, 0xf7, 0x91, 0xa0, 0x60, 0x8d, 0x45, 0xf5, 0x72, 0x6e, 0x90, 0x7b}}
+	return a, nil
+}
+
+var __4_canonical_message_hashUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x7c\x8d\x41\x6a\xc3\x30\x10\x45\xf7\x3a\xc5\x5f\xb6\x90\xf4\x02\xa6\x8b\xb6\x56\xa9\x21\xc8\x69\x2c\x43\x76\x65\x2c\x4f\x2a\x81\x23\x05\x49\x0e\xe4\xf6\x45\x21\xa6\x59\x65\x35\xf0\xe6\xcd\x9b\xf5\x1a\xda\x32\x0c\xf9\xe0\x9d\xa1\x09\x47\x4e\x89\x7e\x19\x96\x92\x85\x09\x67\x8e\x09\xd9\x32\x4e\xf3\x90\xe6\x01\x39\x9c\x9c\x01\xf9\x11\x7c\xe6\x78\xc1\xc1\xf1\x34\x22\x1c\x40\xcb\xe5\x8b\xb8\x35\x4b\x81\xd3\xea\x2a\x97\x82\x1b\x4b\x89\x32\x4c\xf0\x99\x9c\x2f\xf0\xb8\x02\x45\x46\x64\x43\x93\x99\x27\xca\x3c\x62\xb8\x5c\xf5\xfa\xbd\xcb\x21\xb2\xa8\x77\xed\x16\x8d\xaa\xe5\x1e\xcd\x27\xe4\xbe\xe9\x74\xb7\xfc\xfa\xb9\xcd\x2f\x4a\xb6\x12\xa2\xdf\xd6\x6f\x5a\x2e\x4b\x74\x52\xe3\x4e\xc0\x2b\x54\xbf\xd9\x54\x42\x7c\xec\x64\xf1\x7a\xd5\x7c\xf7\xf2\xbf\xad\x5a\xfd\xa0\x8f\x56\x2d\xf8\xe9\x0e\x3f\x57\xe2\x6f\x00\xf6\x40\x5b\x04\x44\x01\x00\x00")
+
+func _4_canonical_message_hashUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__4_canonical_message_hashUpSql,
+		"4_canonical_message_hash.up.sql",
+	)
+}
+
+func _4_canonical_message_hashUpSql() (*asset, error) {
+	bytes, err := _4_canonical_message_hashUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "4_canonical_message_hash.up.sql", size: 324, mode: os.FileMode(0664), modTime: time.Unix(1792208682, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x34, 0x1c, 0x8, 0xe7, 0x72, 0xd, 0x3e, 0x4a, 0xb4, 0x60, 0x8b, 0x21, 0x65, 0xf3, 0x23, 0x23, 0xa8, 0xfb, 0xdd, 0x8d, 0xff, 0x0, 0x72, 0xc4, 0x17, 0xf, 0x6, 0x39, 0xc2, 0xe3, 0x5d, 0x1e}}
+	return a, nil
+}
+
+var __5_store_metadataDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x2e\xc9\x2f\x4a\x8d\xcf\x4d\x2d\x49\x4c\x49\x2c\x49\xb4\xe6\x02\x0c\x00\xf1\x16\xc7\x72\x25\x00\x00\x00")
+
+func _5_store_metadataDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__5_store_metadataDownSql,
+		"5_store_metadata.down.sql",
+	)
+}
+
+func _5_store_metadataDownSql() (*asset, error) {
+	bytes, err := _5_store_metadataDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "5_store_metadata.down.sql", size: 37, mode: os.FileMode(0664), modTime: time.Unix(1792209261, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xa7, 0x92, 0xdb, 0xb5, 0x8c, 0x11, 0xc4, 0xad, 0x3f, 0x9f, 0x9c, 0x6f, 0xac, 0xbf, 0x97, 0x7c, 0xbc, 0x5f, 0x77, 0x3b, 0xe, 0x21, 0x69, 0x71, 0x30, 0x1c, 0xcb, 0x7b, 0x14, 0x62, 0xea, 0x7a}}
+	return a, nil
+}
+
+var __5_store_metadataUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x24\xcd\xbd\x6a\xc3\x30\x14\x47\xf1\x39\x7a\x8a\xff\xd8\x42\xf4\x04\x9d\x94\xa2\x82\xe9\x27\xb6\x86\x78\x2a\x97\xe4\x26\x12\xae\x25\xa3\x7b\xed\xe2\xb7\x0f\xd8\xeb\x39\xc3\xcf\x5a\x74\xac\x9a\xf2\x5d\x50\x6e\xd0\xc8\x10\x2d\x95\xa1\x91\x14\x54\x19\x03\x4f\x8a\xff\xa4\x71\x9b\x23\x8b\xd0\x9d\xe5\x08\x99\x2f\x11\x24\x5b\x5d\xe8\x6f\x66\xcc\xc2\x57\x68\x31\xd6\x62\xe1\x9a\x6e\xeb\xf6\x38\x5f\xea\x3a\x69\x2a\x19\x03\xaf\xe6\xb5\xf5\x2e\x78\x04\x77\xfa\xf0\x68\xde\xf0\xf5\x1d\xe0\xcf\x4d\x17\xba\x1d\xfe\x1d\x59\xe9\x4a\x4a\x78\x32\x87\x4c\x23\x23\xf8\x73\xc0\x4f\xdb\x7c\xba\xb6\xc7\xbb\xef\x8f\xe6\xb0\x7b\xa7\x3e\x78\x67\x9e\x5f\xcc\x63\x00\xb6\x24\xaa\xb1\xc5\x00\x00\x00")
+
+func _5_store_metadataUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__5_store_metadataUpSql,
+		"5_store_metadata.up.sql",
+	)
+}
+
+func _5_store_metadataUpSql() (*asset, error) {
+	bytes, err := _5_store_metadataUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "5_store_metadata.up.sql", size: 197, mode: os.FileMode(0664), modTime: time.Unix(1792209261, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xb4, 0xb2, 0x73, 0xfd, 0x73, 0x6e, 0xcc, 0x2c, 0xd, 0x10, 0x3e, 0xba, 0x4, 0xb2, 0x96, 0x93, 0xa8, 0x9, 0x44, 0xcb, 0x92, 0x78, 0x49, 0xa4, 0xf6, 0x5, 0x67, 0xbc, 0xd1, 0x19, 0x5d, 0x67}}
+	return a, nil
+}
+
+var __6_query_indexesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\xc8\x4d\x2d\x2e\x4e\x4c\x4f\x8d\x4f\xce\xcf\x2b\x49\xcd\x2b\x09\xc9\x2f\xc8\x4c\x8e\x2f\x4e\xcd\x4b\x49\x2d\x0a\xc9\xcc\x4d\x2d\x2e\x49\xcc\x2d\xb0\xe6\xc2\xab\xb5\xa0\x34\xa9\xb8\x34\x09\x87\x4e\xc0\x00\x92\x2f\x0a\xd7\x75\x00\x00\x00")
+
+func _6_query_indexesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__6_query_indexesDownSql,
+		"6_query_indexes.down.sql",
+	)
+}
+
+func _6_query_indexesDownSql() (*asset, error) {
+	bytes, err := _6_query_indexesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "6_query_indexes.down.sql", size: 117, mode: os.FileMode(0664), modTime: time.Unix(1792209684, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe1, 0x53, 0x4b, 0x7, 0x43, 0xb8, 0x2c, 0x4c, 0x57, 0xf2, 0xa0, 0xe5, 0x1c, 0x8d, 0x66, 0xed, 0x4f, 0x5a, 0x44, 0x55, 0x67, 0x48, 0xae, 0xce, 0xec, 0x28, 0xeb, 0x66, 0x40, 0x37, 0x41, 0xa6}}
+	return a, nil
+}
+
+var __6_query_indexesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8c\x8f\xc1\x4a\xc4\x30\x14\x45\xf7\xf3\x15\x77\xa9\xd0\x7c\x81\x2b\xd1\x08\xd9\x74\xc0\xc9\x62\x76\x25\x6d\x9e\x36\x60\x92\x9a\xf7\x02\xf6\xef\x25\xd6\x42\x11\x44\xb7\xc9\x39\xf7\xf0\x94\x82\x49\x9e\x3e\x88\x51\x99\x3c\xc6\x15\x32\x13\xe6\xc0\x92\xcb\x8a\xf7\x4a\x25\x10\x43\x66\x27\x78\x09\x6f\x42\xe5\xeb\x3f\x12\xb3\x7b\x25\x6e\xfc\x52\x47\xae\x23\x24\x2f\x61\x42\x2e\x27\xa5\x30\xe5\x24\x94\x64\x7b\xeb\xe0\x92\x07\xe7\x22\x4d\x8d\x4d\x61\x4a\xbe\x2d\x85\x48\x2c\x2e\x2e\xa7\x87\x67\x7d\x6f\x35\x4c\xff\xa8\xaf\x30\x4f\xe8\xcf\x16\xfa\x6a\x2e\xf6\xb2\xa7\x86\x2d\x63\xdb\xe2\xb0\xf9\x76\xd7\x71\xee\x77\xec\xe6\x80\x75\xf8\xc1\x75\x08\xfe\xf6\xee\x3f\xb1\xef\x03\xfe\xac\x1d\xb9\xdf\x72\x9f\x03\x00\x5a\x74\x2c\x38\x63\x01\x00\x00")
+
+func _6_query_indexesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__6_query_indexesUpSql,
+		"6_query_indexes.up.sql",
+	)
+}
+
+func _6_query_indexesUpSql() (*asset, error) {
+	bytes, err := _6_query_indexesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "6_query_indexes.up.sql", size: 355, mode: os.FileMode(0664), modTime: time.Unix(1792209684, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x2b, 0xb7, 0x97, 0x24, 0xbc, 0xa4, 0xc7, 0xd3, 0xa5, 0xfc, 0x33, 0xb, 0xd, 0x6a, 0xec, 0x6c, 0xd2, 0x2e, 0x75, 0x8f, 0x9e, 0xde, 0x7b, 0xe1, 0xcf, 0x4d, 0x98, 0x81, 0xd2, 0xbe, 0xf6, 0x66}}
+	return a, nil
+}
+
+var __7_tombstonesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\xc9\xcf\x4d\x2a\x2e\xc9\xcf\x4b\xb5\xe6\x02\x0c\x00\xcd\x87\x5f\x37\x20\x00\x00\x00")
+
+func _7_tombstonesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__7_tombstonesDownSql,
+		"7_tombstones.down.sql",
+	)
+}
+
+func _7_tombstonesDownSql() (*asset, error) {
+	bytes, err := _7_tombstonesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "7_tombstones.down.sql", size: 32, mode: os.FileMode(0664), modTime: time.Unix(1792210495, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x46, 0x74, 0x99, 0xb9, 0x65, 0x97, 0x3c, 0x9f, 0xe1, 0xab, 0xdf, 0xfb, 0xa5, 0xec, 0x98, 0xc5, 0x5e, 0x89, 0x0, 0xb1, 0x3f, 0xe6, 0xe9, 0x84, 0x81, 0x98, 0x6e, 0xc4, 0x58, 0x98, 0x11, 0xb}}
+	return a, nil
+}
+
+var __7_tombstonesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x2c\xcd\xd1\x0a\x82\x30\x14\x06\xe0\x6b\xf7\x14\xff\x65\x81\x3e\x41\x57\x33\x56\x8d\xcc\x42\x17\xe4\xe5\xcc\x93\x13\x72\x8b\xed\x40\xf4\xf6\x51\xf8\x02\xdf\x57\x14\x38\xd8\xe4\x28\x21\x3c\xc0\x8e\x30\x53\x4a\x76\xa4\x84\x81\x9e\xc4\x34\xa0\xff\xc0\x7a\x84\x17\x45\xcb\x21\xe6\x78\xbb\xe9\xee\x60\x23\xc1\x07\x46\xe2\x10\x69\x80\x1d\xed\xe4\xc5\xb6\x51\xd2\x28\x18\x59\x56\x0a\x7a\x87\xfa\x6c\xa0\x6e\xba\x35\x2d\x38\xcc\x7d\xe2\xe0\x09\x2b\x91\x2d\xc7\x2f\x46\xd9\x19\x25\x71\x69\xf4\x49\x36\x1d\x8e\xaa\xcb\x45\xb6\xd4\x92\x51\xea\xbd\xae\xcd\x1f\xaa\xaf\x55\x25\xd6\x1b\xf1\x1d\x00\x0e\x95\xb4\x0b\xb2\x00\x00\x00")
+
+func _7_tombstonesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__7_tombstonesUpSql,
+		"7_tombstones.up.sql",
+	)
+}
+
+func _7_tombstonesUpSql() (*asset, error) {
+	bytes, err := _7_tombstonesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "7_tombstones.up.sql", size: 178, mode: os.FileMode(0664), modTime: time.Unix(1792210495, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x1, 0x63, 0xca, 0x4c, 0x1, 0x11, 0x66, 0xf9, 0xf9, 0xad, 0xe9, 0xc5, 0x39, 0xae, 0x5a, 0xf9, 0xb, 0xd4, 0x96, 0x90, 0xeb, 0xbb, 0x15, 0x9e, 0xf7, 0x6d, 0x99, 0xa2, 0xa8, 0xec, 0x32, 0x59}}
+	return a, nil
+}
+
+var _docGo = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x2c\xc9\xb1\x0d\xc4\x20\x0c\x05\xd0\x9e\x29\xfe\x02\xd8\xfd\x6d\xe3\x4b\xac\x2f\x44\x82\x09\x78\x7f\xa5\x49\xfd\xa6\x1d\xdd\xe8\xd8\xcf\x55\x8a\x2a\xe3\x47\x1f\xbe\x2c\x1d\x8c\xfa\x6f\xe3\xb4\x34\xd4\xd9\x89\xbb\x71\x59\xb6\x18\x1b\x35\x20\xa2\x9f\x0a\x03\xa2\xe5\x1d\x00\x60\xcd\x06\xbe\x4a\x00\x00\x00")
+
+func docGoBytes() ([]byte, error) {
+	return bindataRead(
+		_docGo,
+		"doc.go",
+	)
+}
+
+func docGo() (*asset, error) {
+	bytes, err := docGoBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "doc.go", size: 74, mode: os.FileMode(0664), modTime: time.Unix(1792207046, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xde, 0x7c, 0x28, 0xcd, 0x47, 0xf2, 0xfa, 0x7c, 0x51, 0x2d, 0xd8, 0x38, 0xb, 0xb0, 0x34, 0x9d, 0x4c, 0x62, 0xa, 0x9e, 0x28, 0xc3, 0x31, 0x23, 0xd9, 0xbb, 0x89, 0x9f, 0xa0, 0x89, 0x1f, 0xe8}}
+	return a, nil
+}
+
+// Asset loads and returns the asset for the given name.
+// It returns an error if the asset could not be found or
+// could not be loaded.
+func Asset(name string) ([]byte, error) {
+	canonicalName := strings.Replace(name, "\\", "/", -1)
+	if f, ok := _bindata[canonicalName]; ok {
+		a, err := f()
+		if err != nil {
+			return nil, fmt.Errorf("Asset %s can't read by error: %v", name, err)
+		}
+		return a.bytes, nil
+	}
+	return nil, fmt.Errorf("Asset %s not found", name)
+}
+
+// AssetString returns the asset contents as a string (instead of a []byte).
+func AssetString(name string) (string, error) {
+	data, err := Asset(name)
+	return string(data), err
+}
+
+// MustAsset is like Asset but panics when Asset would return an error.
+// It simplifies safe initialization of global variables.
+func MustAsset(name string) []byte {
+	a, err := Asset(name)
+	if err != nil {
+		panic("asset: Asset(" + name + "): " + err.Error())
+	}
+
+	return a
+}
+
+// MustAssetString is like AssetString but panics when Asset would return an
+// error. It simplifies safe initialization of global variables.
+func MustAssetString(name string) string {
+	return string(MustAsset(name))
+}
+
+// AssetInfo loads and returns the asset info for the given name.
+// It returns an error if the asset could not be found or
+// could not be loaded.
+func AssetInfo(name string) (os.FileInfo, error) {
+	canonicalName := strings.Replace(name, "\\", "/", -1)
+	if f, ok := _bindata[canonicalName]; ok {
+		a, err := f()
+		if err != nil {
+			return nil, fmt.Errorf("AssetInfo %s can't read by error: %v", name, err)
+		}
+		return a.info, nil
+	}
+	return nil, fmt.Errorf("AssetInfo %s not found", name)
+}
+
+// AssetDigest returns the digest of the file with the given name. It returns an
+// error if the asset could not be found or the digest could not be loaded.
+func AssetDigest(name string) ([sha256.Size]byte, error) {
+	canonicalName := strings.Replace(name, "\\", "/", -1)
+	if f, ok := _bindata[canonicalName]; ok {
+		a, err := f()
+		if err != nil {
+			return [sha256.Size]byte{}, fmt.Errorf("AssetDigest %s can't read by error: %v", name, err)
+		}
+		return a.digest, nil
+	}
+	return [sha256.Size]byte{}, fmt.Errorf("AssetDigest %s not found", name)
+}
+
+// Digests returns a map of all known files and their checksums.
+func Digests() (map[string][sha256.Size]byte, error) {
+	mp := make(map[string][sha256.Size]byte, len(_bindata))
+	for name := range _bindata {
+		a, err := _bindata[name]()
+		if err != nil {
+			return nil, err
+		}
+		mp[name] = a.digest
+	}
+	return mp, nil
+}
+
+// AssetNames returns the names of the assets.
+func AssetNames() []string {
+	names := make([]string, 0, len(_bindata))
+	for name := range _bindata {
+		names = append(names, name)
+	}
+	return names
+}
+
+// _bindata is a table, holding each asset generator, mapped to its name.
+var _bindata = map[string]func() (*asset, error){
+	"1_messages.down.sql":               _1_messagesDownSql,
+	"1_messages.up.sql":                 _1_messagesUpSql,
+	"2_raw_message.down.sql":            _2_raw_messageDownSql,
+	"2_raw_message.up.sql":              _2_raw_messageUpSql,
+	"3_message_hash.down.sql":           _3_message_hashDownSql,
+	"3_message_hash.up.sql":             _3_message_hashUpSql,
+	"4_canonical_message_hash.down.sql": _4_canonical_message_hashDownSql,
+	"4_canonical_message_hash.up.sql":   _4_canonical_message_hashUpSql,
+	"5_store_metadata.down.sql":         _5_store_metadataDownSql,
+	"5_store_metadata.up.sql":           _5_store_metadataUpSql,
+	"6_query_indexes.down.sql":          _6_query_indexesDownSql,
+	"6_query_indexes.up.sql":            _6_query_indexesUpSql,
+	"7_tombstones.down.sql":             _7_tombstonesDownSql,
+	"7_tombstones.up.sql":               _7_tombstonesUpSql,
+	"doc.go":                            docGo,
+}
+
+// AssetDebug is true if the assets were built with the debug flag enabled.
+const AssetDebug = false
+
+// AssetDir returns the file names below a certain
+// directory embedded in the file by go-bindata.
+// For example if you run go-bindata on data/... and data contains the
+// following hierarchy:
+//     data/
+//       foo.txt
+//       img/
+//         a.png
+//         b.png
+// then AssetDir("data") would return []string{"foo.txt", "img"},
+// AssetDir("data/img") would return []string{"a.png", "b.png"},
+// AssetDir("foo.txt") and AssetDir("notexist") would return an error, and
+// AssetDir("") will return []string{"data"}.
+func AssetDir(name string) ([]string, error) {
+	node := _bintree
+	if len(name) != 0 {
+		canonicalName := strings.Replace(name, "\\", "/", -1)
+		pathList := strings.Split(canonicalName, "/")
+		for _, p := range pathList {
+			node = node.Children[p]
+			if node == nil {
+				return nil, fmt.Errorf("Asset %s not found", name)
+			}
+		}
+	}
+	if node.Func != nil {
+		return nil, fmt.Errorf("Asset %s not found", name)
+	}
+	rv := make([]string, 0, len(node.Children))
+	for childName := range node.Children {
+		rv = append(rv, childName)
+	}
+	return rv, nil
+}
+
+type bintree struct {
+	Func     func() (*asset, error)
+	Children map[string]*bintree
+}
+
+var _bintree = &bintree{nil, map[string]*bintree{
+	"1_messages.down.sql": {_1_messagesDownSql, map[string]*bintree{}},
+	"1_messages.up.sql": {_1_messagesUpSql, map[string]*bintree{}},
+	"2_raw_message.down.sql": {_2_raw_messageDownSql, map[string]*bintree{}},
+	"2_raw_message.up.sql": {_2_raw_messageUpSql, map[string]*bintree{}},
+	"3_message_hash.down.sql": {_3_message_hashDownSql, map[string]*bintree{}},
+	"3_message_hash.up.sql": {_3_message_hashUpSql, map[string]*bintree{}},
+	"4_canonical_message_hash.down.sql": {_4_canonical_message_hashDownSql, map[string]*bintree{}},
+	"4_canonical_message_hash.up.sql": {_4_canonical_message_hashUpSql, map[string]*bintree{}},
+	"5_store_metadata.down.sql": {_5_store_metadataDownSql, map[string]*bintree{}},
+	"5_store_metadata.up.sql": {_5_store_metadataUpSql, map[string]*bintree{}},
+	"6_query_indexes.down.sql": {_6_query_indexesDownSql, map[string]*bintree{}},
+	"6_query_indexes.up.sql": {_6_query_indexesUpSql, map[string]*bintree{}},
+	"7_tombstones.down.sql": {_7_tombstonesDownSql, map[string]*bintree{}},
+	"7_tombstones.up.sql": {_7_tombstonesUpSql, map[string]*bintree{}},
+	"doc.go": {docGo, map[string]*bintree{}},
+}}
+
+// RestoreAsset restores an asset under the given directory.
+func RestoreAsset(dir, name string) error {
+	data, err := Asset(name)
+	if err != nil {
+		return err
+	}
+	info, err := AssetInfo(name)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(_filePath(dir, filepath.Dir(name)), os.FileMode(0755))
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(_filePath(dir, name), data, info.Mode())
+	if err != nil {
+		return err
+	}
+	return os.Chtimes(_filePath(dir, name), info.ModTime(), info.ModTime())
+}
+
+// RestoreAssets restores an asset under the given directory recursively.
+func RestoreAssets(dir, name string) error {
+	children, err := AssetDir(name)
+	// File
+	if err != nil {
+		return RestoreAsset(dir, name)
+	}
+	// Dir
+	for _, child := range children {
+		err = RestoreAssets(dir, filepath.Join(name, child))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func _filePath(dir, name string) string {
+	canonicalName := strings.Replace(name, "\\", "/", -1)
+	return filepath.Join(append([]string{dir}, strings.Split(canonicalName, "/")...)...)
+}
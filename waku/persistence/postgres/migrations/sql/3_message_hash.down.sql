@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS message_messageHash;
+
+ALTER TABLE message DROP COLUMN IF EXISTS messageHash;
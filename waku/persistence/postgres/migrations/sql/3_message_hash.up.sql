@@ -0,0 +1,3 @@
+ALTER TABLE message ADD COLUMN IF NOT EXISTS messageHash BYTEA;
+
+CREATE INDEX IF NOT EXISTS message_messageHash ON message(messageHash);
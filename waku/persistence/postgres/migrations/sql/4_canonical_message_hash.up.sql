@@ -0,0 +1,7 @@
+-- The canonical message hash covers the pubsub topic and every field of a message.
+-- The hashes, and the ids that contain them, are recalculated by the DBStore
+DROP INDEX IF EXISTS message_messageHash;
+
+UPDATE message SET messageHash = NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS message_messageHash ON message(messageHash);
@@ -0,0 +1,4 @@
+-- Indexes used by the history queries that filter the messages by pubsub topic or
+-- content topic, and sort them by sender timestamp
+CREATE INDEX IF NOT EXISTS message_pubsubTopic_senderTimestamp ON message(pubsubTopic, senderTimestamp, id);
+CREATE INDEX IF NOT EXISTS message_contentTopic_senderTimestamp ON message(contentTopic, senderTimestamp, id);
@@ -0,0 +1,5 @@
+-- Hashes of the messages deleted by an operator, which are not stored again
+CREATE TABLE IF NOT EXISTS tombstone (
+	messageHash BYTEA PRIMARY KEY,
+	deletedAt BIGINT NOT NULL
+);
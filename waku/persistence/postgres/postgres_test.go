@@ -0,0 +1,12 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebind(t *testing.T) {
+	require.Equal(t, "SELECT 1 FROM message WHERE id = $1 AND pubsubTopic = $2 LIMIT $3", Dialect{}.Rebind("SELECT 1 FROM message WHERE id = ? AND pubsubTopic = ? LIMIT ?"))
+	require.Equal(t, "SELECT max(senderTimestamp) FROM message", Dialect{}.Rebind("SELECT max(senderTimestamp) FROM message"))
+}
@@ -0,0 +1,29 @@
+package persistence_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/status-im/go-waku/waku/persistence"
+	"github.com/status-im/go-waku/waku/persistence/postgres"
+	"github.com/stretchr/testify/require"
+)
+
+// The DBStore tests are executed against PostgreSQL when TEST_POSTGRES_URL contains
+// the URL of a DB that can be wiped. The postgres package imports persistence, so it
+// is registered from this external test package
+func init() {
+	dbURL := os.Getenv("TEST_POSTGRES_URL")
+	if dbURL == "" {
+		return
+	}
+
+	persistence.RegisterTestBackend("postgres", postgres.Dialect{}, func(t *testing.T) *sql.DB {
+		db, err := postgres.NewDB(dbURL)
+		require.NoError(t, err)
+		_, err = db.Exec("DROP TABLE IF EXISTS message, store_metadata, tombstone, gowaku_schema_migrations")
+		require.NoError(t, err)
+		return db
+	})
+}
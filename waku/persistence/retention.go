@@ -0,0 +1,224 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/status-im/go-waku/waku/v2/metrics"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidRetentionRule is returned when a retention rule can't be parsed
+var ErrInvalidRetentionRule = errors.New("invalid retention rule")
+
+// RetentionRule limits the messages that match a pubsub topic and a content topic
+// prefix. Empty values match every message. A message is deleted as soon as any of
+// the rules that match it is exceeded, starting from the messages received first
+type RetentionRule struct {
+	// Name identifies the rule in logs and metrics
+	Name               string
+	PubsubTopic        string
+	ContentTopicPrefix string
+	// MaxMessages is the maximum number of messages kept (0 for unlimited)
+	MaxMessages int
+	// MaxDuration is how long messages are kept since they were received (0 for unlimited)
+	MaxDuration time.Duration
+	// MaxBytes is the maximum size of the messages kept (0 for unlimited)
+	MaxBytes int
+}
+
+// WithRetentionRules is a DBOption that adds retention rules to the ones specified
+// with WithRetentionPolicy and WithRetentionMaxBytes
+func WithRetentionRules(rules ...RetentionRule) DBOption {
+	return func(d *DBStore) error {
+		for _, r := range rules {
+			if r.Name == "" {
+				return fmt.Errorf("%w: a name is required", ErrInvalidRetentionRule)
+			}
+		}
+		d.retentionRules = append(d.retentionRules, rules...)
+		return nil
+	}
+}
+
+// WithRetentionMaxBytes is a DBOption that limits the total size of the stored messages
+func WithRetentionMaxBytes(maxBytes int) DBOption {
+	return func(d *DBStore) error {
+		d.maxBytes = maxBytes
+		return nil
+	}
+}
+
+// ParseRetentionRule parses a retention rule with the format
+// name=telemetry;pubsub=/waku/2/default-waku/proto;prefix=/telemetry/;duration=1h;messages=1000;bytes=1048576
+// Every attribute is optional, and the name defaults to the pubsub topic and the prefix
+func ParseRetentionRule(s string) (RetentionRule, error) {
+	var rule RetentionRule
+	for _, attr := range strings.Split(s, ";") {
+		attr = strings.TrimSpace(attr)
+		if attr == "" {
+			continue
+		}
+
+		kv := strings.SplitN(attr, "=", 2)
+		if len(kv) != 2 {
+			return RetentionRule{}, fmt.Errorf("%w: %s", ErrInvalidRetentionRule, attr)
+		}
+
+		var err error
+		switch kv[0] {
+		case "name":
+			rule.Name = kv[1]
+		case "pubsub":
+			rule.PubsubTopic = kv[1]
+		case "prefix":
+			rule.ContentTopicPrefix = kv[1]
+		case "duration":
+			rule.MaxDuration, err = time.ParseDuration(kv[1])
+		case "messages":
+			rule.MaxMessages, err = strconv.Atoi(kv[1])
+		case "bytes":
+			rule.MaxBytes, err = strconv.Atoi(kv[1])
+		default:
+			return RetentionRule{}, fmt.Errorf("%w: unknown attribute %s", ErrInvalidRetentionRule, kv[0])
+		}
+
+		if err != nil {
+			return RetentionRule{}, fmt.Errorf("%w: %s: %v", ErrInvalidRetentionRule, attr, err)
+		}
+	}
+
+	if rule.MaxDuration < 0 || rule.MaxMessages < 0 || rule.MaxBytes < 0 {
+		return RetentionRule{}, fmt.Errorf("%w: limits can't be negative", ErrInvalidRetentionRule)
+	}
+
+	if rule.Name == "" {
+		rule.Name = rule.PubsubTopic + rule.ContentTopicPrefix
+		if rule.Name == "" {
+			rule.Name = "all"
+		}
+	}
+
+	return rule, nil
+}
+
+// ruleConditions returns the SQL conditions and parameters that select the messages a rule applies to
+func (d *DBStore) ruleConditions(r RetentionRule) ([]string, []interface{}) {
+	var conditions []string
+	var parameters []interface{}
+
+	if r.PubsubTopic != "" {
+		conditions = append(conditions, "pubsubTopic = ?")
+		parameters = append(parameters, d.pubsubTopicColumn(r.PubsubTopic))
+	}
+
+	if r.ContentTopicPrefix != "" {
+		conditions = append(conditions, "substr(contentTopic, 1, ?) = ?")
+		parameters = append(parameters, utf8.RuneCountInString(r.ContentTopicPrefix), r.ContentTopicPrefix)
+	}
+
+	return conditions, parameters
+}
+
+// rules returns every retention rule enforced by the DBStore. The global retention
+// policy is the first one
+func (d *DBStore) rules() []RetentionRule {
+	return append([]RetentionRule{{
+		Name:        "global",
+		MaxMessages: d.maxMessages,
+		MaxDuration: d.maxDuration,
+		MaxBytes:    d.maxBytes,
+	}}, d.retentionRules...)
+}
+
+// enforceRule deletes the messages exceeding the limits of a retention rule, and
+// returns the number of deleted messages
+func (d *DBStore) enforceRule(rule RetentionRule) (int64, error) {
+	var deleted int64
+
+	exec := func(sqlStmt string, parameters ...interface{}) error {
+		result, err := d.db.Exec(d.dialect.Rebind(sqlStmt), parameters...)
+		if err != nil {
+			return err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		deleted += rows
+		return nil
+	}
+
+	conditions, parameters := d.ruleConditions(rule)
+	where := func(extra ...string) string {
+		all := append(append([]string{}, conditions...), extra...)
+		if len(all) == 0 {
+			return ""
+		}
+		return "WHERE " + strings.Join(all, " AND ")
+	}
+
+	// Delete older messages
+	if rule.MaxDuration > 0 {
+		sqlStmt := fmt.Sprintf(`DELETE FROM message %s`, where("receiverTimestamp < ?"))
+		err := exec(sqlStmt, append(parameters, utils.GetUnixEpochFrom(time.Now().Add(-rule.MaxDuration)))...)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Limit number of records to a max N
+	if rule.MaxMessages > 0 {
+		sqlStmt := fmt.Sprintf(`DELETE FROM message WHERE id IN (SELECT id FROM message %s ORDER BY receiverTimestamp DESC LIMIT %s OFFSET ?)`, where(), d.dialect.NoLimit())
+		err := exec(sqlStmt, append(parameters, rule.MaxMessages)...)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Limit the size of the messages, keeping the most recent ones
+	if rule.MaxBytes > 0 {
+		sqlStmt := fmt.Sprintf(`DELETE FROM message WHERE id IN (
+			SELECT id FROM (
+				SELECT id, SUM(COALESCE(length(rawMessage), length(payload), 0)) OVER (ORDER BY receiverTimestamp DESC, id) AS totalBytes
+				FROM message
+				%s
+			) AS sizes WHERE totalBytes > ?)`, where())
+		err := exec(sqlStmt, append(parameters, rule.MaxBytes)...)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return deleted, nil
+}
+
+func (d *DBStore) cleanOlderRecords() error {
+	d.log.Debug("Cleaning older records...")
+
+	for _, rule := range d.rules() {
+		if rule.MaxDuration <= 0 && rule.MaxMessages <= 0 && rule.MaxBytes <= 0 {
+			continue
+		}
+
+		start := time.Now()
+		deleted, err := d.enforceRule(rule)
+		if err != nil {
+			return err
+		}
+
+		if deleted > 0 {
+			metrics.RecordStoreRetentionDeletions(context.Background(), rule.Name, deleted)
+		}
+
+		d.log.Debug("deleting records from the DB", zap.String("rule", rule.Name), zap.Int64("count", deleted), zap.Duration("duration", time.Since(start)))
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package persistence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetentionRule(t *testing.T) {
+	rule, err := ParseRetentionRule("pubsub=/waku/2/default-waku/proto; prefix=/telemetry/;duration=1h;messages=1000;bytes=1048576")
+	require.NoError(t, err)
+	require.Equal(t, RetentionRule{
+		Name:               "/waku/2/default-waku/proto/telemetry/",
+		PubsubTopic:        "/waku/2/default-waku/proto",
+		ContentTopicPrefix: "/telemetry/",
+		MaxDuration:        time.Hour,
+		MaxMessages:        1000,
+		MaxBytes:           1048576,
+	}, rule)
+
+	rule, err = ParseRetentionRule("name=chat;duration=720h")
+	require.NoError(t, err)
+	require.Equal(t, "chat", rule.Name)
+	require.Equal(t, 720*time.Hour, rule.MaxDuration)
+
+	for _, invalid := range []string{"duration", "duration=1 day", "messages=-1", "topic=/waku"} {
+		_, err = ParseRetentionRule(invalid)
+		require.ErrorIs(t, err, ErrInvalidRetentionRule)
+	}
+}
+
+func TestStoreRetentionRules(t *testing.T) {
+	for _, b := range backends() {
+		t.Run(b.name, func(t *testing.T) {
+			db := b.newDB(t)
+			store, err := NewDBStore(utils.Logger(), WithDB(db), WithDialect(b.dialect))
+			require.NoError(t, err)
+
+			insertTime := time.Now()
+			put := func(contentTopic string, pubsubTopic string, age time.Duration) {
+				ts := insertTime.Add(-age).UnixNano()
+				require.NoError(t, store.Put(protocol.NewEnvelope(tests.CreateWakuMessage(contentTopic, ts), ts, pubsubTopic)))
+			}
+
+			put("/telemetry/1", "a", 3*time.Hour)
+			put("/telemetry/2", "a", 30*time.Minute)
+			put("/chat/1", "a", 3*time.Hour)
+			put("/chat/2", "b", 4*time.Minute)
+			put("/chat/3", "b", 3*time.Minute)
+			put("/chat/4", "b", 2*time.Minute)
+			put("/chat/5", "b", 1*time.Minute)
+
+			// This step simulates starting go-waku again with retention rules
+			store, err = NewDBStore(utils.Logger(), WithDB(db), WithDialect(b.dialect),
+				WithRetentionRules(
+					RetentionRule{Name: "telemetry", ContentTopicPrefix: "/telemetry/", MaxDuration: time.Hour},
+					RetentionRule{Name: "b", PubsubTopic: "b", MaxMessages: 3},
+				))
+			require.NoError(t, err)
+
+			var contentTopics []string
+			res, err := store.GetAll()
+			require.NoError(t, err)
+			for _, r := range res {
+				contentTopics = append(contentTopics, r.Message.ContentTopic)
+			}
+			require.ElementsMatch(t, []string{"/telemetry/2", "/chat/1", "/chat/3", "/chat/4", "/chat/5"}, contentTopics)
+
+			// The size of the messages is limited, keeping the most recent ones
+			raw, err := res[len(res)-1].Message.Marshal()
+			require.NoError(t, err)
+
+			store, err = NewDBStore(utils.Logger(), WithDB(db), WithDialect(b.dialect),
+				WithRetentionMaxBytes(2*len(raw)))
+			require.NoError(t, err)
+
+			res, err = store.GetAll()
+			require.NoError(t, err)
+			require.Len(t, res, 2)
+			require.Equal(t, "/chat/4", res[0].Message.ContentTopic)
+			require.Equal(t, "/chat/5", res[1].Message.ContentTopic)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package persistence
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+)
+
+// statsQuery returns a copy of a history query without its paging info, which is
+// ignored when calculating statistics
+func statsQuery(query *pb.HistoryQuery) *pb.HistoryQuery {
+	return &pb.HistoryQuery{
+		PubsubTopic:    query.PubsubTopic,
+		ContentFilters: query.ContentFilters,
+		StartTime:      query.StartTime,
+		EndTime:        query.EndTime,
+	}
+}
+
+// newHistoryStats calculates the totals of the statistics of each content topic,
+// which are sorted by content topic
+func newHistoryStats(contentTopics []*pb.ContentTopicStats) *pb.HistoryStats {
+	sort.Slice(contentTopics, func(i, j int) bool { return contentTopics[i].ContentTopic < contentTopics[j].ContentTopic })
+
+	result := &pb.HistoryStats{ContentTopics: contentTopics}
+	for i, s := range contentTopics {
+		result.Count += s.Count
+		if i == 0 || s.OldestTimestamp < result.OldestTimestamp {
+			result.OldestTimestamp = s.OldestTimestamp
+		}
+		if i == 0 || s.NewestTimestamp > result.NewestTimestamp {
+			result.NewestTimestamp = s.NewestTimestamp
+		}
+	}
+
+	return result
+}
+
+// statsFromMessages calculates the statistics of the messages returned by an iteration
+func statsFromMessages(iterate func(fn IterateFunc) error) (*pb.HistoryStats, error) {
+	byContentTopic := make(map[string]*pb.ContentTopicStats)
+	var contentTopics []*pb.ContentTopicStats
+	err := iterate(func(msg StoredMessage) error {
+		s, ok := byContentTopic[msg.Message.ContentTopic]
+		if !ok {
+			s = &pb.ContentTopicStats{
+				ContentTopic:    msg.Message.ContentTopic,
+				OldestTimestamp: msg.Message.Timestamp,
+				NewestTimestamp: msg.Message.Timestamp,
+			}
+			byContentTopic[msg.Message.ContentTopic] = s
+			contentTopics = append(contentTopics, s)
+		}
+
+		s.Count++
+		if msg.Message.Timestamp < s.OldestTimestamp {
+			s.OldestTimestamp = msg.Message.Timestamp
+		}
+		if msg.Message.Timestamp > s.NewestTimestamp {
+			s.NewestTimestamp = msg.Message.Timestamp
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newHistoryStats(contentTopics), nil
+}
+
+// Stats returns the number of messages that match the filters of a history query,
+// and the range of their sender timestamps, per content topic. The paging info of
+// the query is ignored
+func (d *DBStore) Stats(query *pb.HistoryQuery) (*pb.HistoryStats, error) {
+	conditions, parameters := d.queryConditions(query)
+
+	conditionStr := ""
+	if len(conditions) != 0 {
+		conditionStr = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	sqlQuery := fmt.Sprintf("SELECT contentTopic, COUNT(*), MIN(senderTimestamp), MAX(senderTimestamp) FROM message %s GROUP BY contentTopic", conditionStr)
+	rows, err := d.readDB.Query(d.dialect.Rebind(sqlQuery), parameters...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contentTopics []*pb.ContentTopicStats
+	for rows.Next() {
+		s := new(pb.ContentTopicStats)
+		err := rows.Scan(&s.ContentTopic, &s.Count, &s.OldestTimestamp, &s.NewestTimestamp)
+		if err != nil {
+			return nil, err
+		}
+		contentTopics = append(contentTopics, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if d.cipher != nil {
+		// The contentTopic column contains a keyed hash, the content topic is obtained
+		// by decrypting one of the messages of each group
+		for _, s := range contentTopics {
+			var rawMessage []byte
+			err := d.readDB.QueryRow(d.dialect.Rebind("SELECT rawMessage FROM message WHERE contentTopic = ? LIMIT 1"), s.ContentTopic).Scan(&rawMessage)
+			if err != nil {
+				return nil, err
+			}
+
+			msg, _, err := d.decryptMessage(rawMessage)
+			if err != nil {
+				return nil, err
+			}
+			s.ContentTopic = msg.ContentTopic
+		}
+	}
+
+	return newHistoryStats(contentTopics), nil
+}
+
+// Stats returns the statistics of the messages that match the filters of a history
+// query, calculated from every matching message
+func (m *MemoryStore) Stats(query *pb.HistoryQuery) (*pb.HistoryStats, error) {
+	return statsFromMessages(func(fn IterateFunc) error {
+		return m.Iterate(statsQuery(query), fn)
+	})
+}
+
+// Stats returns the statistics of the messages that match the filters of a history
+// query. Every matching message is read, so it's slower than DBStore.Stats
+func (l *LevelDBStore) Stats(query *pb.HistoryQuery) (*pb.HistoryStats, error) {
+	return statsFromMessages(func(fn IterateFunc) error {
+		return l.Iterate(statsQuery(query), fn)
+	})
+}
@@ -0,0 +1,79 @@
+package persistence
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+
+	dbStore, err := NewDBStore(utils.Logger(), WithDB(db))
+	require.NoError(t, err)
+	defer dbStore.Stop()
+
+	levelDBStore, err := NewLevelDBStore(utils.Logger(), WithLevelDB(NewMemLevelDB(t)))
+	require.NoError(t, err)
+	defer levelDBStore.Stop()
+
+	providers := map[string]interface {
+		Put(env *protocol.Envelope) error
+		Stats(query *pb.HistoryQuery) (*pb.HistoryStats, error)
+	}{
+		"db":      dbStore,
+		"memory":  NewMemoryStore(utils.Logger()),
+		"leveldb": levelDBStore,
+	}
+
+	for name, p := range providers {
+		t.Run(name, func(t *testing.T) {
+			put := func(contentTopic string, pubsubTopic string, ts int64) {
+				require.NoError(t, p.Put(protocol.NewEnvelope(tests.CreateWakuMessage(contentTopic, ts), utils.GetUnixEpoch(), pubsubTopic)))
+			}
+			put("b", "test", 10)
+			put("a", "test", 20)
+			put("b", "test", 30)
+			put("a", "other", 40)
+
+			stats, err := p.Stats(&pb.HistoryQuery{})
+			require.NoError(t, err)
+			require.Equal(t, &pb.HistoryStats{
+				Count:           4,
+				OldestTimestamp: 10,
+				NewestTimestamp: 40,
+				ContentTopics: []*pb.ContentTopicStats{
+					{ContentTopic: "a", Count: 2, OldestTimestamp: 20, NewestTimestamp: 40},
+					{ContentTopic: "b", Count: 2, OldestTimestamp: 10, NewestTimestamp: 30},
+				},
+			}, stats)
+
+			// The paging info is ignored
+			stats, err = p.Stats(&pb.HistoryQuery{
+				PubsubTopic: "test",
+				StartTime:   15,
+				PagingInfo:  &pb.PagingInfo{PageSize: 1, Cursor: &pb.Index{Digest: []byte{1}}},
+			})
+			require.NoError(t, err)
+			require.Equal(t, &pb.HistoryStats{
+				Count:           2,
+				OldestTimestamp: 20,
+				NewestTimestamp: 30,
+				ContentTopics: []*pb.ContentTopicStats{
+					{ContentTopic: "a", Count: 1, OldestTimestamp: 20, NewestTimestamp: 20},
+					{ContentTopic: "b", Count: 1, OldestTimestamp: 30, NewestTimestamp: 30},
+				},
+			}, stats)
+
+			stats, err = p.Stats(&pb.HistoryQuery{ContentFilters: []*pb.ContentFilter{{ContentTopic: "c"}}})
+			require.NoError(t, err)
+			require.Equal(t, &pb.HistoryStats{}, stats)
+		})
+	}
+}
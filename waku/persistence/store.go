@@ -1,6 +1,7 @@
 package persistence
 
 import (
+	"bytes"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -8,18 +9,23 @@ import (
 	"sync"
 	"time"
 
-	"github.com/status-im/go-waku/waku/persistence/migrations"
 	"github.com/status-im/go-waku/waku/v2/protocol"
 	"github.com/status-im/go-waku/waku/v2/protocol/pb"
-	"github.com/status-im/go-waku/waku/v2/utils"
 	"go.uber.org/zap"
 )
 
 type MessageProvider interface {
 	GetAll() ([]StoredMessage, error)
 	Put(env *protocol.Envelope) error
+	PutBatch(envs []*protocol.Envelope) []error
 	Query(query *pb.HistoryQuery) ([]StoredMessage, error)
+	GetByHashes(hashes [][]byte) ([]StoredMessage, error)
+	Iterate(query *pb.HistoryQuery, fn IterateFunc) error
 	MostRecentTimestamp() (int64, error)
+	DeleteByHashes(hashes [][]byte, tombstone bool) (int64, error)
+	DeleteByContentTopic(contentTopic string, tombstone bool) (int64, error)
+	DeleteByTimeRange(startTime int64, endTime int64, tombstone bool) (int64, error)
+	Tombstoned(hashes [][]byte) ([]bool, error)
 	Stop()
 }
 
@@ -31,11 +37,25 @@ const WALMode = "wal"
 // DBStore is a MessageProvider that has a *sql.DB connection
 type DBStore struct {
 	MessageProvider
-	db  *sql.DB
-	log *zap.Logger
+	db      *sql.DB
+	dialect Dialect
+	log     *zap.Logger
 
-	maxMessages int
-	maxDuration time.Duration
+	maxMessages    int
+	maxDuration    time.Duration
+	maxBytes       int
+	retentionRules []RetentionRule
+
+	cipher *messageCipher
+
+	// readDB is used by the queries that only read messages, so they don't wait for writes
+	readDB *sql.DB
+
+	// insertStmt is prepared once and reused by every write
+	insertStmt *sql.Stmt
+
+	// hasTombstones is 1 if messages were deleted with a tombstone, and is accessed atomically
+	hasTombstones int32
 
 	wg   sync.WaitGroup
 	quit chan struct{}
@@ -71,6 +91,15 @@ func WithDriver(driverName string, datasourceName string) DBOption {
 	}
 }
 
+// WithDialect is a DBOption that specifies the Dialect of the database used by
+// a DBStore. SQLiteDialect is used if this option is not specified
+func WithDialect(dialect Dialect) DBOption {
+	return func(d *DBStore) error {
+		d.dialect = dialect
+		return nil
+	}
+}
+
 func WithRetentionPolicy(maxMessages int, maxDuration time.Duration) DBOption {
 	return func(d *DBStore) error {
 		d.maxDuration = maxDuration
@@ -86,6 +115,7 @@ func NewDBStore(log *zap.Logger, options ...DBOption) (*DBStore, error) {
 	result := new(DBStore)
 	result.log = log.Named("dbstore")
 	result.quit = make(chan struct{})
+	result.dialect = SQLiteDialect{}
 
 	for _, opt := range options {
 		err := opt(result)
@@ -94,73 +124,58 @@ func NewDBStore(log *zap.Logger, options ...DBOption) (*DBStore, error) {
 		}
 	}
 
-	// Disable concurrent access as not supported by the driver
-	result.db.SetMaxOpenConns(1)
+	if result.cipher != nil {
+		for _, r := range result.retentionRules {
+			if r.ContentTopicPrefix != "" {
+				return nil, fmt.Errorf("%w: content topic prefixes can't be used when the messages are encrypted", ErrInvalidRetentionRule)
+			}
+		}
+	}
 
-	var seq string
-	var name string
-	var file string // file will be empty if DB is :memory"
-	err := result.db.QueryRow("PRAGMA database_list").Scan(&seq, &name, &file)
+	err := result.dialect.Setup(result.db)
 	if err != nil {
 		return nil, err
 	}
 
-	// readers do not block writers and faster i/o operations
-	// https://www.sqlite.org/draft/wal.html
-	// must be set after db is encrypted
-	var mode string
-	err = result.db.QueryRow("PRAGMA journal_mode=WAL").Scan(&mode)
+	err = result.dialect.Migrate(result.db)
 	if err != nil {
 		return nil, err
 	}
-	if mode != WALMode && file != "" {
-		return nil, fmt.Errorf("unable to set journal_mode to WAL. actual mode %s", mode)
-	}
 
-	err = migrations.Migrate(result.db)
+	err = result.setupEncryption()
 	if err != nil {
 		return nil, err
 	}
 
-	err = result.cleanOlderRecords()
+	err = result.loadTombstones()
 	if err != nil {
 		return nil, err
 	}
 
-	result.wg.Add(1)
-	go result.checkForOlderRecords(10 * time.Second) // is 10s okay?
-
-	return result, nil
-}
+	err = result.backfillMessageHashes()
+	if err != nil {
+		return nil, err
+	}
 
-func (d *DBStore) cleanOlderRecords() error {
-	d.log.Debug("Cleaning older records...")
+	err = result.cleanOlderRecords()
+	if err != nil {
+		return nil, err
+	}
 
-	// Delete older messages
-	if d.maxDuration > 0 {
-		start := time.Now()
-		sqlStmt := `DELETE FROM message WHERE receiverTimestamp < ?`
-		_, err := d.db.Exec(sqlStmt, utils.GetUnixEpochFrom(time.Now().Add(-d.maxDuration)))
-		if err != nil {
-			return err
-		}
-		elapsed := time.Since(start)
-		d.log.Debug("deleting older records from the DB", zap.Duration("duration", elapsed))
+	result.readDB, err = result.dialect.ReadDB(result.db)
+	if err != nil {
+		return nil, err
 	}
 
-	// Limit number of records to a max N
-	if d.maxMessages > 0 {
-		start := time.Now()
-		sqlStmt := `DELETE FROM message WHERE id IN (SELECT id FROM message ORDER BY receiverTimestamp DESC LIMIT -1 OFFSET ?)`
-		_, err := d.db.Exec(sqlStmt, d.maxMessages)
-		if err != nil {
-			return err
-		}
-		elapsed := time.Since(start)
-		d.log.Debug("deleting excess records from the DB", zap.Duration("duration", elapsed))
+	result.insertStmt, err = result.db.Prepare(result.dialect.Rebind("INSERT INTO message (id, receiverTimestamp, senderTimestamp, contentTopic, pubsubTopic, payload, version, rawMessage, messageHash) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING"))
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	result.wg.Add(1)
+	go result.checkForOlderRecords(10 * time.Second) // is 10s okay?
+
+	return result, nil
 }
 
 func (d *DBStore) checkForOlderRecords(t time.Duration) {
@@ -186,27 +201,108 @@ func (d *DBStore) checkForOlderRecords(t time.Duration) {
 func (d *DBStore) Stop() {
 	d.quit <- struct{}{}
 	d.wg.Wait()
+	d.insertStmt.Close()
+	if d.readDB != d.db {
+		d.readDB.Close()
+	}
 	d.db.Close()
 }
 
-// Inserts a WakuMessage into the DB
+// Inserts a WakuMessage into the DB. ErrDuplicateMessage is returned if a message
+// with the same hash was already stored, and ErrDeletedMessage if it was deleted
+// with a tombstone
 func (d *DBStore) Put(env *protocol.Envelope) error {
-	stmt, err := d.db.Prepare("INSERT INTO message (id, receiverTimestamp, senderTimestamp, contentTopic, pubsubTopic, payload, version) VALUES (?, ?, ?, ?, ?, ?, ?)")
+	errs := make([]error, 1)
+	err := d.rejectDeleted([]*protocol.Envelope{env}, errs)
+	if err != nil {
+		return err
+	}
+	if errs[0] != nil {
+		return errs[0]
+	}
+
+	columns, err := d.encodeMessage(env.Message(), env.PubsubTopic(), env.Index().Digest)
+	if err != nil {
+		return err
+	}
+
+	return d.insert(d.insertStmt, env, columns)
+}
+
+// PutBatch inserts a list of WakuMessages into the DB in a single transaction, and
+// returns the error of each message, which is ErrDuplicateMessage if a message with
+// the same hash was already stored, or ErrDeletedMessage if it was deleted with a
+// tombstone. If the transaction fails, the messages are inserted one by one, so a
+// message does not prevent the others from being stored
+func (d *DBStore) PutBatch(envs []*protocol.Envelope) []error {
+	errs := make([]error, len(envs))
+	if err := d.rejectDeleted(envs, errs); err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	columns := make([]messageColumns, len(envs))
+	for i, env := range envs {
+		if errs[i] == nil {
+			columns[i], errs[i] = d.encodeMessage(env.Message(), env.PubsubTopic(), env.Index().Digest)
+		}
+	}
+
+	err := d.insertBatch(envs, columns, errs)
+	if err != nil {
+		d.log.Warn("storing messages in a single transaction", zap.Int("count", len(envs)), zap.Error(err))
+		for i, env := range envs {
+			if columns[i].id != nil {
+				errs[i] = d.insert(d.insertStmt, env, columns[i])
+			}
+		}
+	}
+
+	return errs
+}
+
+// insertBatch inserts in a single transaction the messages that were encoded without
+// errors, setting their errors. An error is returned if the transaction fails
+func (d *DBStore) insertBatch(envs []*protocol.Envelope, columns []messageColumns, errs []error) error {
+	tx, err := d.db.Begin()
 	if err != nil {
 		return err
 	}
 
-	cursor := env.Index()
-	dbKey := NewDBKey(uint64(cursor.SenderTime), env.PubsubTopic(), env.Index().Digest)
-	_, err = stmt.Exec(dbKey.Bytes(), cursor.ReceiverTime, env.Message().Timestamp, env.Message().ContentTopic, env.PubsubTopic(), env.Message().Payload, env.Message().Version)
+	stmt := tx.Stmt(d.insertStmt)
+	defer stmt.Close()
+
+	for i, env := range envs {
+		if errs[i] != nil {
+			continue
+		}
+
+		err := d.insert(stmt, env, columns[i])
+		if err != nil && err != ErrDuplicateMessage {
+			_ = tx.Rollback()
+			return err
+		}
+		errs[i] = err
+	}
+
+	return tx.Commit()
+}
+
+func (d *DBStore) insert(stmt *sql.Stmt, env *protocol.Envelope, columns messageColumns) error {
+	result, err := stmt.Exec(columns.id, env.Index().ReceiverTime, env.Message().Timestamp, columns.contentTopic, columns.pubsubTopic, columns.payload, env.Message().Version, columns.rawMessage, env.Hash())
 	if err != nil {
 		return err
 	}
 
-	err = stmt.Close()
+	rows, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
+	if rows == 0 {
+		return ErrDuplicateMessage
+	}
 
 	return nil
 }
@@ -218,18 +314,107 @@ func (d *DBStore) Query(query *pb.HistoryQuery) ([]StoredMessage, error) {
 		d.log.Info(fmt.Sprintf("Loading records from the DB took %s", elapsed))
 	}()
 
-	sqlQuery := `SELECT id, receiverTimestamp, senderTimestamp, contentTopic, pubsubTopic, payload, version 
+	cursor := query.PagingInfo.Cursor
+	if cursor == nil {
+		result, _, err := d.queryPage(query, nil)
+		return result, err
+	}
+
+	cursorID := d.dbKey(cursor.SenderTime, cursor.PubsubTopic, cursor.Digest).Bytes()
+	result, cursorFound, err := d.queryPage(query, cursorID)
+	if err != nil || cursorFound {
+		return result, err
+	}
+
+	// The page is empty, or the cursor was not found because its digest is a legacy one
+	resolvedID, err := d.cursorID(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.Equal(resolvedID, cursorID) {
+		return result, nil
+	}
+
+	result, _, err = d.queryPage(query, resolvedID)
+	return result, err
+}
+
+// queryPage returns the page of messages that match a history query, following the
+// message whose id is cursorID if it's not nil. Whether the message of the cursor
+// exists is checked by the same SQL query, and is only known if the page is not empty
+func (d *DBStore) queryPage(query *pb.HistoryQuery, cursorID []byte) ([]StoredMessage, bool, error) {
+	sqlQuery := `SELECT id, receiverTimestamp, senderTimestamp, contentTopic, pubsubTopic, payload, version, rawMessage, %s
 					 FROM message 
 					 %s
 					 ORDER BY senderTimestamp %s, pubsubTopic, id %s
 					 LIMIT ?`
 
+	var cursorParameters []interface{}
+	cursorFoundColumn := "TRUE"
+	if cursorID != nil {
+		cursorFoundColumn = "EXISTS(SELECT 1 FROM message WHERE id = ?)"
+		cursorParameters = append(cursorParameters, cursorID)
+	}
+
+	conditions, parameters := d.queryConditions(query)
+	parameters = append(cursorParameters, parameters...)
+
+	if cursorID != nil {
+		eqOp := ">"
+		if query.PagingInfo.Direction == pb.PagingInfo_BACKWARD {
+			eqOp = "<"
+		}
+		conditions = append(conditions, fmt.Sprintf("id %s ?", eqOp))
+
+		parameters = append(parameters, cursorID)
+	}
+
+	conditionStr := ""
+	if len(conditions) != 0 {
+		conditionStr = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	orderDirection := "ASC"
+	if query.PagingInfo.Direction == pb.PagingInfo_BACKWARD {
+		orderDirection = "DESC"
+	}
+
+	sqlQuery = fmt.Sprintf(sqlQuery, cursorFoundColumn, conditionStr, orderDirection, orderDirection)
+
+	parameters = append(parameters, query.PagingInfo.PageSize)
+	rows, err := d.readDB.Query(d.dialect.Rebind(sqlQuery), parameters...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var result []StoredMessage
+	var cursorFound bool
+	for rows.Next() {
+		record, err := d.scanStoredMessage(rows, &cursorFound)
+		if err != nil {
+			return nil, false, err
+		}
+		result = append(result, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	return result, cursorFound, nil
+}
+
+// queryConditions returns the SQL conditions and parameters that select the messages
+// matching the filters of a history query, excluding its cursor
+func (d *DBStore) queryConditions(query *pb.HistoryQuery) ([]string, []interface{}) {
 	var conditions []string
 	var parameters []interface{}
 
 	if query.PubsubTopic != "" {
 		conditions = append(conditions, "pubsubTopic = ?")
-		parameters = append(parameters, query.PubsubTopic)
+		parameters = append(parameters, d.pubsubTopicColumn(query.PubsubTopic))
 	}
 
 	if query.StartTime != 0 {
@@ -250,60 +435,143 @@ func (d *DBStore) Query(query *pb.HistoryQuery) ([]StoredMessage, error) {
 		for _, ct := range query.ContentFilters {
 			if ct.ContentTopic != "" {
 				ctPlaceHolder = append(ctPlaceHolder, "?")
-				parameters = append(parameters, ct.ContentTopic)
+				parameters = append(parameters, d.contentTopicColumn(ct.ContentTopic))
 			}
 		}
 		conditions = append(conditions, "contentTopic IN ("+strings.Join(ctPlaceHolder, ", ")+")")
 	}
 
-	if query.PagingInfo.Cursor != nil {
-		var exists bool
-		cursorDBKey := NewDBKey(uint64(query.PagingInfo.Cursor.SenderTime), query.PagingInfo.Cursor.PubsubTopic, query.PagingInfo.Cursor.Digest)
+	return conditions, parameters
+}
 
-		err := d.db.QueryRow("SELECT EXISTS(SELECT 1 FROM message WHERE id = ?)",
-			cursorDBKey.Bytes(),
-		).Scan(&exists)
+// cursorID returns the id of the message a cursor points to. Cursors issued before
+// the canonical message hash was used as digest are resolved by comparing their
+// digest with the legacy digest of the messages with the same sender time and pubsub topic
+func (d *DBStore) cursorID(cursor *pb.Index) ([]byte, error) {
+	var exists bool
+	cursorDBKey := d.dbKey(cursor.SenderTime, cursor.PubsubTopic, cursor.Digest)
+
+	err := d.readDB.QueryRow(d.dialect.Rebind("SELECT EXISTS(SELECT 1 FROM message WHERE id = ?)"),
+		cursorDBKey.Bytes(),
+	).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+
+	if exists {
+		return cursorDBKey.Bytes(), nil
+	}
+
+	rows, err := d.readDB.Query(d.dialect.Rebind("SELECT id, receiverTimestamp, senderTimestamp, contentTopic, pubsubTopic, payload, version, rawMessage FROM message WHERE senderTimestamp = ? AND pubsubTopic = ?"),
+		cursor.SenderTime, d.pubsubTopicColumn(cursor.PubsubTopic))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
+	for rows.Next() {
+		record, err := d.GetStoredMessage(rows)
 		if err != nil {
 			return nil, err
 		}
+		if bytes.Equal(legacyDigest(record.Message), cursor.Digest) {
+			return record.ID, nil
+		}
+	}
 
-		if exists {
-			eqOp := ">"
-			if query.PagingInfo.Direction == pb.PagingInfo_BACKWARD {
-				eqOp = "<"
-			}
-			conditions = append(conditions, fmt.Sprintf("id %s ?", eqOp))
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, ErrInvalidCursor
+}
 
-			parameters = append(parameters, cursorDBKey.Bytes())
-		} else {
-			return nil, ErrInvalidCursor
+// backfillMessageHashes sets the hash of the messages stored before the canonical
+// message hash was persisted. The id of those messages is updated too, since it
+// contains the hash of the message
+func (d *DBStore) backfillMessageHashes() error {
+	rows, err := d.db.Query("SELECT id, receiverTimestamp, senderTimestamp, contentTopic, pubsubTopic, payload, version, rawMessage FROM message WHERE messageHash IS NULL")
+	if err != nil {
+		return err
+	}
+
+	type messageHash struct {
+		id          []byte
+		pubsubTopic string
+		newID       []byte
+		hash        []byte
+	}
+
+	var hashes []messageHash
+	for rows.Next() {
+		record, err := d.GetStoredMessage(rows)
+		if err != nil {
+			rows.Close()
+			return err
 		}
+
+		env := protocol.NewEnvelope(record.Message, record.ReceiverTime, record.PubsubTopic)
+		hashes = append(hashes, messageHash{
+			id:          record.ID,
+			pubsubTopic: d.pubsubTopicColumn(record.PubsubTopic),
+			newID:       d.dbKey(record.Message.Timestamp, record.PubsubTopic, env.Hash()).Bytes(),
+			hash:        env.Hash(),
+		})
 	}
+	rows.Close()
 
-	conditionStr := ""
-	if len(conditions) != 0 {
-		conditionStr = "WHERE " + strings.Join(conditions, " AND ")
+	if err := rows.Err(); err != nil {
+		return err
 	}
 
-	orderDirection := "ASC"
-	if query.PagingInfo.Direction == pb.PagingInfo_BACKWARD {
-		orderDirection = "DESC"
+	if len(hashes) == 0 {
+		return nil
 	}
 
-	sqlQuery = fmt.Sprintf(sqlQuery, conditionStr, orderDirection, orderDirection)
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
 
-	stmt, err := d.db.Prepare(sqlQuery)
+	stmt, err := tx.Prepare(d.dialect.Rebind("UPDATE message SET id = ?, messageHash = ? WHERE id = ? AND pubsubTopic = ?"))
 	if err != nil {
-		return nil, err
+		_ = tx.Rollback()
+		return err
 	}
 	defer stmt.Close()
 
-	parameters = append(parameters, query.PagingInfo.PageSize)
-	rows, err := stmt.Query(parameters...)
+	for _, h := range hashes {
+		_, err := stmt.Exec(h.newID, h.hash, h.id, h.pubsubTopic)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	d.log.Info("message hashes added to stored messages", zap.Int("count", len(hashes)))
+
+	return tx.Commit()
+}
+
+// GetByHashes returns the stored messages whose hash is in a list
+func (d *DBStore) GetByHashes(hashes [][]byte) ([]StoredMessage, error) {
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+
+	var placeholders []string
+	var parameters []interface{}
+	for _, h := range hashes {
+		placeholders = append(placeholders, "?")
+		parameters = append(parameters, h)
+	}
+
+	sqlQuery := fmt.Sprintf("SELECT id, receiverTimestamp, senderTimestamp, contentTopic, pubsubTopic, payload, version, rawMessage FROM message WHERE messageHash IN (%s)", strings.Join(placeholders, ", "))
+	rows, err := d.readDB.Query(d.dialect.Rebind(sqlQuery), parameters...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	var result []StoredMessage
 	for rows.Next() {
@@ -314,57 +582,45 @@ func (d *DBStore) Query(query *pb.HistoryQuery) ([]StoredMessage, error) {
 		result = append(result, record)
 	}
 
-	defer rows.Close()
-
-	return result, nil
+	return result, rows.Err()
 }
 
 func (d *DBStore) MostRecentTimestamp() (int64, error) {
 	result := sql.NullInt64{}
 
-	err := d.db.QueryRow(`SELECT max(senderTimestamp) FROM message`).Scan(&result)
+	err := d.readDB.QueryRow(`SELECT max(senderTimestamp) FROM message`).Scan(&result)
 	if err != nil && err != sql.ErrNoRows {
 		return 0, err
 	}
 	return result.Int64, nil
 }
 
-// Returns all the stored WakuMessages
+// Returns all the stored WakuMessages. Iterate should be used instead for large
+// DBs, since every message is loaded in memory
 func (d *DBStore) GetAll() ([]StoredMessage, error) {
 	start := time.Now()
-	defer func() {
-		elapsed := time.Since(start)
-		d.log.Info("loading records from the DB", zap.Duration("duration", elapsed))
-	}()
-
-	rows, err := d.db.Query("SELECT id, receiverTimestamp, senderTimestamp, contentTopic, pubsubTopic, payload, version FROM message ORDER BY senderTimestamp ASC")
-	if err != nil {
-		return nil, err
-	}
 
 	var result []StoredMessage
-
-	defer rows.Close()
-
-	for rows.Next() {
-		record, err := d.GetStoredMessage(rows)
-		if err != nil {
-			return nil, err
-		}
-		result = append(result, record)
-	}
-
-	d.log.Info("DB returned records", zap.Int("count", len(result)))
-
-	err = rows.Err()
+	err := d.Iterate(&pb.HistoryQuery{}, func(msg StoredMessage) error {
+		result = append(result, msg)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	d.log.Debug("loaded every record from the DB", zap.Int("count", len(result)), zap.Duration("duration", time.Since(start)))
+
 	return result, nil
 }
 
 func (d *DBStore) GetStoredMessage(rows *sql.Rows) (StoredMessage, error) {
+	return d.scanStoredMessage(rows)
+}
+
+// scanStoredMessage reads a StoredMessage from the current row, along with the
+// values of the columns that follow the message columns
+func (d *DBStore) scanStoredMessage(rows *sql.Rows, extra ...interface{}) (StoredMessage, error) {
 	var id []byte
 	var receiverTimestamp int64
 	var senderTimestamp int64
@@ -372,18 +628,35 @@ func (d *DBStore) GetStoredMessage(rows *sql.Rows) (StoredMessage, error) {
 	var payload []byte
 	var version uint32
 	var pubsubTopic string
+	var rawMessage []byte
 
-	err := rows.Scan(&id, &receiverTimestamp, &senderTimestamp, &contentTopic, &pubsubTopic, &payload, &version)
+	dest := append([]interface{}{&id, &receiverTimestamp, &senderTimestamp, &contentTopic, &pubsubTopic, &payload, &version, &rawMessage}, extra...)
+	err := rows.Scan(dest...)
 	if err != nil {
 		d.log.Error("scanning messages from db", zap.Error(err))
 		return StoredMessage{}, err
 	}
 
 	msg := new(pb.WakuMessage)
-	msg.ContentTopic = contentTopic
-	msg.Payload = payload
-	msg.Timestamp = senderTimestamp
-	msg.Version = version
+	if d.cipher != nil {
+		msg, pubsubTopic, err = d.decryptMessage(rawMessage)
+		if err != nil {
+			d.log.Error("decrypting stored message", zap.Error(err))
+			return StoredMessage{}, err
+		}
+	} else if rawMessage != nil {
+		err = msg.Unmarshal(rawMessage)
+		if err != nil {
+			d.log.Error("decoding stored message", zap.Error(err))
+			return StoredMessage{}, err
+		}
+	} else {
+		// Messages stored before the serialized message was persisted
+		msg.ContentTopic = contentTopic
+		msg.Payload = payload
+		msg.Timestamp = senderTimestamp
+		msg.Version = version
+	}
 
 	record := StoredMessage{
 		ID:           id,
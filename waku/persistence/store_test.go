@@ -1,13 +1,17 @@
 package persistence
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"fmt"
+	"path/filepath"
 	"testing"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3" // Blank import to register the sqlite3 driver
 	"github.com/status-im/go-waku/tests"
 	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
 	"github.com/status-im/go-waku/waku/v2/utils"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -22,53 +26,370 @@ func NewMock() *sql.DB {
 	return db
 }
 
+type backend struct {
+	name    string
+	dialect Dialect
+	newDB   func(t *testing.T) *sql.DB
+}
+
+// extraBackends contains the databases registered with RegisterTestBackend
+var extraBackends []backend
+
+// RegisterTestBackend adds a database the DBStore tests are executed against. It is
+// used by the test files of other packages, which can't be imported by these tests
+func RegisterTestBackend(name string, dialect Dialect, newDB func(t *testing.T) *sql.DB) {
+	extraBackends = append(extraBackends, backend{name: name, dialect: dialect, newDB: newDB})
+}
+
+// backends returns the databases the DBStore tests are executed against: SQLite,
+// and the ones registered with RegisterTestBackend
+func backends() []backend {
+	result := []backend{
+		{name: "sqlite", dialect: SQLiteDialect{}, newDB: func(t *testing.T) *sql.DB { return NewMock() }},
+	}
+
+	return append(result, extraBackends...)
+}
+
 func TestDbStore(t *testing.T) {
-	db := NewMock()
-	option := WithDB(db)
-	store, err := NewDBStore(utils.Logger(), option)
-	require.NoError(t, err)
+	for _, b := range backends() {
+		t.Run(b.name, func(t *testing.T) {
+			db := b.newDB(t)
+			store, err := NewDBStore(utils.Logger(), WithDB(db), WithDialect(b.dialect))
+			require.NoError(t, err)
 
-	res, err := store.GetAll()
-	require.NoError(t, err)
-	require.Empty(t, res)
+			res, err := store.GetAll()
+			require.NoError(t, err)
+			require.Empty(t, res)
 
-	err = store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("test", 1), utils.GetUnixEpoch(), "test"))
-	require.NoError(t, err)
+			err = store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("test", 1), utils.GetUnixEpoch(), "test"))
+			require.NoError(t, err)
 
-	res, err = store.GetAll()
-	require.NoError(t, err)
-	require.NotEmpty(t, res)
+			res, err = store.GetAll()
+			require.NoError(t, err)
+			require.NotEmpty(t, res)
+		})
+	}
 }
 
 func TestStoreRetention(t *testing.T) {
-	db := NewMock()
-	store, err := NewDBStore(utils.Logger(), WithDB(db), WithRetentionPolicy(5, 20*time.Second))
-	require.NoError(t, err)
+	for _, b := range backends() {
+		t.Run(b.name, func(t *testing.T) {
+			db := b.newDB(t)
+			store, err := NewDBStore(utils.Logger(), WithDB(db), WithDialect(b.dialect), WithRetentionPolicy(5, 20*time.Second))
+			require.NoError(t, err)
 
-	insertTime := time.Now()
+			insertTime := time.Now()
 
-	_ = store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("test1", insertTime.Add(-70*time.Second).UnixNano()), insertTime.Add(-70*time.Second).UnixNano(), "test"))
-	_ = store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("test2", insertTime.Add(-60*time.Second).UnixNano()), insertTime.Add(-60*time.Second).UnixNano(), "test"))
-	_ = store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("test3", insertTime.Add(-50*time.Second).UnixNano()), insertTime.Add(-50*time.Second).UnixNano(), "test"))
-	_ = store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("test4", insertTime.Add(-40*time.Second).UnixNano()), insertTime.Add(-40*time.Second).UnixNano(), "test"))
-	_ = store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("test5", insertTime.Add(-30*time.Second).UnixNano()), insertTime.Add(-30*time.Second).UnixNano(), "test"))
+			_ = store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("test1", insertTime.Add(-70*time.Second).UnixNano()), insertTime.Add(-70*time.Second).UnixNano(), "test"))
+			_ = store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("test2", insertTime.Add(-60*time.Second).UnixNano()), insertTime.Add(-60*time.Second).UnixNano(), "test"))
+			_ = store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("test3", insertTime.Add(-50*time.Second).UnixNano()), insertTime.Add(-50*time.Second).UnixNano(), "test"))
+			_ = store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("test4", insertTime.Add(-40*time.Second).UnixNano()), insertTime.Add(-40*time.Second).UnixNano(), "test"))
+			_ = store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("test5", insertTime.Add(-30*time.Second).UnixNano()), insertTime.Add(-30*time.Second).UnixNano(), "test"))
 
-	dbResults, err := store.GetAll()
-	require.NoError(t, err)
-	require.Len(t, dbResults, 5)
+			dbResults, err := store.GetAll()
+			require.NoError(t, err)
+			require.Len(t, dbResults, 5)
+
+			_ = store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("test6", insertTime.Add(-20*time.Second).UnixNano()), insertTime.Add(-20*time.Second).UnixNano(), "test"))
+			_ = store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("test7", insertTime.Add(-10*time.Second).UnixNano()), insertTime.Add(-10*time.Second).UnixNano(), "test"))
+
+			// This step simulates starting go-waku again from scratch
+
+			store, err = NewDBStore(utils.Logger(), WithDB(db), WithDialect(b.dialect), WithRetentionPolicy(5, 40*time.Second))
+			require.NoError(t, err)
+
+			dbResults, err = store.GetAll()
+			require.NoError(t, err)
+			require.Len(t, dbResults, 3)
+			require.Equal(t, "test5", dbResults[0].Message.ContentTopic)
+			require.Equal(t, "test6", dbResults[1].Message.ContentTopic)
+			require.Equal(t, "test7", dbResults[2].Message.ContentTopic)
+		})
+	}
+}
+
+func TestStoreFullMessage(t *testing.T) {
+	for _, b := range backends() {
+		t.Run(b.name, func(t *testing.T) {
+			db := b.newDB(t)
+			store, err := NewDBStore(utils.Logger(), WithDB(db), WithDialect(b.dialect))
+			require.NoError(t, err)
+
+			// The proof and fields unknown to this version must be kept
+			msg := tests.CreateWakuMessage("test", 1)
+			msg.Proof = []byte{1, 2, 3}
+			msg.XXX_unrecognized = []byte{0xf8, 0x01, 0x01} // field 31, varint 1
+			expectedHash, err := msg.Hash()
+			require.NoError(t, err)
+
+			require.NoError(t, store.Put(protocol.NewEnvelope(msg, utils.GetUnixEpoch(), "test")))
+
+			// Rows stored before the serialized message was persisted are still returned
+			_, err = db.Exec(b.dialect.Rebind("INSERT INTO message (id, receiverTimestamp, senderTimestamp, contentTopic, pubsubTopic, payload, version) VALUES (?, ?, ?, ?, ?, ?, ?)"),
+				[]byte{1}, 2, 2, "legacy", "test", []byte{4, 5}, 0)
+			require.NoError(t, err)
+
+			res, err := store.Query(&pb.HistoryQuery{PagingInfo: &pb.PagingInfo{PageSize: 10, Direction: pb.PagingInfo_FORWARD}})
+			require.NoError(t, err)
+			require.Len(t, res, 2)
+
+			require.Equal(t, []byte{1, 2, 3}, res[0].Message.Proof)
+			hash, err := res[0].Message.Hash()
+			require.NoError(t, err)
+			require.Equal(t, expectedHash, hash)
+
+			require.Equal(t, "legacy", res[1].Message.ContentTopic)
+			require.Equal(t, []byte{4, 5}, res[1].Message.Payload)
+			require.Equal(t, int64(2), res[1].Message.Timestamp)
+		})
+	}
+}
+
+func TestStoreGetByHashes(t *testing.T) {
+	for _, b := range backends() {
+		t.Run(b.name, func(t *testing.T) {
+			db := b.newDB(t)
+			store, err := NewDBStore(utils.Logger(), WithDB(db), WithDialect(b.dialect))
+			require.NoError(t, err)
+
+			env1 := protocol.NewEnvelope(tests.CreateWakuMessage("test1", 1), utils.GetUnixEpoch(), "test")
+			env2 := protocol.NewEnvelope(tests.CreateWakuMessage("test2", 2), utils.GetUnixEpoch(), "test")
+			require.NoError(t, store.Put(env1))
+			require.NoError(t, store.Put(env2))
+
+			res, err := store.GetByHashes([][]byte{env2.Hash(), {1, 2, 3}})
+			require.NoError(t, err)
+			require.Len(t, res, 1)
+			require.Equal(t, "test2", res[0].Message.ContentTopic)
+
+			// The hash of messages stored before it was persisted is set when the store is created
+			_, err = db.Exec("UPDATE message SET messageHash = NULL")
+			require.NoError(t, err)
+
+			store, err = NewDBStore(utils.Logger(), WithDB(db), WithDialect(b.dialect))
+			require.NoError(t, err)
+
+			res, err = store.GetByHashes([][]byte{env1.Hash(), env2.Hash()})
+			require.NoError(t, err)
+			require.Len(t, res, 2)
+		})
+	}
+}
+
+func TestStoreCanonicalHash(t *testing.T) {
+	for _, b := range backends() {
+		t.Run(b.name, func(t *testing.T) {
+			db := b.newDB(t)
+			store, err := NewDBStore(utils.Logger(), WithDB(db), WithDialect(b.dialect))
+			require.NoError(t, err)
+
+			// Messages with the same content topic and payload are different messages
+			msg1 := tests.CreateWakuMessage("test", 1)
+			msg2 := tests.CreateWakuMessage("test", 1)
+			msg2.Version = 1
+			require.NoError(t, store.Put(protocol.NewEnvelope(msg1, utils.GetUnixEpoch(), "test")))
+			require.NoError(t, store.Put(protocol.NewEnvelope(msg2, utils.GetUnixEpoch(), "test")))
+			require.ErrorIs(t, store.Put(protocol.NewEnvelope(msg1, utils.GetUnixEpoch(), "test")), ErrDuplicateMessage)
+
+			// Messages stored by older versions used a digest of the content topic and payload
+			legacyMsg := tests.CreateWakuMessage("test", 5)
+			rawMessage, err := legacyMsg.Marshal()
+			require.NoError(t, err)
+			legacyDigest := sha256.Sum256(append([]byte(legacyMsg.ContentTopic), legacyMsg.Payload...))
+			_, err = db.Exec(b.dialect.Rebind("INSERT INTO message (id, receiverTimestamp, senderTimestamp, contentTopic, pubsubTopic, payload, version, rawMessage) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"),
+				NewDBKey(5, "test", legacyDigest[:]).Bytes(), 5, 5, "test", "test", legacyMsg.Payload, 0, rawMessage)
+			require.NoError(t, err)
 
-	_ = store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("test6", insertTime.Add(-20*time.Second).UnixNano()), insertTime.Add(-20*time.Second).UnixNano(), "test"))
-	_ = store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("test7", insertTime.Add(-10*time.Second).UnixNano()), insertTime.Add(-10*time.Second).UnixNano(), "test"))
+			require.NoError(t, store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("test", 10), utils.GetUnixEpoch(), "test")))
 
-	// This step simulates starting go-waku again from scratch
+			// The hash and id of the message are updated when the store is created
+			store, err = NewDBStore(utils.Logger(), WithDB(db), WithDialect(b.dialect))
+			require.NoError(t, err)
 
-	store, err = NewDBStore(utils.Logger(), WithDB(db), WithRetentionPolicy(5, 40*time.Second))
+			legacyEnv := protocol.NewEnvelope(legacyMsg, 5, "test")
+			res, err := store.GetByHashes([][]byte{legacyEnv.Hash()})
+			require.NoError(t, err)
+			require.Len(t, res, 1)
+			require.Equal(t, NewDBKey(5, "test", legacyEnv.Hash()).Bytes(), res[0].ID)
+
+			// Cursors issued by older versions are still accepted
+			legacyCursor := &pb.Index{Digest: legacyDigest[:], ReceiverTime: 5, SenderTime: 5, PubsubTopic: "test"}
+			res, err = store.Query(&pb.HistoryQuery{PagingInfo: &pb.PagingInfo{PageSize: 10, Cursor: legacyCursor, Direction: pb.PagingInfo_FORWARD}})
+			require.NoError(t, err)
+			require.Len(t, res, 1)
+			require.Equal(t, int64(10), res[0].Message.Timestamp)
+
+			_, err = store.Query(&pb.HistoryQuery{PagingInfo: &pb.PagingInfo{PageSize: 10, Cursor: legacyEnv.Index(), Direction: pb.PagingInfo_BACKWARD}})
+			require.NoError(t, err)
+
+			legacyCursor.Digest = []byte{1, 2, 3}
+			_, err = store.Query(&pb.HistoryQuery{PagingInfo: &pb.PagingInfo{PageSize: 10, Cursor: legacyCursor}})
+			require.ErrorIs(t, err, ErrInvalidCursor)
+		})
+	}
+}
+
+func TestStorePutBatch(t *testing.T) {
+	for _, b := range backends() {
+		t.Run(b.name, func(t *testing.T) {
+			db := b.newDB(t)
+			store, err := NewDBStore(utils.Logger(), WithDB(db), WithDialect(b.dialect))
+			require.NoError(t, err)
+
+			stored := protocol.NewEnvelope(tests.CreateWakuMessage("test", 1), utils.GetUnixEpoch(), "test")
+			require.NoError(t, store.Put(stored))
+
+			newEnv := protocol.NewEnvelope(tests.CreateWakuMessage("test", 2), utils.GetUnixEpoch(), "test")
+			errs := store.PutBatch([]*protocol.Envelope{
+				newEnv,
+				stored,
+				protocol.NewEnvelope(tests.CreateWakuMessage("test", 3), utils.GetUnixEpoch(), "test"),
+				newEnv,
+			})
+			require.Equal(t, []error{nil, ErrDuplicateMessage, nil, ErrDuplicateMessage}, errs)
+
+			res, err := store.GetAll()
+			require.NoError(t, err)
+			require.Len(t, res, 3)
+		})
+	}
+}
+
+func TestStoreReadDBPathEscaping(t *testing.T) {
+	// The path can't contain `?` here, since the driver takes it as the start of the
+	// options of the DB opened with it
+	path := filepath.Join(t.TempDir(), "store#%41.db")
+	db, err := sql.Open("sqlite3", path)
 	require.NoError(t, err)
 
-	dbResults, err = store.GetAll()
+	store, err := NewDBStore(utils.Logger(), WithDB(db))
 	require.NoError(t, err)
-	require.Len(t, dbResults, 3)
-	require.Equal(t, "test5", dbResults[0].Message.ContentTopic)
-	require.Equal(t, "test6", dbResults[1].Message.ContentTopic)
-	require.Equal(t, "test7", dbResults[2].Message.ContentTopic)
+	defer store.Stop()
+
+	require.NoError(t, store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("test", 1), utils.GetUnixEpoch(), "test")))
+
+	// The queries are served by the read-only connections to the same file
+	res, err := store.Query(&pb.HistoryQuery{PagingInfo: &pb.PagingInfo{PageSize: 10, Direction: pb.PagingInfo_FORWARD}})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+}
+
+// sharedPoolDialect uses the same connection for reads and writes, like the DBStore
+// did before queries had their own connection pool
+type sharedPoolDialect struct {
+	SQLiteDialect
+}
+
+func (sharedPoolDialect) ReadDB(db *sql.DB) (*sql.DB, error) {
+	return db, nil
+}
+
+func benchmarkStore(b *testing.B, dialect Dialect, count int) (*DBStore, *sql.DB) {
+	db, err := sql.Open("sqlite3", filepath.Join(b.TempDir(), "store.db"))
+	require.NoError(b, err)
+
+	store, err := NewDBStore(utils.Logger(), WithDB(db), WithDialect(dialect))
+	require.NoError(b, err)
+
+	var envs []*protocol.Envelope
+	for i := 0; i < count; i++ {
+		msg := tests.CreateWakuMessage(fmt.Sprintf("/bench/%d", i%20), int64(i+1))
+		envs = append(envs, protocol.NewEnvelope(msg, int64(i+1), fmt.Sprintf("pubsub/%d", i%2)))
+		if len(envs) == 500 {
+			store.PutBatch(envs)
+			envs = nil
+		}
+	}
+	store.PutBatch(envs)
+
+	return store, db
+}
+
+// BenchmarkStoreQuery retrieves pages of a content topic, with and without the indexes
+// created by the migrations
+func BenchmarkStoreQuery(b *testing.B) {
+	for _, indexed := range []bool{true, false} {
+		b.Run(fmt.Sprintf("indexed=%t", indexed), func(b *testing.B) {
+			store, db := benchmarkStore(b, SQLiteDialect{}, 20000)
+			defer store.Stop()
+
+			if !indexed {
+				_, err := db.Exec("DROP INDEX message_pubsubTopic_senderTimestamp; DROP INDEX message_contentTopic_senderTimestamp")
+				require.NoError(b, err)
+			}
+
+			query := &pb.HistoryQuery{
+				PubsubTopic:    "pubsub/1",
+				ContentFilters: []*pb.ContentFilter{{ContentTopic: "/bench/7"}},
+				PagingInfo:     &pb.PagingInfo{PageSize: 20, Direction: pb.PagingInfo_BACKWARD},
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				res, err := store.Query(query)
+				require.NoError(b, err)
+				require.Len(b, res, 20)
+
+				// The next page is retrieved with a cursor
+				last := res[len(res)-1]
+				query.PagingInfo.Cursor = protocol.NewEnvelope(last.Message, last.ReceiverTime, last.PubsubTopic).Index()
+				_, err = store.Query(query)
+				require.NoError(b, err)
+				query.PagingInfo.Cursor = nil
+			}
+		})
+	}
+}
+
+// BenchmarkStoreQueryWhileWriting retrieves pages of messages while other messages are
+// being stored, with and without a separate connection pool for the queries
+func BenchmarkStoreQueryWhileWriting(b *testing.B) {
+	dialects := map[string]Dialect{
+		"read-pool":   SQLiteDialect{},
+		"shared-pool": sharedPoolDialect{},
+	}
+
+	for name, dialect := range dialects {
+		b.Run(name, func(b *testing.B) {
+			store, _ := benchmarkStore(b, dialect, 5000)
+
+			quit := make(chan struct{})
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				ts := int64(100000)
+				for {
+					select {
+					case <-quit:
+						return
+					default:
+					}
+
+					var envs []*protocol.Envelope
+					for i := 0; i < 100; i++ {
+						ts++
+						envs = append(envs, protocol.NewEnvelope(tests.CreateWakuMessage("/bench/write", ts), ts, "pubsub/0"))
+					}
+					store.PutBatch(envs)
+				}
+			}()
+
+			query := &pb.HistoryQuery{
+				ContentFilters: []*pb.ContentFilter{{ContentTopic: "/bench/3"}},
+				PagingInfo:     &pb.PagingInfo{PageSize: 100, Direction: pb.PagingInfo_FORWARD},
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := store.Query(query)
+				require.NoError(b, err)
+			}
+			b.StopTimer()
+
+			close(quit)
+			<-done
+			store.Stop()
+		})
+	}
 }
@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"context"
+	"time"
 
 	"github.com/status-im/go-waku/waku/v2/utils"
 	"go.opencensus.io/stats"
@@ -11,19 +12,25 @@ import (
 )
 
 var (
-	Messages            = stats.Int64("node_messages", "Number of messages received", stats.UnitDimensionless)
-	Peers               = stats.Int64("peers", "Number of connected peers", stats.UnitDimensionless)
-	NetworkPeers        = stats.Int64("network_peers", "Current count of host network peers", stats.UnitDimensionless)
-	Dials               = stats.Int64("dials", "Number of peer dials", stats.UnitDimensionless)
-	StoreMessages       = stats.Int64("store_messages", "Number of historical messages", stats.UnitDimensionless)
-	FilterSubscriptions = stats.Int64("filter_subscriptions", "Number of filter subscriptions", stats.UnitDimensionless)
-	StoreErrors         = stats.Int64("errors", "Number of errors in store protocol", stats.UnitDimensionless)
-	LightpushErrors     = stats.Int64("errors", "Number of errors in lightpush protocol", stats.UnitDimensionless)
+	Messages                = stats.Int64("node_messages", "Number of messages received", stats.UnitDimensionless)
+	Peers                   = stats.Int64("peers", "Number of connected peers", stats.UnitDimensionless)
+	NetworkPeers            = stats.Int64("network_peers", "Current count of host network peers", stats.UnitDimensionless)
+	Dials                   = stats.Int64("dials", "Number of peer dials", stats.UnitDimensionless)
+	StoreMessages           = stats.Int64("store_messages", "Number of historical messages", stats.UnitDimensionless)
+	FilterSubscriptions     = stats.Int64("filter_subscriptions", "Number of filter subscriptions", stats.UnitDimensionless)
+	StoreErrors             = stats.Int64("errors", "Number of errors in store protocol", stats.UnitDimensionless)
+	LightpushErrors         = stats.Int64("errors", "Number of errors in lightpush protocol", stats.UnitDimensionless)
+	StoreRetentionDeletions = stats.Int64("store_retention_deletions", "Number of messages deleted by store retention rules", stats.UnitDimensionless)
+	StoreQueueLength        = stats.Int64("store_queue_length", "Number of messages waiting to be stored", stats.UnitDimensionless)
+	StoreBatchSize          = stats.Int64("store_batch_size", "Number of messages stored in a single write", stats.UnitDimensionless)
+	StoreWriteDuration      = stats.Float64("store_write_duration", "Time spent storing a batch of messages", stats.UnitMilliseconds)
+	StoreSyncMessages       = stats.Int64("store_sync_messages", "Number of messages exchanged by store synchronization", stats.UnitDimensionless)
 )
 
 var (
 	KeyType, _   = tag.NewKey("type")
 	ErrorType, _ = tag.NewKey("error_type")
+	RuleName, _  = tag.NewKey("rule")
 )
 
 var (
@@ -78,6 +85,38 @@ var (
 		Aggregation: view.Count(),
 		TagKeys:     []tag.Key{ErrorType},
 	}
+	StoreRetentionDeletionsView = &view.View{
+		Name:        "gowaku_store_retention_deletions",
+		Measure:     StoreRetentionDeletions,
+		Description: "The number of messages deleted by each store retention rule",
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{RuleName},
+	}
+	StoreQueueLengthView = &view.View{
+		Name:        "gowaku_store_queue_length",
+		Measure:     StoreQueueLength,
+		Description: "The number of messages waiting to be stored",
+		Aggregation: view.LastValue(),
+	}
+	StoreBatchSizeView = &view.View{
+		Name:        "gowaku_store_batch_size",
+		Measure:     StoreBatchSize,
+		Description: "The distribution of the number of messages stored in a single write",
+		Aggregation: view.Distribution(1, 10, 50, 100, 250, 500, 1000),
+	}
+	StoreWriteDurationView = &view.View{
+		Name:        "gowaku_store_write_duration",
+		Measure:     StoreWriteDuration,
+		Description: "The distribution of the time spent storing a batch of messages",
+		Aggregation: view.Distribution(1, 5, 10, 25, 50, 100, 250, 500, 1000, 5000),
+	}
+	StoreSyncMessagesView = &view.View{
+		Name:        "gowaku_store_sync_messages",
+		Measure:     StoreSyncMessages,
+		Description: "The number of messages fetched from, and offered to, other store nodes during synchronization",
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{KeyType},
+	}
 )
 
 func RecordLightpushError(ctx context.Context, tagType string) {
@@ -97,3 +136,26 @@ func RecordStoreError(ctx context.Context, tagType string) {
 		utils.Logger().Error("failed to record with tags", zap.Error(err))
 	}
 }
+
+func RecordStoreRetentionDeletions(ctx context.Context, rule string, count int64) {
+	if err := stats.RecordWithTags(ctx, []tag.Mutator{tag.Insert(RuleName, rule)}, StoreRetentionDeletions.M(count)); err != nil {
+		utils.Logger().Error("failed to record with tags", zap.Error(err))
+	}
+}
+
+// RecordStoreWrite records the number of messages written in a batch, the time it
+// took, and the number of messages that are still waiting to be stored
+func RecordStoreWrite(ctx context.Context, batchSize int, duration time.Duration, queueLength int) {
+	stats.Record(ctx,
+		StoreBatchSize.M(int64(batchSize)),
+		StoreWriteDuration.M(float64(duration)/float64(time.Millisecond)),
+		StoreQueueLength.M(int64(queueLength)))
+}
+
+// RecordStoreSync records the number of messages fetched from another store node
+// during a synchronization, or offered to it because it was missing them
+func RecordStoreSync(ctx context.Context, tagType string, count int) {
+	if err := stats.RecordWithTags(ctx, []tag.Mutator{tag.Insert(KeyType, tagType)}, StoreSyncMessages.M(int64(count))); err != nil {
+		utils.Logger().Error("failed to record with tags", zap.Error(err))
+	}
+}
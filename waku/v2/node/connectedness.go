@@ -103,6 +103,7 @@ func (w *WakuNode) connectednessListener() {
 		case <-w.connectionNotif.DisconnectChan:
 		}
 		w.sendConnStatus()
+		w.notifyConnectivityChange()
 	}
 }
 
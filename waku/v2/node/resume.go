@@ -0,0 +1,194 @@
+package node
+
+import (
+	"errors"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/status-im/go-waku/logging"
+	"github.com/status-im/go-waku/waku/v2/protocol/store"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"go.uber.org/zap"
+)
+
+// resumeRequestTimeout limits the time to retrieve each page of the history, so the
+// resume of a long history is not cut short and retried from the start
+const resumeRequestTimeout = 30 * time.Second
+const resumeInitialBackoff = 10 * time.Second
+const resumeMaxBackoff = 5 * time.Minute
+
+// ResumeStatus is used to indicate the progress of the automatic history
+// resume of a pubsub topic. LastSynced is the timestamp up to which the
+// message history of the topic is known to be complete
+type ResumeStatus struct {
+	PubsubTopic string
+	PeerID      peer.ID
+	Retrieved   int
+	Stored      int
+	LastSynced  int64
+	Err         error
+}
+
+// resumeScheduler keeps track of the pubsub topics whose history has been
+// retrieved since the node last had connectivity to store nodes
+type resumeScheduler struct {
+	lastSynced map[string]int64
+	inSync     map[string]bool
+
+	isOnline   bool
+	hasHistory bool
+
+	backoff time.Duration
+}
+
+func newResumeScheduler() *resumeScheduler {
+	return &resumeScheduler{
+		lastSynced: make(map[string]int64),
+		inSync:     make(map[string]bool),
+		backoff:    resumeInitialBackoff,
+	}
+}
+
+// notifyConnectivityChange signals the resume scheduler that the connectivity
+// of the node might have changed. It never blocks
+func (w *WakuNode) notifyConnectivityChange() {
+	select {
+	case w.connectivityC <- struct{}{}:
+	default:
+	}
+}
+
+// startResumeScheduler creates a go routine that retrieves the message history
+// of the relayed pubsub topics each time the node goes from offline to online,
+// or regains access to a store node after losing all of them
+func (w *WakuNode) startResumeScheduler() {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		r := newResumeScheduler()
+
+		timer := time.NewTimer(0)
+		if !timer.Stop() {
+			<-timer.C
+		}
+		defer timer.Stop()
+
+		pending := false
+
+		// The connectivity might have changed before the scheduler started
+		w.notifyConnectivityChange()
+
+		for {
+			select {
+			case <-w.quit:
+				return
+			case <-w.connectivityC:
+				isOnline, hasHistory := w.Status()
+
+				if (r.isOnline && !isOnline) || (r.hasHistory && !hasHistory) {
+					w.log.Info("lost connectivity to store nodes, history will be resumed once it's regained")
+					r.markOutOfSync(utils.GetUnixEpoch())
+				}
+
+				if (!r.isOnline && isOnline) || (!r.hasHistory && hasHistory) {
+					pending = true
+					r.backoff = resumeInitialBackoff
+					timer.Reset(0)
+				}
+
+				r.isOnline = isOnline
+				r.hasHistory = hasHistory
+			case <-timer.C:
+				if !pending || !r.hasHistory {
+					continue
+				}
+
+				success, err := w.resume(r)
+				if errors.Is(err, store.ErrNoMessageProvider) {
+					// Retrying can't succeed until the node is restarted with a message provider
+					w.log.Warn("stopping history resume", zap.Error(err))
+					return
+				}
+
+				if success {
+					pending = false
+					r.backoff = resumeInitialBackoff
+				} else {
+					w.log.Info("retrying history resume", zap.Duration("backoff", r.backoff))
+					timer.Reset(r.backoff)
+					r.backoff *= 2
+					if r.backoff > resumeMaxBackoff {
+						r.backoff = resumeMaxBackoff
+					}
+				}
+			}
+		}
+	}()
+}
+
+// markOutOfSync flags every pubsub topic that was in sync as requiring a resume,
+// recording that their history is complete up to time t
+func (r *resumeScheduler) markOutOfSync(t int64) {
+	for topic, inSync := range r.inSync {
+		if inSync {
+			r.lastSynced[topic] = t
+			r.inSync[topic] = false
+		}
+	}
+}
+
+// resume retrieves the history of each relayed pubsub topic that is not in sync.
+// It returns true if the history of all the topics was retrieved successfully, and
+// store.ErrNoMessageProvider if the history can't be resumed because the node does
+// not store messages
+func (w *WakuNode) resume(r *resumeScheduler) (bool, error) {
+	success := true
+	for _, topic := range w.resumeTopics() {
+		if r.inSync[topic] {
+			continue
+		}
+
+		now := utils.GetUnixEpoch()
+		result, err := w.store.Resume(w.ctx, []string{topic}, nil, store.WithLastSynced(r.lastSynced[topic]), store.WithRequestTimeout(resumeRequestTimeout))
+
+		status := ResumeStatus{PubsubTopic: topic, LastSynced: r.lastSynced[topic], Err: err}
+		if errors.Is(err, store.ErrNoMessageProvider) {
+			w.sendResumeStatus(status)
+			return false, err
+		}
+
+		if err != nil {
+			w.log.Info("resuming history", zap.String("pubsubTopic", topic), zap.Error(err))
+			success = false
+		} else {
+			r.lastSynced[topic] = now
+			r.inSync[topic] = true
+
+			status.LastSynced = now
+			if len(result) != 0 {
+				status.PeerID = result[0].PeerID
+				status.Retrieved = result[0].Retrieved
+				status.Stored = result[0].Stored
+			}
+
+			w.log.Info("history resumed", zap.String("pubsubTopic", topic), logging.HostID("peer", status.PeerID), logging.Time("lastSynced", now))
+		}
+
+		w.sendResumeStatus(status)
+	}
+
+	return success, nil
+}
+
+// sendResumeStatus pushes a status to the resume status channel without blocking the
+// scheduler. Statuses are dropped while the channel is full
+func (w *WakuNode) sendResumeStatus(status ResumeStatus) {
+	if w.resumeStatusChan != nil {
+		select {
+		case w.resumeStatusChan <- status:
+		default:
+			w.log.Debug("resume status channel is full, dropping status", zap.String("pubsubTopic", status.PubsubTopic))
+		}
+	}
+}
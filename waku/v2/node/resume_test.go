@@ -0,0 +1,156 @@
+package node
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/persistence"
+	"github.com/status-im/go-waku/waku/persistence/sqlite"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/relay"
+	"github.com/status-im/go-waku/waku/v2/protocol/store"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func memoryDBStore(t *testing.T) *persistence.DBStore {
+	db, err := sqlite.NewDB(":memory:")
+	require.NoError(t, err)
+	dbStore, err := persistence.NewDBStore(utils.Logger(), persistence.WithDB(db))
+	require.NoError(t, err)
+	return dbStore
+}
+
+func waitForResumeStatus(t *testing.T, resumeStatusChan chan ResumeStatus) ResumeStatus {
+	select {
+	case status := <-resumeStatusChan:
+		return status
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "history should have been resumed")
+	}
+	return ResumeStatus{}
+}
+
+func TestResumeOnConnectivityChanges(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dbStore1 := memoryDBStore(t)
+	for i := 0; i < 5; i++ {
+		msg := tests.CreateWakuMessage("test", utils.GetUnixEpoch())
+		require.NoError(t, dbStore1.Put(protocol.NewEnvelope(msg, utils.GetUnixEpoch(), relay.DefaultWakuTopic)))
+	}
+
+	// Node1: Relay + Store
+	hostAddr1, err := net.ResolveTCPAddr("tcp", "0.0.0.0:0")
+	require.NoError(t, err)
+	node1, err := New(ctx,
+		WithHostAddress(hostAddr1),
+		WithWakuRelay(),
+		WithWakuStore(true, false),
+		WithMessageProvider(dbStore1),
+	)
+	require.NoError(t, err)
+	require.NoError(t, node1.Start())
+	defer node1.Stop()
+
+	resumeStatusChan := make(chan ResumeStatus, 10)
+
+	// Node2: Relay + Store with resume
+	hostAddr2, err := net.ResolveTCPAddr("tcp", "0.0.0.0:0")
+	require.NoError(t, err)
+	node2, err := New(ctx,
+		WithHostAddress(hostAddr2),
+		WithWakuRelay(),
+		WithWakuStore(true, true),
+		WithMessageProvider(memoryDBStore(t)),
+		WithResumeStatusChannel(resumeStatusChan),
+	)
+	require.NoError(t, err)
+	require.NoError(t, node2.Start())
+	defer node2.Stop()
+
+	err = node2.DialPeer(ctx, node1.ListenAddresses()[0].String())
+	require.NoError(t, err)
+
+	status := waitForResumeStatus(t, resumeStatusChan)
+	require.NoError(t, status.Err)
+	require.Equal(t, relay.DefaultWakuTopic, status.PubsubTopic)
+	require.Equal(t, node1.Host().ID(), status.PeerID)
+	require.Equal(t, 5, status.Stored)
+	require.NotZero(t, status.LastSynced)
+
+	lastSynced := status.LastSynced
+
+	// Going offline and back online triggers a new resume
+	err = node2.ClosePeerById(node1.Host().ID())
+	require.NoError(t, err)
+
+	time.Sleep(500 * time.Millisecond)
+
+	err = node2.DialPeerByID(ctx, node1.Host().ID())
+	require.NoError(t, err)
+
+	status = waitForResumeStatus(t, resumeStatusChan)
+	require.NoError(t, status.Err)
+	require.Equal(t, 0, status.Stored)
+	require.Greater(t, status.LastSynced, lastSynced)
+}
+
+func TestSendResumeStatusDoesNotBlock(t *testing.T) {
+	w := &WakuNode{log: utils.Logger(), resumeStatusChan: make(chan ResumeStatus)}
+
+	done := make(chan struct{})
+	go func() {
+		w.sendResumeStatus(ResumeStatus{PubsubTopic: "test"})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.Fail(t, "sending a status blocked without a reader")
+	}
+}
+
+func TestResumeWithoutMessageProvider(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Node1: Relay + Store
+	hostAddr1, err := net.ResolveTCPAddr("tcp", "0.0.0.0:0")
+	require.NoError(t, err)
+	node1, err := New(ctx,
+		WithHostAddress(hostAddr1),
+		WithWakuRelay(),
+		WithWakuStore(true, false),
+		WithMessageProvider(memoryDBStore(t)),
+	)
+	require.NoError(t, err)
+	require.NoError(t, node1.Start())
+	defer node1.Stop()
+
+	resumeStatusChan := make(chan ResumeStatus, 10)
+
+	// Node2: Relay + Store with resume, but without a message provider
+	hostAddr2, err := net.ResolveTCPAddr("tcp", "0.0.0.0:0")
+	require.NoError(t, err)
+	node2, err := New(ctx,
+		WithHostAddress(hostAddr2),
+		WithWakuRelay(),
+		WithWakuStore(false, true),
+		WithResumeStatusChannel(resumeStatusChan),
+	)
+	require.NoError(t, err)
+	require.NoError(t, node2.Start())
+	defer node2.Stop()
+
+	err = node2.DialPeer(ctx, node1.ListenAddresses()[0].String())
+	require.NoError(t, err)
+
+	status := waitForResumeStatus(t, resumeStatusChan)
+	require.ErrorIs(t, status.Err, store.ErrNoMessageProvider)
+}
@@ -85,11 +85,18 @@ type WakuNode struct {
 	// receiving connection status notifications
 	connStatusChan chan ConnStatus
 
+	// Channel passed to WakuNode constructor
+	// receiving history resume progress notifications
+	resumeStatusChan chan ResumeStatus
+
+	// Signals the resume scheduler of connectivity changes
+	connectivityC chan struct{}
+
 	storeFactory storeFactory
 }
 
 func defaultStoreFactory(w *WakuNode) store.Store {
-	return store.NewWakuStore(w.host, w.swap, w.opts.messageProvider, w.opts.maxMessages, w.opts.maxDuration, w.log)
+	return store.NewWakuStore(w.host, w.swap, w.opts.messageProvider, w.opts.maxMessages, w.opts.maxDuration, w.log, w.opts.storeOpts...)
 }
 
 // New is used to instantiate a WakuNode using a set of WakuNodeOptions
@@ -155,6 +162,7 @@ func New(ctx context.Context, opts ...WakuNodeOption) (*WakuNode, error) {
 	w.wg = &sync.WaitGroup{}
 	w.addrChan = make(chan ma.Multiaddr, 1024)
 	w.keepAliveFails = make(map[peer.ID]int)
+	w.connectivityC = make(chan struct{}, 1)
 	w.wakuFlag = utils.NewWakuEnrBitfield(w.opts.enableLightPush, w.opts.enableFilter, w.opts.enableStore, w.opts.enableRelay)
 
 	if params.storeFactory != nil {
@@ -179,6 +187,10 @@ func New(ctx context.Context, opts ...WakuNodeOption) (*WakuNode, error) {
 		w.connStatusChan = params.connStatusC
 	}
 
+	if params.resumeStatusC != nil {
+		w.resumeStatusChan = params.resumeStatusC
+	}
+
 	w.connectionNotif = NewConnectionNotifier(ctx, host, w.log)
 	w.host.Network().Notify(w.connectionNotif)
 
@@ -245,10 +257,15 @@ func (w *WakuNode) Start() error {
 	w.log.Info("Version details ", zap.String("commit", GitCommit), zap.String("version", Version))
 
 	if w.opts.enableSwap {
-		w.swap = swap.NewWakuSwap(w.log, []swap.SwapOption{
+		w.swap = swap.NewWakuSwap(w.host, w.log, []swap.SwapOption{
 			swap.WithMode(w.opts.swapMode),
 			swap.WithThreshold(w.opts.swapPaymentThreshold, w.opts.swapDisconnectThreshold),
+			swap.WithPrivateKey(w.opts.privKey),
 		}...)
+
+		if err := w.swap.Start(w.ctx); err != nil {
+			return err
+		}
 	}
 
 	w.store = w.storeFactory(w)
@@ -343,6 +360,10 @@ func (w *WakuNode) Stop() {
 	w.lightPush.Stop()
 	w.store.Stop()
 
+	if w.swap != nil {
+		w.swap.Stop()
+	}
+
 	w.host.Close()
 
 	w.wg.Wait()
@@ -415,7 +436,7 @@ func (w *WakuNode) Publish(ctx context.Context, msg *pb.WakuMessage) error {
 		return errors.New("cannot publish message, relay and lightpush are disabled")
 	}
 
-	hash, _ := msg.Hash()
+	hash := pb.MessageHash(relay.DefaultWakuTopic, msg)
 	err := try.Do(func(attempt int) (bool, error) {
 		var err error
 		if !w.relay.EnoughPeersToPublish() {
@@ -488,40 +509,23 @@ func (w *WakuNode) startStore() {
 	w.store.Start(w.ctx)
 
 	if w.opts.shouldResume {
-		// TODO: extract this to a function and run it when you go offline
-		// TODO: determine if a store is listening to a topic
-		w.wg.Add(1)
-		go func() {
-			defer w.wg.Done()
-
-			ticker := time.NewTicker(time.Second)
-			defer ticker.Stop()
-
-			for {
-			peerVerif:
-				for {
-					select {
-					case <-w.quit:
-						return
-					case <-ticker.C:
-						_, err := utils.SelectPeer(w.host, string(store.StoreID_v20beta4), w.log)
-						if err == nil {
-							break peerVerif
-						}
-					}
-				}
+		w.startResumeScheduler()
+	}
+}
 
-				ctxWithTimeout, ctxCancel := context.WithTimeout(w.ctx, 20*time.Second)
-				defer ctxCancel()
-				if _, err := w.store.Resume(ctxWithTimeout, string(relay.DefaultWakuTopic), nil); err != nil {
-					w.log.Info("Retrying in 10s...")
-					time.Sleep(10 * time.Second)
-				} else {
-					break
-				}
-			}
-		}()
+// resumeTopics returns the list of pubsub topics whose history should be
+// retrieved when resuming, which are all the topics the node relays
+func (w *WakuNode) resumeTopics() []string {
+	var topics []string
+	if w.relay != nil {
+		topics = w.relay.Topics()
 	}
+
+	if len(topics) == 0 {
+		topics = append(topics, string(relay.DefaultWakuTopic))
+	}
+
+	return topics
 }
 
 func (w *WakuNode) addPeer(info *peer.AddrInfo, protocols ...string) error {
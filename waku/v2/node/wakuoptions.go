@@ -64,6 +64,7 @@ type WakuNodeParameters struct {
 	shouldResume    bool
 	storeMsgs       bool
 	messageProvider store.MessageProvider
+	storeOpts       []store.Option
 	maxMessages     int
 	maxDuration     time.Duration
 
@@ -88,6 +89,8 @@ type WakuNodeParameters struct {
 
 	connStatusC chan ConnStatus
 
+	resumeStatusC chan ResumeStatus
+
 	storeFactory storeFactory
 }
 
@@ -298,12 +301,14 @@ func WithWakuFilter(fullNode bool, filterOpts ...filter.Option) WakuNodeOption {
 }
 
 // WithWakuStore enables the Waku V2 Store protocol and if the messages should
-// be stored or not in a message provider
-func WithWakuStore(shouldStoreMessages bool, shouldResume bool) WakuNodeOption {
+// be stored or not in a message provider. This WakuNodeOption accepts a list of
+// WakuStore options to setup the protocol
+func WithWakuStore(shouldStoreMessages bool, shouldResume bool, storeOpts ...store.Option) WakuNodeOption {
 	return func(params *WakuNodeParameters) error {
 		params.enableStore = true
 		params.storeMsgs = shouldStoreMessages
 		params.shouldResume = shouldResume
+		params.storeOpts = storeOpts
 		return nil
 	}
 }
@@ -331,13 +336,14 @@ func WithWakuSwap(mode int, disconnectThreshold, paymentThreshold int) WakuNodeO
 
 // WithWakuStoreAndRetentionPolicy enables the Waku V2 Store protocol, storing them in an optional message provider
 // applying an specific retention policy
-func WithWakuStoreAndRetentionPolicy(shouldResume bool, maxDuration time.Duration, maxMessages int) WakuNodeOption {
+func WithWakuStoreAndRetentionPolicy(shouldResume bool, maxDuration time.Duration, maxMessages int, storeOpts ...store.Option) WakuNodeOption {
 	return func(params *WakuNodeParameters) error {
 		params.enableStore = true
 		params.storeMsgs = true
 		params.shouldResume = shouldResume
 		params.maxDuration = maxDuration
 		params.maxMessages = maxMessages
+		params.storeOpts = storeOpts
 		return nil
 	}
 }
@@ -381,6 +387,17 @@ func WithConnectionStatusChannel(connStatus chan ConnStatus) WakuNodeOption {
 	}
 }
 
+// WithResumeStatusChannel is a WakuNodeOption used to set a channel where the
+// progress of the automatic history resume will be pushed to. A status is sent
+// each time the history of a pubsub topic is retrieved, or fails to be retrieved.
+// Statuses are dropped when the channel is full, so it should be buffered
+func WithResumeStatusChannel(resumeStatus chan ResumeStatus) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.resumeStatusC = resumeStatus
+		return nil
+	}
+}
+
 // WithWebsockets is a WakuNodeOption used to enable websockets support
 func WithWebsockets(address string, port int) WakuNodeOption {
 	return func(params *WakuNodeParameters) error {
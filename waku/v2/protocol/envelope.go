@@ -1,14 +1,11 @@
 package protocol
 
 import (
-	"crypto/sha256"
-
 	"github.com/status-im/go-waku/waku/v2/protocol/pb"
 )
 
 // Envelope contains information about the pubsub topic of a WakuMessage
-// and a hash used to identify a message based on the bytes of a WakuMessage
-// protobuffer
+// and the canonical hash used to identify a message published on a pubsub topic
 type Envelope struct {
 	msg   *pb.WakuMessage
 	size  int
@@ -18,17 +15,17 @@ type Envelope struct {
 
 // NewEnvelope creates a new Envelope that contains a WakuMessage
 // It's used as a way to know to which Pubsub topic belongs a WakuMessage
-// as well as generating a hash based on the bytes that compose the message
+// as well as generating the hash of the message, which is also the digest of its index
 func NewEnvelope(msg *pb.WakuMessage, receiverTime int64, pubSubTopic string) *Envelope {
 	data, _ := msg.Marshal()
-	hash := sha256.Sum256(append([]byte(msg.ContentTopic), msg.Payload...))
+	hash := pb.MessageHash(pubSubTopic, msg)
 
 	return &Envelope{
 		msg:  msg,
 		size: len(data),
-		hash: pb.Hash(data),
+		hash: hash,
 		index: &pb.Index{
-			Digest:       hash[:],
+			Digest:       hash,
 			ReceiverTime: receiverTime,
 			SenderTime:   msg.Timestamp,
 			PubsubTopic:  pubSubTopic,
@@ -46,7 +43,8 @@ func (e *Envelope) PubsubTopic() string {
 	return e.index.PubsubTopic
 }
 
-// Hash returns a 32 byte hash calculated from the WakuMessage bytes
+// Hash returns the 32 byte canonical hash of the WakuMessage and its pubsub topic.
+// See pb.MessageHash
 func (e *Envelope) Hash() []byte {
 	return e.hash
 }
@@ -206,7 +206,8 @@ func (wakuLP *WakuLightPush) Stop() {
 	wakuLP.started = false
 }
 
-// PublishToTopic is used to broadcast a WakuMessage to a pubsub topic via lightpush protocol
+// PublishToTopic is used to broadcast a WakuMessage to a pubsub topic via lightpush protocol.
+// It returns the hash of the message, as calculated by pb.MessageHash
 func (wakuLP *WakuLightPush) PublishToTopic(ctx context.Context, message *pb.WakuMessage, topic string, opts ...LightPushOption) ([]byte, error) {
 	if message == nil {
 		return nil, errors.New("message can't be null")
@@ -222,8 +223,7 @@ func (wakuLP *WakuLightPush) PublishToTopic(ctx context.Context, message *pb.Wak
 	}
 
 	if response.IsSuccess {
-		hash, _ := message.Hash()
-		return hash, nil
+		return pb.MessageHash(topic, message), nil
 	} else {
 		return nil, errors.New(response.Info)
 	}
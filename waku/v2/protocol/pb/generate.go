@@ -4,4 +4,5 @@ package pb
 //go:generate protoc -I. --gofast_out=. ./waku_lightpush.proto
 //go:generate protoc -I. --gofast_out=. ./waku_message.proto
 //go:generate protoc -I. --gofast_out=. ./waku_store.proto
+//go:generate protoc -I. --gofast_out=. ./waku_store_sync.proto
 //go:generate protoc -I. --gofast_out=. ./waku_swap.proto
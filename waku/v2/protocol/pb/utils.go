@@ -2,6 +2,7 @@ package pb
 
 import (
 	"crypto/sha256"
+	"encoding/binary"
 
 	proto "github.com/golang/protobuf/proto"
 )
@@ -21,3 +22,35 @@ func Hash(data []byte) []byte {
 	hash := sha256.Sum256(data)
 	return hash[:]
 }
+
+// MessageHash calculates the deterministic hash that identifies a WakuMessage published
+// on a pubsub topic. Unlike the hash of the serialized message, it covers the pubsub
+// topic, and it does not depend on how the message was encoded. It's computed using
+// sha2-256 over the pubsub topic, payload, content topic, version, timestamp and proof,
+// with every variable length field prefixed by its length as a 64 bit big endian integer
+func MessageHash(pubsubTopic string, msg *WakuMessage) []byte {
+	h := sha256.New()
+
+	writeField := func(data []byte) {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(data)))
+		h.Write(length[:])
+		h.Write(data)
+	}
+
+	writeField([]byte(pubsubTopic))
+	writeField(msg.Payload)
+	writeField([]byte(msg.ContentTopic))
+
+	var version [4]byte
+	binary.BigEndian.PutUint32(version[:], msg.Version)
+	h.Write(version[:])
+
+	var timestamp [8]byte
+	binary.BigEndian.PutUint64(timestamp[:], uint64(msg.Timestamp))
+	h.Write(timestamp[:])
+
+	writeField(msg.Proof)
+
+	return h.Sum(nil)
+}
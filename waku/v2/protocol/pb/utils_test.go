@@ -24,3 +24,33 @@ func TestEnvelopeHash(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, expected, result)
 }
+
+func TestMessageHash(t *testing.T) {
+	msg := &WakuMessage{
+		ContentTopic: "Test",
+		Payload:      []byte("Hello World"),
+		Timestamp:    123456789123456789,
+		Version:      1,
+	}
+
+	expected := []byte{0x25, 0x47, 0x46, 0x58, 0xce, 0x81, 0xa8, 0x6b, 0x97, 0x61, 0xc3, 0x80, 0xcd, 0xd7, 0xfa, 0x13, 0x92, 0x7f, 0xe0, 0xda, 0x8b, 0x63, 0xda, 0x6d, 0x93, 0x1d, 0x59, 0x85, 0xbd, 0xcd, 0x1f, 0x6}
+	hash := MessageHash("/waku/2/default-waku/proto", msg)
+	require.Equal(t, expected, hash)
+
+	// Unknown fields don't change the hash
+	msg.XXX_unrecognized = []byte{0xa8, 0x01, 0x01}
+	require.Equal(t, hash, MessageHash("/waku/2/default-waku/proto", msg))
+	msg.XXX_unrecognized = nil
+
+	// Every field, and the pubsub topic, are covered by the hash
+	require.NotEqual(t, hash, MessageHash("/waku/2/other", msg))
+	variations := []*WakuMessage{
+		{ContentTopic: "Test", Payload: []byte("Hello World"), Timestamp: 123456789123456790, Version: 1},
+		{ContentTopic: "Test", Payload: []byte("Hello World"), Timestamp: 123456789123456789, Version: 0},
+		{ContentTopic: "Test", Payload: []byte("Hello World"), Timestamp: 123456789123456789, Version: 1, Proof: []byte{1}},
+		{ContentTopic: "TestHello", Payload: []byte(" World"), Timestamp: 123456789123456789, Version: 1},
+	}
+	for _, v := range variations {
+		require.NotEqual(t, hash, MessageHash("/waku/2/default-waku/proto", v))
+	}
+}
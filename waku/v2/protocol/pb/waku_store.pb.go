@@ -52,16 +52,40 @@ type HistoryResponse_Error int32
 const (
 	HistoryResponse_NONE           HistoryResponse_Error = 0
 	HistoryResponse_INVALID_CURSOR HistoryResponse_Error = 1
+	// the requester owes too much to the store node, which runs swap in hard mode
+	HistoryResponse_PAYMENT_REQUIRED HistoryResponse_Error = 402
+	// the query requests topics that are not archived by the store node
+	HistoryResponse_TOPIC_NOT_SERVED HistoryResponse_Error = 404
+	// the query exceeds the limits of the store node
+	HistoryResponse_QUERY_TOO_LARGE HistoryResponse_Error = 413
+	// the requester sent too many queries
+	HistoryResponse_TOO_MANY_REQUESTS HistoryResponse_Error = 429
+	// the store node failed to process the query
+	HistoryResponse_INTERNAL HistoryResponse_Error = 500
+	// the store node is unable to serve queries at the moment
+	HistoryResponse_SERVICE_UNAVAILABLE HistoryResponse_Error = 503
 )
 
 var HistoryResponse_Error_name = map[int32]string{
-	0: "NONE",
-	1: "INVALID_CURSOR",
+	0:   "NONE",
+	1:   "INVALID_CURSOR",
+	402: "PAYMENT_REQUIRED",
+	404: "TOPIC_NOT_SERVED",
+	413: "QUERY_TOO_LARGE",
+	429: "TOO_MANY_REQUESTS",
+	500: "INTERNAL",
+	503: "SERVICE_UNAVAILABLE",
 }
 
 var HistoryResponse_Error_value = map[string]int32{
-	"NONE":           0,
-	"INVALID_CURSOR": 1,
+	"NONE":                0,
+	"INVALID_CURSOR":      1,
+	"PAYMENT_REQUIRED":    402,
+	"TOPIC_NOT_SERVED":    404,
+	"QUERY_TOO_LARGE":     413,
+	"TOO_MANY_REQUESTS":   429,
+	"INTERNAL":            500,
+	"SERVICE_UNAVAILABLE": 503,
 }
 
 func (x HistoryResponse_Error) String() string {
@@ -69,7 +93,7 @@ func (x HistoryResponse_Error) String() string {
 }
 
 func (HistoryResponse_Error) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_ca6891f77a46e680, []int{4, 0}
+	return fileDescriptor_ca6891f77a46e680, []int{7, 0}
 }
 
 type Index struct {
@@ -254,14 +278,20 @@ func (m *ContentFilter) GetContentTopic() string {
 }
 
 type HistoryQuery struct {
-	PubsubTopic          string           `protobuf:"bytes,2,opt,name=pubsubTopic,proto3" json:"pubsubTopic,omitempty"`
-	ContentFilters       []*ContentFilter `protobuf:"bytes,3,rep,name=contentFilters,proto3" json:"contentFilters,omitempty"`
-	PagingInfo           *PagingInfo      `protobuf:"bytes,4,opt,name=pagingInfo,proto3" json:"pagingInfo,omitempty"`
-	StartTime            int64            `protobuf:"zigzag64,5,opt,name=startTime,proto3" json:"startTime,omitempty"`
-	EndTime              int64            `protobuf:"zigzag64,6,opt,name=endTime,proto3" json:"endTime,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
-	XXX_unrecognized     []byte           `json:"-"`
-	XXX_sizecache        int32            `json:"-"`
+	PubsubTopic    string           `protobuf:"bytes,2,opt,name=pubsubTopic,proto3" json:"pubsubTopic,omitempty"`
+	ContentFilters []*ContentFilter `protobuf:"bytes,3,rep,name=contentFilters,proto3" json:"contentFilters,omitempty"`
+	PagingInfo     *PagingInfo      `protobuf:"bytes,4,opt,name=pagingInfo,proto3" json:"pagingInfo,omitempty"`
+	StartTime      int64            `protobuf:"zigzag64,5,opt,name=startTime,proto3" json:"startTime,omitempty"`
+	EndTime        int64            `protobuf:"zigzag64,6,opt,name=endTime,proto3" json:"endTime,omitempty"`
+	// hashes of the messages to look up. When set, the rest of the query is ignored
+	MessageHashes [][]byte `protobuf:"bytes,7,rep,name=messageHashes,proto3" json:"messageHashes,omitempty"`
+	// only return whether the messages of messageHashes are stored
+	PresenceOnly bool `protobuf:"varint,8,opt,name=presenceOnly,proto3" json:"presenceOnly,omitempty"`
+	// only return statistics of the messages that match the query. The paging info is ignored
+	StatsOnly            bool     `protobuf:"varint,9,opt,name=statsOnly,proto3" json:"statsOnly,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *HistoryQuery) Reset()         { *m = HistoryQuery{} }
@@ -332,21 +362,254 @@ func (m *HistoryQuery) GetEndTime() int64 {
 	return 0
 }
 
+func (m *HistoryQuery) GetMessageHashes() [][]byte {
+	if m != nil {
+		return m.MessageHashes
+	}
+	return nil
+}
+
+func (m *HistoryQuery) GetPresenceOnly() bool {
+	if m != nil {
+		return m.PresenceOnly
+	}
+	return false
+}
+
+func (m *HistoryQuery) GetStatsOnly() bool {
+	if m != nil {
+		return m.StatsOnly
+	}
+	return false
+}
+
+type StoredWakuMessage struct {
+	Message              *WakuMessage `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	PubsubTopic          string       `protobuf:"bytes,2,opt,name=pubsubTopic,proto3" json:"pubsubTopic,omitempty"`
+	ReceiverTime         int64        `protobuf:"zigzag64,3,opt,name=receiverTime,proto3" json:"receiverTime,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *StoredWakuMessage) Reset()         { *m = StoredWakuMessage{} }
+func (m *StoredWakuMessage) String() string { return proto.CompactTextString(m) }
+func (*StoredWakuMessage) ProtoMessage()    {}
+func (*StoredWakuMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ca6891f77a46e680, []int{4}
+}
+func (m *StoredWakuMessage) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *StoredWakuMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_StoredWakuMessage.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *StoredWakuMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StoredWakuMessage.Merge(m, src)
+}
+func (m *StoredWakuMessage) XXX_Size() int {
+	return m.Size()
+}
+func (m *StoredWakuMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_StoredWakuMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StoredWakuMessage proto.InternalMessageInfo
+
+func (m *StoredWakuMessage) GetMessage() *WakuMessage {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+func (m *StoredWakuMessage) GetPubsubTopic() string {
+	if m != nil {
+		return m.PubsubTopic
+	}
+	return ""
+}
+
+func (m *StoredWakuMessage) GetReceiverTime() int64 {
+	if m != nil {
+		return m.ReceiverTime
+	}
+	return 0
+}
+
+type ContentTopicStats struct {
+	ContentTopic         string   `protobuf:"bytes,1,opt,name=contentTopic,proto3" json:"contentTopic,omitempty"`
+	Count                uint64   `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	OldestTimestamp      int64    `protobuf:"zigzag64,3,opt,name=oldestTimestamp,proto3" json:"oldestTimestamp,omitempty"`
+	NewestTimestamp      int64    `protobuf:"zigzag64,4,opt,name=newestTimestamp,proto3" json:"newestTimestamp,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ContentTopicStats) Reset()         { *m = ContentTopicStats{} }
+func (m *ContentTopicStats) String() string { return proto.CompactTextString(m) }
+func (*ContentTopicStats) ProtoMessage()    {}
+func (*ContentTopicStats) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ca6891f77a46e680, []int{5}
+}
+func (m *ContentTopicStats) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ContentTopicStats) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ContentTopicStats.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ContentTopicStats) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ContentTopicStats.Merge(m, src)
+}
+func (m *ContentTopicStats) XXX_Size() int {
+	return m.Size()
+}
+func (m *ContentTopicStats) XXX_DiscardUnknown() {
+	xxx_messageInfo_ContentTopicStats.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ContentTopicStats proto.InternalMessageInfo
+
+func (m *ContentTopicStats) GetContentTopic() string {
+	if m != nil {
+		return m.ContentTopic
+	}
+	return ""
+}
+
+func (m *ContentTopicStats) GetCount() uint64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *ContentTopicStats) GetOldestTimestamp() int64 {
+	if m != nil {
+		return m.OldestTimestamp
+	}
+	return 0
+}
+
+func (m *ContentTopicStats) GetNewestTimestamp() int64 {
+	if m != nil {
+		return m.NewestTimestamp
+	}
+	return 0
+}
+
+// statistics of the messages that match a query. Timestamps are sender timestamps
+type HistoryStats struct {
+	Count                uint64               `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	OldestTimestamp      int64                `protobuf:"zigzag64,2,opt,name=oldestTimestamp,proto3" json:"oldestTimestamp,omitempty"`
+	NewestTimestamp      int64                `protobuf:"zigzag64,3,opt,name=newestTimestamp,proto3" json:"newestTimestamp,omitempty"`
+	ContentTopics        []*ContentTopicStats `protobuf:"bytes,4,rep,name=contentTopics,proto3" json:"contentTopics,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *HistoryStats) Reset()         { *m = HistoryStats{} }
+func (m *HistoryStats) String() string { return proto.CompactTextString(m) }
+func (*HistoryStats) ProtoMessage()    {}
+func (*HistoryStats) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ca6891f77a46e680, []int{6}
+}
+func (m *HistoryStats) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *HistoryStats) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_HistoryStats.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *HistoryStats) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HistoryStats.Merge(m, src)
+}
+func (m *HistoryStats) XXX_Size() int {
+	return m.Size()
+}
+func (m *HistoryStats) XXX_DiscardUnknown() {
+	xxx_messageInfo_HistoryStats.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HistoryStats proto.InternalMessageInfo
+
+func (m *HistoryStats) GetCount() uint64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *HistoryStats) GetOldestTimestamp() int64 {
+	if m != nil {
+		return m.OldestTimestamp
+	}
+	return 0
+}
+
+func (m *HistoryStats) GetNewestTimestamp() int64 {
+	if m != nil {
+		return m.NewestTimestamp
+	}
+	return 0
+}
+
+func (m *HistoryStats) GetContentTopics() []*ContentTopicStats {
+	if m != nil {
+		return m.ContentTopics
+	}
+	return nil
+}
+
 type HistoryResponse struct {
 	// the first field is reserved for future use
-	Messages             []*WakuMessage        `protobuf:"bytes,2,rep,name=messages,proto3" json:"messages,omitempty"`
-	PagingInfo           *PagingInfo           `protobuf:"bytes,3,opt,name=pagingInfo,proto3" json:"pagingInfo,omitempty"`
-	Error                HistoryResponse_Error `protobuf:"varint,4,opt,name=error,proto3,enum=pb.HistoryResponse_Error" json:"error,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
-	XXX_unrecognized     []byte                `json:"-"`
-	XXX_sizecache        int32                 `json:"-"`
+	Messages   []*WakuMessage        `protobuf:"bytes,2,rep,name=messages,proto3" json:"messages,omitempty"`
+	PagingInfo *PagingInfo           `protobuf:"bytes,3,opt,name=pagingInfo,proto3" json:"pagingInfo,omitempty"`
+	Error      HistoryResponse_Error `protobuf:"varint,4,opt,name=error,proto3,enum=pb.HistoryResponse_Error" json:"error,omitempty"`
+	// messages including their metadata, used instead of `messages` since 2.0.0-beta5
+	StoredMessages []*StoredWakuMessage `protobuf:"bytes,5,rep,name=storedMessages,proto3" json:"storedMessages,omitempty"`
+	// bitmap in which the bit i, in LSB 0 order, is set if the message of messageHashes[i] is stored
+	Presence []byte `protobuf:"bytes,6,opt,name=presence,proto3" json:"presence,omitempty"`
+	// statistics returned instead of the messages when statsOnly is set
+	Stats                *HistoryStats `protobuf:"bytes,7,opt,name=stats,proto3" json:"stats,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
 }
 
 func (m *HistoryResponse) Reset()         { *m = HistoryResponse{} }
 func (m *HistoryResponse) String() string { return proto.CompactTextString(m) }
 func (*HistoryResponse) ProtoMessage()    {}
 func (*HistoryResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ca6891f77a46e680, []int{4}
+	return fileDescriptor_ca6891f77a46e680, []int{7}
 }
 func (m *HistoryResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -396,6 +659,27 @@ func (m *HistoryResponse) GetError() HistoryResponse_Error {
 	return HistoryResponse_NONE
 }
 
+func (m *HistoryResponse) GetStoredMessages() []*StoredWakuMessage {
+	if m != nil {
+		return m.StoredMessages
+	}
+	return nil
+}
+
+func (m *HistoryResponse) GetPresence() []byte {
+	if m != nil {
+		return m.Presence
+	}
+	return nil
+}
+
+func (m *HistoryResponse) GetStats() *HistoryStats {
+	if m != nil {
+		return m.Stats
+	}
+	return nil
+}
+
 type HistoryRPC struct {
 	RequestId            string           `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
 	Query                *HistoryQuery    `protobuf:"bytes,2,opt,name=query,proto3" json:"query,omitempty"`
@@ -409,7 +693,7 @@ func (m *HistoryRPC) Reset()         { *m = HistoryRPC{} }
 func (m *HistoryRPC) String() string { return proto.CompactTextString(m) }
 func (*HistoryRPC) ProtoMessage()    {}
 func (*HistoryRPC) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ca6891f77a46e680, []int{5}
+	return fileDescriptor_ca6891f77a46e680, []int{8}
 }
 func (m *HistoryRPC) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -466,6 +750,9 @@ func init() {
 	proto.RegisterType((*PagingInfo)(nil), "pb.PagingInfo")
 	proto.RegisterType((*ContentFilter)(nil), "pb.ContentFilter")
 	proto.RegisterType((*HistoryQuery)(nil), "pb.HistoryQuery")
+	proto.RegisterType((*StoredWakuMessage)(nil), "pb.StoredWakuMessage")
+	proto.RegisterType((*ContentTopicStats)(nil), "pb.ContentTopicStats")
+	proto.RegisterType((*HistoryStats)(nil), "pb.HistoryStats")
 	proto.RegisterType((*HistoryResponse)(nil), "pb.HistoryResponse")
 	proto.RegisterType((*HistoryRPC)(nil), "pb.HistoryRPC")
 }
@@ -473,41 +760,62 @@ func init() {
 func init() { proto.RegisterFile("waku_store.proto", fileDescriptor_ca6891f77a46e680) }
 
 var fileDescriptor_ca6891f77a46e680 = []byte{
-	// 543 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x93, 0xdf, 0x6e, 0xd3, 0x4c,
-	0x10, 0xc5, 0xbb, 0x49, 0x93, 0xc6, 0x93, 0x7c, 0xa9, 0xbf, 0x41, 0x42, 0xa6, 0x82, 0x28, 0x58,
-	0xa2, 0x8a, 0x84, 0xe4, 0x4a, 0xa9, 0x84, 0xc4, 0x65, 0x9a, 0xb4, 0x22, 0x02, 0x92, 0xb2, 0x2d,
-	0xf4, 0x32, 0x72, 0xec, 0x21, 0x5a, 0x95, 0xda, 0xee, 0xae, 0x0d, 0x94, 0x6b, 0x78, 0x07, 0xde,
-	0x81, 0xf7, 0x40, 0x5c, 0x22, 0xf1, 0x02, 0x28, 0xbc, 0x08, 0xf2, 0x66, 0xf3, 0x17, 0x24, 0x2e,
-	0xe7, 0xcc, 0x91, 0xe7, 0x77, 0x66, 0xd6, 0x60, 0xbf, 0xf3, 0x2f, 0xb3, 0x91, 0x4a, 0x63, 0x49,
-	0x5e, 0x22, 0xe3, 0x34, 0xc6, 0x42, 0x32, 0xde, 0x43, 0xad, 0x5e, 0x91, 0x52, 0xfe, 0xc4, 0xe8,
-	0xee, 0x27, 0x06, 0xa5, 0x7e, 0x14, 0xd2, 0x7b, 0xbc, 0x0d, 0xe5, 0x50, 0x4c, 0x48, 0xa5, 0x0e,
-	0x6b, 0xb2, 0x56, 0x8d, 0x9b, 0x0a, 0x5d, 0xa8, 0x49, 0x0a, 0x48, 0xbc, 0x25, 0x79, 0x2e, 0xae,
-	0xc8, 0x29, 0x34, 0x59, 0x0b, 0xf9, 0x9a, 0x86, 0x0d, 0x00, 0x45, 0x51, 0x68, 0x1c, 0x45, 0xed,
-	0x58, 0x51, 0xb0, 0x09, 0xd5, 0x24, 0x1b, 0xab, 0x6c, 0x7c, 0x1e, 0x27, 0x22, 0x70, 0xb6, 0x9b,
-	0xac, 0x65, 0xf1, 0x55, 0xc9, 0xfd, 0xc2, 0x00, 0x4e, 0xfd, 0x89, 0x88, 0x26, 0xfd, 0xe8, 0x75,
-	0x8c, 0x7b, 0x50, 0x49, 0xfc, 0x09, 0x9d, 0x89, 0x0f, 0xa4, 0x71, 0xb6, 0xf9, 0xa2, 0xc6, 0xfb,
-	0x50, 0x0e, 0x32, 0xa9, 0x62, 0xa9, 0x51, 0xaa, 0x6d, 0xcb, 0x4b, 0xc6, 0x9e, 0xce, 0xc0, 0x4d,
-	0x03, 0x1f, 0x81, 0x15, 0x0a, 0x49, 0x41, 0x2a, 0xe2, 0x48, 0xe3, 0xd4, 0xdb, 0x4e, 0xee, 0x5a,
-	0x4e, 0xf0, 0x7a, 0xf3, 0x3e, 0x5f, 0x5a, 0xdd, 0x7d, 0xb0, 0x16, 0x3a, 0xd6, 0xa0, 0x72, 0xd4,
-	0xe9, 0x3e, 0xbd, 0xe8, 0xf0, 0x9e, 0xbd, 0x85, 0x55, 0xd8, 0x39, 0x19, 0x72, 0x5d, 0x30, 0xf7,
-	0x10, 0xfe, 0xeb, 0xc6, 0x51, 0x4a, 0x51, 0x7a, 0x22, 0xde, 0xa4, 0x24, 0xf3, 0x25, 0x05, 0x33,
-	0x61, 0x96, 0x90, 0xe9, 0x84, 0x6b, 0x9a, 0xfb, 0x83, 0x41, 0xed, 0x89, 0xc8, 0x8f, 0x72, 0xf3,
-	0x22, 0x23, 0x79, 0xb3, 0xb9, 0x95, 0xc2, 0x1f, 0x5b, 0xc1, 0xc7, 0x50, 0x0f, 0x56, 0xe7, 0x28,
-	0xa7, 0xd8, 0x2c, 0xb6, 0xaa, 0xed, 0xff, 0xf3, 0x30, 0x6b, 0x04, 0x7c, 0xc3, 0x88, 0x1e, 0x40,
-	0xb2, 0x48, 0xab, 0x37, 0x5e, 0x6d, 0xd7, 0xd7, 0x77, 0xc0, 0x57, 0x1c, 0x78, 0x17, 0x2c, 0x95,
-	0xfa, 0x32, 0xd5, 0x17, 0x2c, 0xe9, 0x0b, 0x2e, 0x05, 0x74, 0x60, 0x87, 0xa2, 0x50, 0xf7, 0xca,
-	0xba, 0x37, 0x2f, 0xdd, 0xaf, 0x0c, 0x76, 0x4d, 0x2a, 0x4e, 0x2a, 0x89, 0x23, 0x45, 0xf8, 0x10,
-	0x2a, 0xe6, 0x95, 0x29, 0xa7, 0xa0, 0x81, 0x77, 0xf3, 0xc9, 0x17, 0xfe, 0x65, 0xf6, 0x7c, 0xa6,
-	0xf3, 0x85, 0x61, 0x03, 0xb4, 0xf8, 0x4f, 0xd0, 0x03, 0x28, 0x91, 0x94, 0xb1, 0xd4, 0x99, 0xea,
-	0xed, 0x3b, 0xb9, 0x75, 0x03, 0xc0, 0x3b, 0xce, 0x0d, 0x7c, 0xe6, 0x73, 0x1f, 0x40, 0x49, 0xd7,
-	0x58, 0x81, 0xed, 0xc1, 0x70, 0x70, 0x6c, 0x6f, 0x21, 0x42, 0xbd, 0x3f, 0x78, 0xd5, 0x79, 0xd6,
-	0xef, 0x8d, 0xba, 0x2f, 0xf9, 0xd9, 0x90, 0xdb, 0xcc, 0xfd, 0xc8, 0x00, 0xe6, 0xdf, 0x39, 0xed,
-	0xe2, 0x3d, 0x00, 0x49, 0xd7, 0x19, 0xa9, 0x74, 0x24, 0x42, 0x73, 0x4f, 0xcb, 0x28, 0xfd, 0x10,
-	0xf7, 0xa1, 0x74, 0x9d, 0x1f, 0xd1, 0xbc, 0x41, 0x7b, 0x85, 0x42, 0x1f, 0x97, 0xcf, 0xda, 0x78,
-	0x00, 0x15, 0x69, 0xa8, 0x4c, 0xb6, 0x5b, 0x7f, 0x01, 0xe6, 0x0b, 0xd3, 0x91, 0xfd, 0x6d, 0xda,
-	0x60, 0xdf, 0xa7, 0x0d, 0xf6, 0x73, 0xda, 0x60, 0x9f, 0x7f, 0x35, 0xb6, 0xc6, 0x65, 0xfd, 0xa7,
-	0x1e, 0xfe, 0x0e, 0x00, 0x00, 0xff, 0xff, 0x33, 0xe0, 0xf4, 0x43, 0xd5, 0x03, 0x00, 0x00,
+	// 871 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x55, 0x4d, 0x6f, 0xe3, 0x54,
+	0x14, 0xed, 0x8b, 0x93, 0x26, 0xb9, 0x49, 0x53, 0xf7, 0xce, 0x87, 0xcc, 0x08, 0xa2, 0x60, 0xa1,
+	0x51, 0x10, 0x52, 0x46, 0xca, 0x48, 0x48, 0x08, 0xb1, 0x70, 0x13, 0x0f, 0x63, 0x91, 0x3a, 0xed,
+	0x4b, 0xda, 0x51, 0x57, 0x56, 0x12, 0x3f, 0x82, 0x35, 0xad, 0xed, 0x79, 0xcf, 0x61, 0x28, 0x6b,
+	0xf8, 0x03, 0x88, 0x05, 0x1b, 0x16, 0x6c, 0x41, 0x2c, 0x59, 0xf1, 0x07, 0xd8, 0xc1, 0x4f, 0x40,
+	0xe5, 0x2f, 0x20, 0xb1, 0x45, 0xef, 0xd9, 0x49, 0x9c, 0xb4, 0xd2, 0x74, 0x79, 0xcf, 0x3d, 0xf2,
+	0x3d, 0xf7, 0xdc, 0x63, 0x1b, 0xf4, 0xd7, 0x93, 0x97, 0x0b, 0x4f, 0x24, 0x11, 0x67, 0x9d, 0x98,
+	0x47, 0x49, 0x84, 0x85, 0x78, 0xfa, 0x08, 0x15, 0x7a, 0xc9, 0x84, 0x98, 0xcc, 0x33, 0xdc, 0xfc,
+	0x96, 0x40, 0xc9, 0x09, 0x7d, 0xf6, 0x15, 0x3e, 0x84, 0x5d, 0x3f, 0x98, 0x33, 0x91, 0x18, 0xa4,
+	0x45, 0xda, 0x75, 0x9a, 0x55, 0x68, 0x42, 0x9d, 0xb3, 0x19, 0x0b, 0xbe, 0x64, 0x7c, 0x1c, 0x5c,
+	0x32, 0xa3, 0xd0, 0x22, 0x6d, 0xa4, 0x1b, 0x18, 0x36, 0x01, 0x04, 0x0b, 0xfd, 0x8c, 0xa1, 0x29,
+	0x46, 0x0e, 0xc1, 0x16, 0xd4, 0xe2, 0xc5, 0x54, 0x2c, 0xa6, 0xe3, 0x28, 0x0e, 0x66, 0x46, 0xb1,
+	0x45, 0xda, 0x55, 0x9a, 0x87, 0xcc, 0x5f, 0x08, 0xc0, 0xf1, 0x64, 0x1e, 0x84, 0x73, 0x27, 0xfc,
+	0x3c, 0xc2, 0x47, 0x50, 0x89, 0x27, 0x73, 0x36, 0x0a, 0xbe, 0x66, 0x4a, 0x4e, 0x91, 0xae, 0x6a,
+	0x7c, 0x17, 0x76, 0x67, 0x0b, 0x2e, 0x22, 0xae, 0xa4, 0xd4, 0xba, 0xd5, 0x4e, 0x3c, 0xed, 0xa8,
+	0x1d, 0x68, 0xd6, 0xc0, 0x0f, 0xa1, 0xea, 0x07, 0x9c, 0xcd, 0x92, 0x20, 0x0a, 0x95, 0x9c, 0x46,
+	0xd7, 0x90, 0xac, 0xf5, 0x84, 0x4e, 0x7f, 0xd9, 0xa7, 0x6b, 0xaa, 0xf9, 0x18, 0xaa, 0x2b, 0x1c,
+	0xeb, 0x50, 0x39, 0xb4, 0x7a, 0x9f, 0xbd, 0xb0, 0x68, 0x5f, 0xdf, 0xc1, 0x1a, 0x94, 0x9f, 0x0d,
+	0xa9, 0x2a, 0x88, 0xf9, 0x14, 0xf6, 0x7a, 0x51, 0x98, 0xb0, 0x30, 0x79, 0x16, 0x5c, 0x24, 0x8c,
+	0x4b, 0x93, 0x66, 0x29, 0x90, 0x6e, 0x48, 0xd4, 0x86, 0x1b, 0x98, 0xf9, 0x7b, 0x01, 0xea, 0xcf,
+	0x03, 0x79, 0x94, 0xab, 0x93, 0x05, 0xe3, 0x57, 0xdb, 0xae, 0x14, 0x6e, 0xb8, 0x82, 0x1f, 0x41,
+	0x63, 0x96, 0x9f, 0x23, 0x0c, 0xad, 0xa5, 0xb5, 0x6b, 0xdd, 0x03, 0xb9, 0xcc, 0x86, 0x02, 0xba,
+	0x45, 0xc4, 0x0e, 0x40, 0xbc, 0xda, 0x56, 0x39, 0x5e, 0xeb, 0x36, 0x36, 0x3d, 0xa0, 0x39, 0x06,
+	0xbe, 0x0d, 0x55, 0x91, 0x4c, 0x78, 0xa2, 0x2e, 0x58, 0x52, 0x17, 0x5c, 0x03, 0x68, 0x40, 0x99,
+	0x85, 0xbe, 0xea, 0xed, 0xaa, 0xde, 0xb2, 0xc4, 0xf7, 0x60, 0x2f, 0x4b, 0xd4, 0xf3, 0x89, 0xf8,
+	0x82, 0x09, 0xa3, 0xdc, 0xd2, 0xda, 0x75, 0xba, 0x09, 0x4a, 0x7f, 0x62, 0xce, 0x04, 0x0b, 0x67,
+	0x6c, 0x18, 0x5e, 0x5c, 0x19, 0x95, 0x16, 0x69, 0x57, 0xe8, 0x06, 0x96, 0x29, 0x48, 0x84, 0x22,
+	0x54, 0x15, 0x61, 0x0d, 0x98, 0xdf, 0x10, 0x38, 0x18, 0xc9, 0x40, 0xfb, 0x2f, 0x26, 0x2f, 0x17,
+	0x47, 0xe9, 0xd3, 0xf1, 0x7d, 0x28, 0x67, 0x83, 0x94, 0xe5, 0xb5, 0xee, 0xbe, 0x5c, 0x31, 0xc7,
+	0xa0, 0xcb, 0xfe, 0x1d, 0xdc, 0xde, 0x4e, 0xba, 0x76, 0x33, 0xe9, 0xe6, 0x4f, 0x04, 0x0e, 0x7a,
+	0xb9, 0xab, 0x8e, 0xa4, 0xc0, 0xbb, 0x9c, 0x1f, 0xef, 0x43, 0x69, 0x16, 0x2d, 0xc2, 0x44, 0x4d,
+	0x2e, 0xd2, 0xb4, 0xc0, 0x36, 0xec, 0x47, 0x17, 0x3e, 0x13, 0xca, 0x66, 0x91, 0x4c, 0x2e, 0xe3,
+	0x6c, 0xec, 0x36, 0x2c, 0x99, 0x21, 0x7b, 0xbd, 0xc1, 0x2c, 0xa6, 0xcc, 0x2d, 0xd8, 0xfc, 0x8d,
+	0xac, 0x82, 0x96, 0xca, 0x5b, 0x8d, 0x26, 0x6f, 0x18, 0x5d, 0xb8, 0xf3, 0x68, 0xed, 0xd6, 0xd1,
+	0xf8, 0x31, 0xec, 0xe5, 0x97, 0x16, 0x46, 0x51, 0xe5, 0xf5, 0x41, 0x2e, 0xaf, 0x6b, 0xdb, 0xe8,
+	0x26, 0xd7, 0xfc, 0x53, 0x83, 0xfd, 0x4c, 0x37, 0x65, 0x22, 0x8e, 0x42, 0xc1, 0xf0, 0x03, 0xa8,
+	0x64, 0x07, 0x14, 0x46, 0xa1, 0xa5, 0xdd, 0x76, 0xe1, 0x15, 0x61, 0x2b, 0xf3, 0xda, 0x1b, 0x33,
+	0xff, 0x04, 0x4a, 0x8c, 0xf3, 0x88, 0x2b, 0x23, 0x1b, 0xdd, 0xb7, 0x24, 0x75, 0x4b, 0x40, 0xc7,
+	0x96, 0x04, 0x9a, 0xf2, 0xf0, 0x13, 0x68, 0x08, 0x95, 0xc1, 0xa3, 0xa5, 0xa6, 0xd2, 0x7a, 0xbf,
+	0x1b, 0xe9, 0xa4, 0x5b, 0x64, 0xf5, 0x55, 0xcb, 0x12, 0xaf, 0x5e, 0xa3, 0x3a, 0x5d, 0xd5, 0xf8,
+	0x18, 0x4a, 0x2a, 0xec, 0x46, 0x59, 0xc9, 0xd6, 0x73, 0x5a, 0x52, 0xb3, 0xd2, 0xb6, 0xf9, 0x33,
+	0x81, 0x92, 0xd2, 0x84, 0x15, 0x28, 0xba, 0x43, 0xd7, 0xd6, 0x77, 0x10, 0xa1, 0xe1, 0xb8, 0x67,
+	0xd6, 0xc0, 0xe9, 0x7b, 0xbd, 0x53, 0x3a, 0x1a, 0x52, 0x9d, 0xe0, 0x03, 0xd0, 0x8f, 0xad, 0xf3,
+	0x23, 0xdb, 0x1d, 0x7b, 0xd4, 0x3e, 0x39, 0x75, 0xa8, 0xdd, 0xd7, 0xbf, 0xd3, 0x24, 0x3c, 0x1e,
+	0x1e, 0x3b, 0x3d, 0xcf, 0x1d, 0x8e, 0xbd, 0x91, 0x4d, 0xcf, 0xec, 0xbe, 0xfe, 0xbd, 0x86, 0xf7,
+	0x61, 0xff, 0xe4, 0xd4, 0xa6, 0xe7, 0xde, 0x78, 0x38, 0xf4, 0x06, 0x16, 0xfd, 0xd4, 0xd6, 0x7f,
+	0xd4, 0xf0, 0x21, 0x1c, 0xc8, 0xfa, 0xc8, 0x72, 0xcf, 0xd5, 0x43, 0xec, 0xd1, 0x78, 0xa4, 0xff,
+	0xaa, 0xe1, 0x1e, 0x54, 0x1c, 0x77, 0x6c, 0x53, 0xd7, 0x1a, 0xe8, 0xff, 0x6a, 0x68, 0xc0, 0x3d,
+	0xf9, 0x24, 0xa7, 0x67, 0x7b, 0xa7, 0xae, 0x75, 0x66, 0x39, 0x03, 0xeb, 0x70, 0x60, 0xeb, 0xff,
+	0x69, 0xf2, 0xa5, 0x85, 0xa5, 0xa1, 0xc7, 0x3d, 0x7c, 0x07, 0x80, 0xb3, 0x57, 0x0b, 0x26, 0x12,
+	0x2f, 0xf0, 0xb3, 0x97, 0xa4, 0x9a, 0x21, 0x8e, 0x2f, 0x2d, 0x78, 0x25, 0x3f, 0x8c, 0x46, 0xe1,
+	0x86, 0x05, 0xea, 0x83, 0x49, 0xd3, 0x36, 0x3e, 0x81, 0x0a, 0xcf, 0xce, 0x93, 0x1d, 0xf9, 0xde,
+	0x2d, 0x97, 0xa3, 0x2b, 0xd2, 0xa1, 0xfe, 0xc7, 0x75, 0x93, 0xfc, 0x75, 0xdd, 0x24, 0x7f, 0x5f,
+	0x37, 0xc9, 0x0f, 0xff, 0x34, 0x77, 0xa6, 0xbb, 0xea, 0xef, 0xf7, 0xf4, 0xff, 0x01, 0x00, 0xb6,
+	0x4b, 0x3b, 0x90, 0x29, 0x07, 0x00, 0x00,
 }
 
 func (m *Index) Marshal() (dAtA []byte, err error) {
@@ -668,6 +976,35 @@ func (m *HistoryQuery) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.StatsOnly {
+		i--
+		if m.StatsOnly {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x48
+	}
+	if m.PresenceOnly {
+		i--
+		if m.PresenceOnly {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x40
+	}
+	if len(m.MessageHashes) > 0 {
+		for iNdEx := len(m.MessageHashes) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.MessageHashes[iNdEx])
+			copy(dAtA[i:], m.MessageHashes[iNdEx])
+			i = encodeVarintWakuStore(dAtA, i, uint64(len(m.MessageHashes[iNdEx])))
+			i--
+			dAtA[i] = 0x3a
+		}
+	}
 	if m.EndTime != 0 {
 		i = encodeVarintWakuStore(dAtA, i, uint64((uint64(m.EndTime)<<1)^uint64((m.EndTime>>63))))
 		i--
@@ -714,7 +1051,7 @@ func (m *HistoryQuery) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func (m *HistoryResponse) Marshal() (dAtA []byte, err error) {
+func (m *StoredWakuMessage) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -724,12 +1061,12 @@ func (m *HistoryResponse) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *HistoryResponse) MarshalTo(dAtA []byte) (int, error) {
+func (m *StoredWakuMessage) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *HistoryResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *StoredWakuMessage) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
@@ -738,14 +1075,21 @@ func (m *HistoryResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	if m.Error != 0 {
-		i = encodeVarintWakuStore(dAtA, i, uint64(m.Error))
+	if m.ReceiverTime != 0 {
+		i = encodeVarintWakuStore(dAtA, i, uint64((uint64(m.ReceiverTime)<<1)^uint64((m.ReceiverTime>>63))))
 		i--
-		dAtA[i] = 0x20
+		dAtA[i] = 0x18
 	}
-	if m.PagingInfo != nil {
+	if len(m.PubsubTopic) > 0 {
+		i -= len(m.PubsubTopic)
+		copy(dAtA[i:], m.PubsubTopic)
+		i = encodeVarintWakuStore(dAtA, i, uint64(len(m.PubsubTopic)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Message != nil {
 		{
-			size, err := m.PagingInfo.MarshalToSizedBuffer(dAtA[:i])
+			size, err := m.Message.MarshalToSizedBuffer(dAtA[:i])
 			if err != nil {
 				return 0, err
 			}
@@ -753,26 +1097,12 @@ func (m *HistoryResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 			i = encodeVarintWakuStore(dAtA, i, uint64(size))
 		}
 		i--
-		dAtA[i] = 0x1a
-	}
-	if len(m.Messages) > 0 {
-		for iNdEx := len(m.Messages) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Messages[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintWakuStore(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x12
-		}
+		dAtA[i] = 0xa
 	}
 	return len(dAtA) - i, nil
 }
 
-func (m *HistoryRPC) Marshal() (dAtA []byte, err error) {
+func (m *ContentTopicStats) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -782,12 +1112,12 @@ func (m *HistoryRPC) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *HistoryRPC) MarshalTo(dAtA []byte) (int, error) {
+func (m *ContentTopicStats) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *HistoryRPC) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *ContentTopicStats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
@@ -796,49 +1126,245 @@ func (m *HistoryRPC) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	if m.Response != nil {
-		{
-			size, err := m.Response.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintWakuStore(dAtA, i, uint64(size))
-		}
+	if m.NewestTimestamp != 0 {
+		i = encodeVarintWakuStore(dAtA, i, uint64((uint64(m.NewestTimestamp)<<1)^uint64((m.NewestTimestamp>>63))))
 		i--
-		dAtA[i] = 0x1a
+		dAtA[i] = 0x20
 	}
-	if m.Query != nil {
-		{
-			size, err := m.Query.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintWakuStore(dAtA, i, uint64(size))
-		}
+	if m.OldestTimestamp != 0 {
+		i = encodeVarintWakuStore(dAtA, i, uint64((uint64(m.OldestTimestamp)<<1)^uint64((m.OldestTimestamp>>63))))
 		i--
-		dAtA[i] = 0x12
+		dAtA[i] = 0x18
 	}
-	if len(m.RequestId) > 0 {
-		i -= len(m.RequestId)
-		copy(dAtA[i:], m.RequestId)
-		i = encodeVarintWakuStore(dAtA, i, uint64(len(m.RequestId)))
+	if m.Count != 0 {
+		i = encodeVarintWakuStore(dAtA, i, uint64(m.Count))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.ContentTopic) > 0 {
+		i -= len(m.ContentTopic)
+		copy(dAtA[i:], m.ContentTopic)
+		i = encodeVarintWakuStore(dAtA, i, uint64(len(m.ContentTopic)))
 		i--
 		dAtA[i] = 0xa
 	}
 	return len(dAtA) - i, nil
 }
 
-func encodeVarintWakuStore(dAtA []byte, offset int, v uint64) int {
-	offset -= sovWakuStore(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
-	}
-	dAtA[offset] = uint8(v)
+func (m *HistoryStats) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *HistoryStats) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *HistoryStats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.ContentTopics) > 0 {
+		for iNdEx := len(m.ContentTopics) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.ContentTopics[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintWakuStore(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x22
+		}
+	}
+	if m.NewestTimestamp != 0 {
+		i = encodeVarintWakuStore(dAtA, i, uint64((uint64(m.NewestTimestamp)<<1)^uint64((m.NewestTimestamp>>63))))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.OldestTimestamp != 0 {
+		i = encodeVarintWakuStore(dAtA, i, uint64((uint64(m.OldestTimestamp)<<1)^uint64((m.OldestTimestamp>>63))))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Count != 0 {
+		i = encodeVarintWakuStore(dAtA, i, uint64(m.Count))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *HistoryResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *HistoryResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *HistoryResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Stats != nil {
+		{
+			size, err := m.Stats.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintWakuStore(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x3a
+	}
+	if len(m.Presence) > 0 {
+		i -= len(m.Presence)
+		copy(dAtA[i:], m.Presence)
+		i = encodeVarintWakuStore(dAtA, i, uint64(len(m.Presence)))
+		i--
+		dAtA[i] = 0x32
+	}
+	if len(m.StoredMessages) > 0 {
+		for iNdEx := len(m.StoredMessages) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.StoredMessages[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintWakuStore(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x2a
+		}
+	}
+	if m.Error != 0 {
+		i = encodeVarintWakuStore(dAtA, i, uint64(m.Error))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.PagingInfo != nil {
+		{
+			size, err := m.PagingInfo.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintWakuStore(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Messages) > 0 {
+		for iNdEx := len(m.Messages) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Messages[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintWakuStore(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *HistoryRPC) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *HistoryRPC) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *HistoryRPC) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Response != nil {
+		{
+			size, err := m.Response.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintWakuStore(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.Query != nil {
+		{
+			size, err := m.Query.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintWakuStore(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.RequestId) > 0 {
+		i -= len(m.RequestId)
+		copy(dAtA[i:], m.RequestId)
+		i = encodeVarintWakuStore(dAtA, i, uint64(len(m.RequestId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintWakuStore(dAtA []byte, offset int, v uint64) int {
+	offset -= sovWakuStore(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
 	return base
 }
 func (m *Index) Size() (n int) {
@@ -931,6 +1457,93 @@ func (m *HistoryQuery) Size() (n int) {
 	if m.EndTime != 0 {
 		n += 1 + sozWakuStore(uint64(m.EndTime))
 	}
+	if len(m.MessageHashes) > 0 {
+		for _, b := range m.MessageHashes {
+			l = len(b)
+			n += 1 + l + sovWakuStore(uint64(l))
+		}
+	}
+	if m.PresenceOnly {
+		n += 2
+	}
+	if m.StatsOnly {
+		n += 2
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *StoredWakuMessage) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Message != nil {
+		l = m.Message.Size()
+		n += 1 + l + sovWakuStore(uint64(l))
+	}
+	l = len(m.PubsubTopic)
+	if l > 0 {
+		n += 1 + l + sovWakuStore(uint64(l))
+	}
+	if m.ReceiverTime != 0 {
+		n += 1 + sozWakuStore(uint64(m.ReceiverTime))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ContentTopicStats) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.ContentTopic)
+	if l > 0 {
+		n += 1 + l + sovWakuStore(uint64(l))
+	}
+	if m.Count != 0 {
+		n += 1 + sovWakuStore(uint64(m.Count))
+	}
+	if m.OldestTimestamp != 0 {
+		n += 1 + sozWakuStore(uint64(m.OldestTimestamp))
+	}
+	if m.NewestTimestamp != 0 {
+		n += 1 + sozWakuStore(uint64(m.NewestTimestamp))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *HistoryStats) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Count != 0 {
+		n += 1 + sovWakuStore(uint64(m.Count))
+	}
+	if m.OldestTimestamp != 0 {
+		n += 1 + sozWakuStore(uint64(m.OldestTimestamp))
+	}
+	if m.NewestTimestamp != 0 {
+		n += 1 + sozWakuStore(uint64(m.NewestTimestamp))
+	}
+	if len(m.ContentTopics) > 0 {
+		for _, e := range m.ContentTopics {
+			l = e.Size()
+			n += 1 + l + sovWakuStore(uint64(l))
+		}
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -949,50 +1562,431 @@ func (m *HistoryResponse) Size() (n int) {
 			n += 1 + l + sovWakuStore(uint64(l))
 		}
 	}
-	if m.PagingInfo != nil {
-		l = m.PagingInfo.Size()
-		n += 1 + l + sovWakuStore(uint64(l))
-	}
-	if m.Error != 0 {
-		n += 1 + sovWakuStore(uint64(m.Error))
-	}
-	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
-	}
-	return n
-}
+	if m.PagingInfo != nil {
+		l = m.PagingInfo.Size()
+		n += 1 + l + sovWakuStore(uint64(l))
+	}
+	if m.Error != 0 {
+		n += 1 + sovWakuStore(uint64(m.Error))
+	}
+	if len(m.StoredMessages) > 0 {
+		for _, e := range m.StoredMessages {
+			l = e.Size()
+			n += 1 + l + sovWakuStore(uint64(l))
+		}
+	}
+	l = len(m.Presence)
+	if l > 0 {
+		n += 1 + l + sovWakuStore(uint64(l))
+	}
+	if m.Stats != nil {
+		l = m.Stats.Size()
+		n += 1 + l + sovWakuStore(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *HistoryRPC) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.RequestId)
+	if l > 0 {
+		n += 1 + l + sovWakuStore(uint64(l))
+	}
+	if m.Query != nil {
+		l = m.Query.Size()
+		n += 1 + l + sovWakuStore(uint64(l))
+	}
+	if m.Response != nil {
+		l = m.Response.Size()
+		n += 1 + l + sovWakuStore(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func sovWakuStore(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozWakuStore(x uint64) (n int) {
+	return sovWakuStore(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *Index) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowWakuStore
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Index: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Index: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Digest", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWakuStore
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Digest = append(m.Digest[:0], dAtA[iNdEx:postIndex]...)
+			if m.Digest == nil {
+				m.Digest = []byte{}
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReceiverTime", wireType)
+			}
+			var v uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWakuStore
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			v = (v >> 1) ^ uint64((int64(v&1)<<63)>>63)
+			m.ReceiverTime = int64(v)
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SenderTime", wireType)
+			}
+			var v uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWakuStore
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			v = (v >> 1) ^ uint64((int64(v&1)<<63)>>63)
+			m.SenderTime = int64(v)
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PubsubTopic", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWakuStore
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.PubsubTopic = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipWakuStore(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PagingInfo) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowWakuStore
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PagingInfo: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PagingInfo: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PageSize", wireType)
+			}
+			m.PageSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWakuStore
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PageSize |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Cursor", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWakuStore
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Cursor == nil {
+				m.Cursor = &Index{}
+			}
+			if err := m.Cursor.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Direction", wireType)
+			}
+			m.Direction = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWakuStore
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Direction |= PagingInfo_Direction(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipWakuStore(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ContentFilter) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowWakuStore
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ContentFilter: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ContentFilter: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ContentTopic", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWakuStore
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ContentTopic = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipWakuStore(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
 
-func (m *HistoryRPC) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	l = len(m.RequestId)
-	if l > 0 {
-		n += 1 + l + sovWakuStore(uint64(l))
-	}
-	if m.Query != nil {
-		l = m.Query.Size()
-		n += 1 + l + sovWakuStore(uint64(l))
-	}
-	if m.Response != nil {
-		l = m.Response.Size()
-		n += 1 + l + sovWakuStore(uint64(l))
-	}
-	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	return n
-}
-
-func sovWakuStore(x uint64) (n int) {
-	return (math_bits.Len64(x|1) + 6) / 7
-}
-func sozWakuStore(x uint64) (n int) {
-	return sovWakuStore(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+	return nil
 }
-func (m *Index) Unmarshal(dAtA []byte) error {
+func (m *HistoryQuery) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1015,17 +2009,17 @@ func (m *Index) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Index: wiretype end group for non-group")
+			return fmt.Errorf("proto: HistoryQuery: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Index: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: HistoryQuery: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 1:
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Digest", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field PubsubTopic", wireType)
 			}
-			var byteLen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowWakuStore
@@ -1035,29 +2029,97 @@ func (m *Index) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthWakuStore
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthWakuStore
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Digest = append(m.Digest[:0], dAtA[iNdEx:postIndex]...)
-			if m.Digest == nil {
-				m.Digest = []byte{}
+			m.PubsubTopic = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ContentFilters", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWakuStore
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ContentFilters = append(m.ContentFilters, &ContentFilter{})
+			if err := m.ContentFilters[len(m.ContentFilters)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
 			iNdEx = postIndex
-		case 2:
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PagingInfo", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWakuStore
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.PagingInfo == nil {
+				m.PagingInfo = &PagingInfo{}
+			}
+			if err := m.PagingInfo.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ReceiverTime", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field StartTime", wireType)
 			}
 			var v uint64
 			for shift := uint(0); ; shift += 7 {
@@ -1075,10 +2137,10 @@ func (m *Index) Unmarshal(dAtA []byte) error {
 				}
 			}
 			v = (v >> 1) ^ uint64((int64(v&1)<<63)>>63)
-			m.ReceiverTime = int64(v)
-		case 3:
+			m.StartTime = int64(v)
+		case 6:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field SenderTime", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field EndTime", wireType)
 			}
 			var v uint64
 			for shift := uint(0); ; shift += 7 {
@@ -1096,12 +2158,12 @@ func (m *Index) Unmarshal(dAtA []byte) error {
 				}
 			}
 			v = (v >> 1) ^ uint64((int64(v&1)<<63)>>63)
-			m.SenderTime = int64(v)
-		case 4:
+			m.EndTime = int64(v)
+		case 7:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field PubsubTopic", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field MessageHashes", wireType)
 			}
-			var stringLen uint64
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowWakuStore
@@ -1111,24 +2173,64 @@ func (m *Index) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if byteLen < 0 {
 				return ErrInvalidLengthWakuStore
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + byteLen
 			if postIndex < 0 {
 				return ErrInvalidLengthWakuStore
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.PubsubTopic = string(dAtA[iNdEx:postIndex])
+			m.MessageHashes = append(m.MessageHashes, make([]byte, postIndex-iNdEx))
+			copy(m.MessageHashes[len(m.MessageHashes)-1], dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PresenceOnly", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWakuStore
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.PresenceOnly = bool(v != 0)
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StatsOnly", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWakuStore
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.StatsOnly = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipWakuStore(dAtA[iNdEx:])
@@ -1151,7 +2253,7 @@ func (m *Index) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *PagingInfo) Unmarshal(dAtA []byte) error {
+func (m *StoredWakuMessage) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1174,17 +2276,17 @@ func (m *PagingInfo) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: PagingInfo: wiretype end group for non-group")
+			return fmt.Errorf("proto: StoredWakuMessage: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: PagingInfo: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: StoredWakuMessage: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field PageSize", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Message", wireType)
 			}
-			m.PageSize = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowWakuStore
@@ -1194,16 +2296,33 @@ func (m *PagingInfo) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.PageSize |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if msglen < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Message == nil {
+				m.Message = &WakuMessage{}
+			}
+			if err := m.Message.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Cursor", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field PubsubTopic", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowWakuStore
@@ -1213,33 +2332,29 @@ func (m *PagingInfo) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthWakuStore
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthWakuStore
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Cursor == nil {
-				m.Cursor = &Index{}
-			}
-			if err := m.Cursor.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.PubsubTopic = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 3:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Direction", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ReceiverTime", wireType)
 			}
-			m.Direction = 0
+			var v uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowWakuStore
@@ -1249,11 +2364,13 @@ func (m *PagingInfo) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Direction |= PagingInfo_Direction(b&0x7F) << shift
+				v |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			v = (v >> 1) ^ uint64((int64(v&1)<<63)>>63)
+			m.ReceiverTime = int64(v)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipWakuStore(dAtA[iNdEx:])
@@ -1276,7 +2393,7 @@ func (m *PagingInfo) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ContentFilter) Unmarshal(dAtA []byte) error {
+func (m *ContentTopicStats) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1299,10 +2416,10 @@ func (m *ContentFilter) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ContentFilter: wiretype end group for non-group")
+			return fmt.Errorf("proto: ContentTopicStats: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ContentFilter: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ContentTopicStats: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
@@ -1337,6 +2454,67 @@ func (m *ContentFilter) Unmarshal(dAtA []byte) error {
 			}
 			m.ContentTopic = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Count", wireType)
+			}
+			m.Count = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWakuStore
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Count |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OldestTimestamp", wireType)
+			}
+			var v uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWakuStore
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			v = (v >> 1) ^ uint64((int64(v&1)<<63)>>63)
+			m.OldestTimestamp = int64(v)
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NewestTimestamp", wireType)
+			}
+			var v uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWakuStore
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			v = (v >> 1) ^ uint64((int64(v&1)<<63)>>63)
+			m.NewestTimestamp = int64(v)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipWakuStore(dAtA[iNdEx:])
@@ -1359,7 +2537,7 @@ func (m *ContentFilter) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *HistoryQuery) Unmarshal(dAtA []byte) error {
+func (m *HistoryStats) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1382,17 +2560,17 @@ func (m *HistoryQuery) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: HistoryQuery: wiretype end group for non-group")
+			return fmt.Errorf("proto: HistoryStats: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: HistoryQuery: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: HistoryStats: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field PubsubTopic", wireType)
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Count", wireType)
 			}
-			var stringLen uint64
+			m.Count = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowWakuStore
@@ -1402,29 +2580,37 @@ func (m *HistoryQuery) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.Count |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthWakuStore
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthWakuStore
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OldestTimestamp", wireType)
 			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+			var v uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWakuStore
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			m.PubsubTopic = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
+			v = (v >> 1) ^ uint64((int64(v&1)<<63)>>63)
+			m.OldestTimestamp = int64(v)
 		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ContentFilters", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NewestTimestamp", wireType)
 			}
-			var msglen int
+			var v uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowWakuStore
@@ -1434,29 +2620,16 @@ func (m *HistoryQuery) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				v |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthWakuStore
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthWakuStore
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.ContentFilters = append(m.ContentFilters, &ContentFilter{})
-			if err := m.ContentFilters[len(m.ContentFilters)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
+			v = (v >> 1) ^ uint64((int64(v&1)<<63)>>63)
+			m.NewestTimestamp = int64(v)
 		case 4:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field PagingInfo", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ContentTopics", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -1483,55 +2656,11 @@ func (m *HistoryQuery) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.PagingInfo == nil {
-				m.PagingInfo = &PagingInfo{}
-			}
-			if err := m.PagingInfo.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.ContentTopics = append(m.ContentTopics, &ContentTopicStats{})
+			if err := m.ContentTopics[len(m.ContentTopics)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 5:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field StartTime", wireType)
-			}
-			var v uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowWakuStore
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				v |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			v = (v >> 1) ^ uint64((int64(v&1)<<63)>>63)
-			m.StartTime = int64(v)
-		case 6:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field EndTime", wireType)
-			}
-			var v uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowWakuStore
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				v |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			v = (v >> 1) ^ uint64((int64(v&1)<<63)>>63)
-			m.EndTime = int64(v)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipWakuStore(dAtA[iNdEx:])
@@ -1672,6 +2801,110 @@ func (m *HistoryResponse) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StoredMessages", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWakuStore
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.StoredMessages = append(m.StoredMessages, &StoredWakuMessage{})
+			if err := m.StoredMessages[len(m.StoredMessages)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Presence", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWakuStore
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Presence = append(m.Presence[:0], dAtA[iNdEx:postIndex]...)
+			if m.Presence == nil {
+				m.Presence = []byte{}
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Stats", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWakuStore
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthWakuStore
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Stats == nil {
+				m.Stats = &HistoryStats{}
+			}
+			if err := m.Stats.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipWakuStore(dAtA[iNdEx:])
@@ -148,15 +148,73 @@ func (m *Handshake) GetBeneficiary() []byte {
 	return nil
 }
 
+// each stream of the swap protocol carries a single message. A handshake is answered
+// with the handshake of the peer, and a cheque is not answered
+type SwapRPC struct {
+	Handshake            *Handshake `protobuf:"bytes,1,opt,name=handshake,proto3" json:"handshake,omitempty"`
+	Cheque               *Cheque    `protobuf:"bytes,2,opt,name=cheque,proto3" json:"cheque,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *SwapRPC) Reset()         { *m = SwapRPC{} }
+func (m *SwapRPC) String() string { return proto.CompactTextString(m) }
+func (*SwapRPC) ProtoMessage()    {}
+func (*SwapRPC) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8ec987fcc28cf932, []int{2}
+}
+func (m *SwapRPC) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SwapRPC) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SwapRPC.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *SwapRPC) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SwapRPC.Merge(m, src)
+}
+func (m *SwapRPC) XXX_Size() int {
+	return m.Size()
+}
+func (m *SwapRPC) XXX_DiscardUnknown() {
+	xxx_messageInfo_SwapRPC.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SwapRPC proto.InternalMessageInfo
+
+func (m *SwapRPC) GetHandshake() *Handshake {
+	if m != nil {
+		return m.Handshake
+	}
+	return nil
+}
+
+func (m *SwapRPC) GetCheque() *Cheque {
+	if m != nil {
+		return m.Cheque
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Cheque)(nil), "pb.Cheque")
 	proto.RegisterType((*Handshake)(nil), "pb.Handshake")
+	proto.RegisterType((*SwapRPC)(nil), "pb.SwapRPC")
 }
 
 func init() { proto.RegisterFile("waku_swap.proto", fileDescriptor_8ec987fcc28cf932) }
 
 var fileDescriptor_8ec987fcc28cf932 = []byte{
-	// 200 bytes of a gzipped FileDescriptorProto
+	// 249 bytes of a gzipped FileDescriptorProto
 	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x2f, 0x4f, 0xcc, 0x2e,
 	0x8d, 0x2f, 0x2e, 0x4f, 0x2c, 0xd0, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0x2a, 0x48, 0x52,
 	0x9a, 0xc5, 0xc8, 0xc5, 0xe6, 0x9c, 0x91, 0x5a, 0x58, 0x9a, 0x2a, 0xa4, 0xc2, 0xc5, 0x9b, 0x59,
@@ -167,9 +225,12 @@ var fileDescriptor_8ec987fcc28cf932 = []byte{
 	0xb1, 0x25, 0xe6, 0xe6, 0x97, 0xe6, 0x95, 0x48, 0xb0, 0x80, 0x45, 0xa1, 0x3c, 0x21, 0x19, 0x2e,
 	0xce, 0xe2, 0xcc, 0xf4, 0xbc, 0xc4, 0x92, 0xd2, 0xa2, 0x54, 0x09, 0x56, 0xb0, 0x59, 0x08, 0x01,
 	0x25, 0x5d, 0x2e, 0x4e, 0x8f, 0xc4, 0xbc, 0x94, 0xe2, 0x8c, 0xc4, 0xec, 0x54, 0x74, 0x8b, 0x19,
-	0x31, 0x2c, 0x76, 0x12, 0x38, 0xf1, 0x48, 0x8e, 0xf1, 0xc2, 0x23, 0x39, 0xc6, 0x07, 0x8f, 0xe4,
-	0x18, 0x67, 0x3c, 0x96, 0x63, 0x48, 0x62, 0x03, 0x7b, 0xd4, 0x18, 0x10, 0x00, 0x00, 0xff, 0xff,
-	0xce, 0x34, 0x72, 0xd2, 0xfb, 0x00, 0x00, 0x00,
+	0x31, 0x2c, 0x56, 0x8a, 0xe2, 0x62, 0x0f, 0x2e, 0x4f, 0x2c, 0x08, 0x0a, 0x70, 0x16, 0xd2, 0xe6,
+	0xe2, 0xcc, 0x80, 0xe9, 0x04, 0x2b, 0xe5, 0x36, 0xe2, 0xd5, 0x2b, 0x48, 0xd2, 0x83, 0x1b, 0x17,
+	0x84, 0x90, 0x17, 0x52, 0xe2, 0x62, 0x4b, 0x06, 0x07, 0x01, 0xd8, 0x37, 0xdc, 0x46, 0x5c, 0x20,
+	0x95, 0x90, 0x40, 0x09, 0x82, 0xca, 0x38, 0x09, 0x9c, 0x78, 0x24, 0xc7, 0x78, 0xe1, 0x91, 0x1c,
+	0xe3, 0x83, 0x47, 0x72, 0x8c, 0x33, 0x1e, 0xcb, 0x31, 0x24, 0xb1, 0x81, 0x03, 0xd1, 0x18, 0x30,
+	0x00, 0x95, 0x9d, 0x35, 0xd7, 0x57, 0x01, 0x00, 0x00,
 }
 
 func (m *Cheque) Marshal() (dAtA []byte, err error) {
@@ -264,6 +325,57 @@ func (m *Handshake) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *SwapRPC) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SwapRPC) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SwapRPC) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Cheque != nil {
+		{
+			size, err := m.Cheque.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintWakuSwap(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Handshake != nil {
+		{
+			size, err := m.Handshake.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintWakuSwap(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintWakuSwap(dAtA []byte, offset int, v uint64) int {
 	offset -= sovWakuSwap(v)
 	base := offset
@@ -321,6 +433,26 @@ func (m *Handshake) Size() (n int) {
 	return n
 }
 
+func (m *SwapRPC) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Handshake != nil {
+		l = m.Handshake.Size()
+		n += 1 + l + sovWakuSwap(uint64(l))
+	}
+	if m.Cheque != nil {
+		l = m.Cheque.Size()
+		n += 1 + l + sovWakuSwap(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
 func sovWakuSwap(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -601,6 +733,129 @@ func (m *Handshake) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *SwapRPC) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowWakuSwap
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SwapRPC: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SwapRPC: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Handshake", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWakuSwap
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthWakuSwap
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthWakuSwap
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Handshake == nil {
+				m.Handshake = &Handshake{}
+			}
+			if err := m.Handshake.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Cheque", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWakuSwap
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthWakuSwap
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthWakuSwap
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Cheque == nil {
+				m.Cheque = &Cheque{}
+			}
+			if err := m.Cheque.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipWakuSwap(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthWakuSwap
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipWakuSwap(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
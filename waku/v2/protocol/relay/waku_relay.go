@@ -155,7 +155,8 @@ func (w *WakuRelay) subscribe(topic string) (subs *pubsub.Subscription, err erro
 	return sub, nil
 }
 
-// PublishToTopic is used to broadcast a WakuMessage to a pubsub topic
+// PublishToTopic is used to broadcast a WakuMessage to a pubsub topic. It returns
+// the hash of the message, as calculated by pb.MessageHash
 func (w *WakuRelay) PublishToTopic(ctx context.Context, message *pb.WakuMessage, topic string) ([]byte, error) {
 	// Publish a `WakuMessage` to a PubSub topic.
 	if w.pubsub == nil {
@@ -186,9 +187,7 @@ func (w *WakuRelay) PublishToTopic(ctx context.Context, message *pb.WakuMessage,
 		return nil, err
 	}
 
-	hash := pb.Hash(out)
-
-	return hash, nil
+	return pb.MessageHash(topic, message), nil
 }
 
 // Publish is used to broadcast a WakuMessage to the default waku pubsub topic
@@ -2,11 +2,16 @@ package store
 
 import (
 	"context"
+	"math"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/libp2p/go-libp2p-core/peerstore"
+	"github.com/libp2p/go-msgio/protoio"
 	"github.com/status-im/go-waku/tests"
 	"github.com/status-im/go-waku/waku/v2/protocol"
 	"github.com/status-im/go-waku/waku/v2/protocol/pb"
@@ -67,10 +72,13 @@ func TestResume(t *testing.T) {
 	err = host2.Peerstore().AddProtocols(host1.ID(), string(StoreID_v20beta4))
 	require.NoError(t, err)
 
-	msgCount, err := s2.Resume(ctx, "test", []peer.ID{host1.ID()})
+	result, err := s2.Resume(ctx, []string{"test"}, []peer.ID{host1.ID()})
 
 	require.NoError(t, err)
-	require.Equal(t, 10, msgCount)
+	require.Len(t, result, 1)
+	require.Equal(t, "test", result[0].PubsubTopic)
+	require.Equal(t, host1.ID(), result[0].PeerID)
+	require.Equal(t, 10, result[0].Stored)
 
 	allMsgs, err := s2.msgProvider.GetAll()
 	require.NoError(t, err)
@@ -78,10 +86,11 @@ func TestResume(t *testing.T) {
 	require.Len(t, allMsgs, 10)
 
 	// Test duplication
-	msgCount, err = s2.Resume(ctx, "test", []peer.ID{host1.ID()})
+	result, err = s2.Resume(ctx, []string{"test"}, []peer.ID{host1.ID()})
 
 	require.NoError(t, err)
-	require.Equal(t, 0, msgCount)
+	require.Equal(t, 10, result[0].Retrieved)
+	require.Equal(t, 0, result[0].Stored)
 }
 
 func TestResumeWithListOfPeers(t *testing.T) {
@@ -114,10 +123,11 @@ func TestResumeWithListOfPeers(t *testing.T) {
 	err = host2.Peerstore().AddProtocols(host1.ID(), string(StoreID_v20beta4))
 	require.NoError(t, err)
 
-	msgCount, err := s2.Resume(ctx, "test", []peer.ID{invalidHost.ID(), host1.ID()})
+	result, err := s2.Resume(ctx, []string{"test"}, []peer.ID{invalidHost.ID(), host1.ID()})
 
 	require.NoError(t, err)
-	require.Equal(t, 1, msgCount)
+	require.Equal(t, host1.ID(), result[0].PeerID)
+	require.Equal(t, 1, result[0].Stored)
 
 	allMsgs, err := s2.msgProvider.GetAll()
 	require.NoError(t, err)
@@ -150,12 +160,223 @@ func TestResumeWithoutSpecifyingPeer(t *testing.T) {
 	err = host2.Peerstore().AddProtocols(host1.ID(), string(StoreID_v20beta4))
 	require.NoError(t, err)
 
-	msgCount, err := s2.Resume(ctx, "test", []peer.ID{})
+	result, err := s2.Resume(ctx, []string{"test"}, []peer.ID{})
 
 	require.NoError(t, err)
-	require.Equal(t, 1, msgCount)
+	require.Equal(t, 1, result[0].Stored)
 
 	allMsgs, err := s2.msgProvider.GetAll()
 	require.NoError(t, err)
 	require.Len(t, allMsgs, 1)
 }
+
+func TestResumePaginatesMultipleTopics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s1 := NewWakuStore(host1, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s1.Start(ctx)
+	defer s1.Stop()
+
+	// More messages than fit in a single page
+	for i := 0; i < MaxPageSize*2+5; i++ {
+		_ = s1.storeMessage(protocol.NewEnvelope(tests.CreateWakuMessage("1", int64(i+1)), utils.GetUnixEpoch(), "topic1"))
+	}
+
+	for i := 0; i < 3; i++ {
+		_ = s1.storeMessage(protocol.NewEnvelope(tests.CreateWakuMessage("2", int64(i+1)), utils.GetUnixEpoch(), "topic2"))
+	}
+
+	host2, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s2 := NewWakuStore(host2, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s2.Start(ctx)
+	defer s2.Stop()
+
+	host2.Peerstore().AddAddr(host1.ID(), tests.GetHostAddress(host1), peerstore.PermanentAddrTTL)
+	err = host2.Peerstore().AddProtocols(host1.ID(), string(StoreID_v20beta4))
+	require.NoError(t, err)
+
+	result, err := s2.Resume(ctx, []string{"topic1", "topic2"}, []peer.ID{host1.ID()})
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+
+	require.Equal(t, "topic1", result[0].PubsubTopic)
+	require.Equal(t, MaxPageSize*2+5, result[0].Stored)
+	require.Equal(t, "topic2", result[1].PubsubTopic)
+	require.Equal(t, 3, result[1].Stored)
+
+	allMsgs, err := s2.msgProvider.GetAll()
+	require.NoError(t, err)
+	require.Len(t, allMsgs, MaxPageSize*2+8)
+}
+
+func TestResumeKeepsResultsOfSuccessfulTopics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s1 := NewWakuStore(host1, nil, MemoryDB(t), 0, 0, utils.Logger(), WithDeniedPubsubTopics("topic2"))
+	s1.Start(ctx)
+	defer s1.Stop()
+
+	_ = s1.storeMessage(protocol.NewEnvelope(tests.CreateWakuMessage("1", 1), utils.GetUnixEpoch(), "topic1"))
+
+	host2, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s2 := NewWakuStore(host2, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s2.Start(ctx)
+	defer s2.Stop()
+
+	host2.Peerstore().AddAddr(host1.ID(), tests.GetHostAddress(host1), peerstore.PermanentAddrTTL)
+	err = host2.Peerstore().AddProtocols(host1.ID(), string(StoreID_v20beta4))
+	require.NoError(t, err)
+
+	// topic2 is not served by host1, but the messages of topic1 are still stored
+	result, err := s2.Resume(ctx, []string{"topic2", "topic1"}, []peer.ID{host1.ID()})
+	require.ErrorIs(t, err, ErrFailedToResumeHistory)
+	require.Len(t, result, 2)
+	require.Equal(t, "topic2", result[0].PubsubTopic)
+	require.Error(t, result[0].Err)
+	require.Equal(t, "topic1", result[1].PubsubTopic)
+	require.NoError(t, result[1].Err)
+	require.Equal(t, 1, result[1].Stored)
+}
+
+func TestResumeStopsOnRepeatedCursor(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	// A peer that always answers with a full page and the same cursor
+	var messages []*pb.WakuMessage
+	for i := 0; i < MaxPageSize; i++ {
+		messages = append(messages, tests.CreateWakuMessage("1", int64(i+1)))
+	}
+	cursor := protocol.NewEnvelope(messages[MaxPageSize-1], 1, "test").Index()
+	host1.SetStreamHandler(StoreID_v20beta4, func(s network.Stream) {
+		defer s.Close()
+		request := &pb.HistoryRPC{}
+		if err := protoio.NewDelimitedReader(s, math.MaxInt32).ReadMsg(request); err != nil {
+			return
+		}
+		_ = protoio.NewDelimitedWriter(s).WriteMsg(&pb.HistoryRPC{
+			RequestId: request.RequestId,
+			Response: &pb.HistoryResponse{
+				Messages:   messages,
+				PagingInfo: &pb.PagingInfo{PageSize: MaxPageSize, Cursor: cursor, Direction: pb.PagingInfo_FORWARD},
+			},
+		})
+	})
+
+	host2, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s2 := NewWakuStore(host2, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s2.Start(ctx)
+	defer s2.Stop()
+
+	host2.Peerstore().AddAddr(host1.ID(), tests.GetHostAddress(host1), peerstore.PermanentAddrTTL)
+	err = host2.Peerstore().AddProtocols(host1.ID(), string(StoreID_v20beta4))
+	require.NoError(t, err)
+
+	result, err := s2.Resume(ctx, []string{"test"}, []peer.ID{host1.ID()})
+	require.NoError(t, err)
+	require.Equal(t, 2*MaxPageSize, result[0].Retrieved)
+	require.Equal(t, MaxPageSize, result[0].Stored)
+}
+
+func TestResumeRequestTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	// A peer that serves 3 full pages and a last one, taking some time for each page.
+	// The receiver time of the cursor is the number of the next page
+	const pages = 4
+	var delays [pages]time.Duration
+	var mu sync.Mutex
+	host1.SetStreamHandler(StoreID_v20beta4, func(s network.Stream) {
+		defer s.Close()
+		request := &pb.HistoryRPC{}
+		if err := protoio.NewDelimitedReader(s, math.MaxInt32).ReadMsg(request); err != nil {
+			return
+		}
+
+		page := request.Query.PagingInfo.GetCursor().GetReceiverTime()
+		pageSize := MaxPageSize
+		var cursor *pb.Index
+		if page < pages-1 {
+			cursor = &pb.Index{ReceiverTime: page + 1}
+		} else {
+			pageSize = MaxPageSize / 2
+		}
+
+		var messages []*pb.WakuMessage
+		for i := 0; i < pageSize; i++ {
+			messages = append(messages, tests.CreateWakuMessage("1", page*MaxPageSize+int64(i)+1))
+		}
+
+		mu.Lock()
+		delay := delays[page]
+		mu.Unlock()
+		time.Sleep(delay)
+
+		_ = protoio.NewDelimitedWriter(s).WriteMsg(&pb.HistoryRPC{
+			RequestId: request.RequestId,
+			Response: &pb.HistoryResponse{
+				Messages:   messages,
+				PagingInfo: &pb.PagingInfo{PageSize: uint64(pageSize), Cursor: cursor, Direction: pb.PagingInfo_FORWARD},
+			},
+		})
+	})
+
+	host2, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s2 := NewWakuStore(host2, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s2.Start(ctx)
+	defer s2.Stop()
+
+	host2.Peerstore().AddAddr(host1.ID(), tests.GetHostAddress(host1), peerstore.PermanentAddrTTL)
+	err = host2.Peerstore().AddProtocols(host1.ID(), string(StoreID_v20beta4))
+	require.NoError(t, err)
+
+	// A page that takes longer than the request timeout fails the resume partway
+	// through the pagination, without waiting for the response
+	mu.Lock()
+	delays[2] = 5 * time.Second
+	mu.Unlock()
+
+	start := time.Now()
+	result, err := s2.Resume(ctx, []string{"test"}, []peer.ID{host1.ID()}, WithRequestTimeout(500*time.Millisecond))
+	require.ErrorIs(t, err, ErrFailedToResumeHistory)
+	require.Error(t, result[0].Err)
+	require.Less(t, time.Since(start), 5*time.Second)
+
+	// The timeout applies to each page, so a resume that takes longer than the timeout
+	// retrieves the whole history
+	mu.Lock()
+	for i := range delays {
+		delays[i] = 200 * time.Millisecond
+	}
+	mu.Unlock()
+
+	start = time.Now()
+	result, err = s2.Resume(ctx, []string{"test"}, []peer.ID{host1.ID()}, WithRequestTimeout(500*time.Millisecond))
+	require.NoError(t, err)
+	require.Greater(t, time.Since(start), 500*time.Millisecond)
+	require.Equal(t, 3*MaxPageSize+MaxPageSize/2, result[0].Retrieved)
+	require.Equal(t, 3*MaxPageSize+MaxPageSize/2, result[0].Stored)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/hex"
 	"errors"
+	"io"
 	"math"
 	"sync"
 	"time"
@@ -27,9 +28,20 @@ import (
 // StoreID_v20beta4 is the current Waku Store protocol identifier
 const StoreID_v20beta4 = libp2pProtocol.ID("/vac/waku/store/2.0.0-beta4")
 
+// StoreID_v20beta5 is the Waku Store protocol identifier whose history responses
+// include the pubsub topic and receiver time of each message
+const StoreID_v20beta5 = libp2pProtocol.ID("/vac/waku/store/2.0.0-beta5")
+
 // MaxPageSize is the maximum number of waku messages to return per page
 const MaxPageSize = 100
 
+// MaxMessageHashes is the maximum number of message hashes that can be looked up in a single query
+const MaxMessageHashes = 100
+
+// disconnectTimeout is the maximum time to wait for a peer to read the last response
+// before its connections are closed
+const disconnectTimeout = 5 * time.Second
+
 // MaxTimeVariance is the maximum duration in the future allowed for a message timestamp
 const MaxTimeVariance = time.Duration(20) * time.Second
 
@@ -49,18 +61,98 @@ var (
 	ErrFailedQuery = errors.New("failed to resolve the query")
 
 	ErrFutureMessage = errors.New("message timestamp in the future")
+
+	// ErrInvalidCursor is returned when the store node could not find the cursor of a query
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	// ErrQueryTooLarge is returned when the query exceeds the limits of the store node
+	ErrQueryTooLarge = errors.New("query too large")
+
+	// ErrTooManyRequests is returned when the store node rejected the query because
+	// too many queries were sent to it
+	ErrTooManyRequests = errors.New("too many requests")
+
+	// ErrInternal is returned when the store node failed to process the query
+	ErrInternal = errors.New("internal store error")
+
+	// ErrServiceUnavailable is returned when the store node is unable to serve queries
+	ErrServiceUnavailable = errors.New("store service unavailable")
+
+	// ErrPaymentRequired is returned when the store node refused to serve the query
+	// because the requester reached the swap disconnect threshold
+	ErrPaymentRequired = errors.New("payment required")
+
+	// ErrTopicNotServed is returned when the query requests topics that are not
+	// archived by the store node
+	ErrTopicNotServed = errors.New("topic not served by store node")
+
+	// ErrHashLookupNotSupported is returned when the store node does not support
+	// looking up messages by their hash
+	ErrHashLookupNotSupported = errors.New("store node does not support lookups by message hash")
+
+	// ErrStatsNotSupported is returned when the store node does not support
+	// queries for the statistics of the stored messages
+	ErrStatsNotSupported = errors.New("store node does not support stats queries")
+
+	// ErrNoMessageProvider is returned when the statistics of the local store are
+	// requested, or its history is resumed, but the node does not store messages
+	ErrNoMessageProvider = errors.New("no message provider")
 )
 
+// IsRetryable returns true when a query failed due to a condition of the store node
+// that served it, so it could succeed if it's sent again later or to a different peer
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrTooManyRequests) || errors.Is(err, ErrInternal) || errors.Is(err, ErrServiceUnavailable)
+}
+
+// responseError returns the error that corresponds to the error code of a history response
+func responseError(response *pb.HistoryResponse) error {
+	switch response.Error {
+	case pb.HistoryResponse_NONE:
+		return nil
+	case pb.HistoryResponse_INVALID_CURSOR:
+		return ErrInvalidCursor
+	case pb.HistoryResponse_PAYMENT_REQUIRED:
+		return ErrPaymentRequired
+	case pb.HistoryResponse_TOPIC_NOT_SERVED:
+		return ErrTopicNotServed
+	case pb.HistoryResponse_QUERY_TOO_LARGE:
+		return ErrQueryTooLarge
+	case pb.HistoryResponse_TOO_MANY_REQUESTS:
+		return ErrTooManyRequests
+	case pb.HistoryResponse_SERVICE_UNAVAILABLE:
+		return ErrServiceUnavailable
+	default:
+		return ErrInternal
+	}
+}
+
 func findMessages(query *pb.HistoryQuery, msgProvider MessageProvider) ([]*pb.WakuMessage, *pb.PagingInfo, error) {
+	queryResult, newPagingInfo, err := findStoredMessages(query, msgProvider)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(queryResult) == 0 {
+		return nil, newPagingInfo, nil
+	}
+
+	resultMessages := make([]*pb.WakuMessage, len(queryResult))
+	for i := range queryResult {
+		resultMessages[i] = queryResult[i].Message
+	}
+
+	return resultMessages, newPagingInfo, nil
+}
+
+func findStoredMessages(query *pb.HistoryQuery, msgProvider MessageProvider) ([]persistence.StoredMessage, *pb.PagingInfo, error) {
 	if query.PagingInfo == nil {
 		query.PagingInfo = &pb.PagingInfo{
 			Direction: pb.PagingInfo_FORWARD,
 		}
 	}
 
-	if query.PagingInfo.PageSize == 0 || query.PagingInfo.PageSize > uint64(MaxPageSize) {
-		query.PagingInfo.PageSize = MaxPageSize
-	}
+	query.PagingInfo.PageSize = effectivePageSize(query.PagingInfo.PageSize)
 
 	queryResult, err := msgProvider.Query(query)
 	if err != nil {
@@ -80,37 +172,185 @@ func findMessages(query *pb.HistoryQuery, msgProvider MessageProvider) ([]*pb.Wa
 		newPagingInfo.PageSize = uint64(len(queryResult))
 	}
 
-	resultMessages := make([]*pb.WakuMessage, len(queryResult))
-	for i := range queryResult {
-		resultMessages[i] = queryResult[i].Message
-	}
+	return queryResult, newPagingInfo, nil
+}
 
-	return resultMessages, newPagingInfo, nil
+// effectivePageSize returns the maximum number of messages a store node returns
+// when a page size is requested
+func effectivePageSize(pageSize uint64) uint64 {
+	if pageSize == 0 || pageSize > uint64(MaxPageSize) {
+		return MaxPageSize
+	}
+	return pageSize
 }
 
 func (store *WakuStore) FindMessages(query *pb.HistoryQuery) *pb.HistoryResponse {
+	return store.findMessages(query, false)
+}
+
+// findMessages builds the response to a history query. When includeMetadata is true,
+// the messages are returned in the storedMessages field along with their pubsub topic
+// and receiver time, as expected by StoreID_v20beta5 clients
+func (store *WakuStore) findMessages(query *pb.HistoryQuery, includeMetadata bool) *pb.HistoryResponse {
 	result := new(pb.HistoryResponse)
 
-	messages, newPagingInfo, err := findMessages(query, store.msgProvider)
+	if query == nil {
+		query = new(pb.HistoryQuery)
+	}
+
+	if len(query.MessageHashes) != 0 {
+		return store.lookupMessages(query, includeMetadata)
+	}
+
+	if !store.params.admitsQuery(query, utils.GetUnixEpoch()) {
+		result.Error = pb.HistoryResponse_QUERY_TOO_LARGE
+		result.PagingInfo = query.PagingInfo
+		metrics.RecordStoreError(store.ctx, "queryTooLarge")
+		return result
+	}
+
+	if !store.params.servesQuery(query) {
+		result.Error = pb.HistoryResponse_TOPIC_NOT_SERVED
+		result.PagingInfo = query.PagingInfo
+		metrics.RecordStoreError(store.ctx, "topicNotServed")
+		return result
+	}
+
+	if query.StatsOnly {
+		stats, err := store.msgProvider.Stats(query)
+		if err != nil {
+			store.log.Error("obtaining stats from db", zap.Error(err))
+			metrics.RecordStoreError(store.ctx, "queryFailure")
+			result.Error = pb.HistoryResponse_INTERNAL
+			return result
+		}
+		result.Stats = stats
+		return result
+	}
+
+	storedMessages, newPagingInfo, err := findStoredMessages(query, store.msgProvider)
 	if err != nil {
 		if err == persistence.ErrInvalidCursor {
 			result.Error = pb.HistoryResponse_INVALID_CURSOR
 		} else {
-			// TODO: return error in pb.HistoryResponse
 			store.log.Error("obtaining messages from db", zap.Error(err))
+			metrics.RecordStoreError(store.ctx, "queryFailure")
+			result.Error = pb.HistoryResponse_INTERNAL
+		}
+	}
+
+	for _, storedMsg := range storedMessages {
+		if includeMetadata {
+			result.StoredMessages = append(result.StoredMessages, &pb.StoredWakuMessage{
+				Message:      storedMsg.Message,
+				PubsubTopic:  storedMsg.PubsubTopic,
+				ReceiverTime: storedMsg.ReceiverTime,
+			})
+		} else {
+			result.Messages = append(result.Messages, storedMsg.Message)
 		}
 	}
 
-	result.Messages = messages
 	result.PagingInfo = newPagingInfo
 	return result
 }
 
+// lookupMessages builds the response to a query for a list of message hashes, which
+// contains a bitmap indicating which of the messages are stored and, unless only their
+// presence was requested, the messages in the same order as the hashes
+func (store *WakuStore) lookupMessages(query *pb.HistoryQuery, includeMetadata bool) *pb.HistoryResponse {
+	result := new(pb.HistoryResponse)
+
+	if len(query.MessageHashes) > MaxMessageHashes {
+		result.Error = pb.HistoryResponse_QUERY_TOO_LARGE
+		metrics.RecordStoreError(store.ctx, "queryTooLarge")
+		return result
+	}
+
+	storedMessages, err := store.msgProvider.GetByHashes(query.MessageHashes)
+	if err != nil {
+		store.log.Error("obtaining messages from db", zap.Error(err))
+		metrics.RecordStoreError(store.ctx, "queryFailure")
+		result.Error = pb.HistoryResponse_INTERNAL
+		return result
+	}
+
+	byHash := make(map[string]persistence.StoredMessage)
+	for _, storedMsg := range storedMessages {
+		hash := string(protocol.NewEnvelope(storedMsg.Message, storedMsg.ReceiverTime, storedMsg.PubsubTopic).Hash())
+		if _, ok := byHash[hash]; !ok {
+			byHash[hash] = storedMsg
+		}
+	}
+
+	result.Presence = make([]byte, (len(query.MessageHashes)+7)/8)
+	added := make(map[string]struct{})
+	for i, hash := range query.MessageHashes {
+		storedMsg, ok := byHash[string(hash)]
+		if !ok {
+			continue
+		}
+
+		result.Presence[i/8] |= 1 << (i % 8)
+
+		if _, ok := added[string(hash)]; ok || query.PresenceOnly {
+			continue
+		}
+		added[string(hash)] = struct{}{}
+
+		if includeMetadata {
+			result.StoredMessages = append(result.StoredMessages, &pb.StoredWakuMessage{
+				Message:      storedMsg.Message,
+				PubsubTopic:  storedMsg.PubsubTopic,
+				ReceiverTime: storedMsg.ReceiverTime,
+			})
+		} else {
+			result.Messages = append(result.Messages, storedMsg.Message)
+		}
+	}
+
+	return result
+}
+
+// envelopes returns the messages of a history response along with their metadata.
+// Responses from store nodes that do not support StoreID_v20beta5 do not contain
+// any metadata, so the pubsub topic of the query is used instead and the receiver
+// time of the messages is 0
+func envelopes(response *pb.HistoryResponse, pubsubTopic string) []*protocol.Envelope {
+	if len(response.StoredMessages) != 0 {
+		result := make([]*protocol.Envelope, len(response.StoredMessages))
+		for i, storedMsg := range response.StoredMessages {
+			result[i] = protocol.NewEnvelope(storedMsg.Message, storedMsg.ReceiverTime, storedMsg.PubsubTopic)
+		}
+		return result
+	}
+
+	result := make([]*protocol.Envelope, len(response.Messages))
+	for i, msg := range response.Messages {
+		result[i] = protocol.NewEnvelope(msg, 0, pubsubTopic)
+	}
+	return result
+}
+
 type MessageProvider interface {
 	GetAll() ([]persistence.StoredMessage, error)
 	Query(query *pb.HistoryQuery) ([]persistence.StoredMessage, error)
+	GetByHashes(hashes [][]byte) ([]persistence.StoredMessage, error)
+	// Iterate calls a function for each stored message that matches the filters of a
+	// query, without loading every message in memory
+	Iterate(query *pb.HistoryQuery, fn persistence.IterateFunc) error
+	Stats(query *pb.HistoryQuery) (*pb.HistoryStats, error)
 	Put(env *protocol.Envelope) error
+	// PutBatch stores a list of messages, usually with a single write, and returns the
+	// error of each message
+	PutBatch(envs []*protocol.Envelope) []error
 	MostRecentTimestamp() (int64, error)
+	DeleteByHashes(hashes [][]byte, tombstone bool) (int64, error)
+	DeleteByContentTopic(contentTopic string, tombstone bool) (int64, error)
+	DeleteByTimeRange(startTime int64, endTime int64, tombstone bool) (int64, error)
+	// Tombstoned returns whether the messages of a list of hashes were deleted with a
+	// tombstone, in which case they are not stored again
+	Tombstoned(hashes [][]byte) ([]bool, error)
 	Stop()
 }
 type Query struct {
@@ -123,16 +363,30 @@ type Query struct {
 // Result represents a valid response from a store node
 type Result struct {
 	Messages []*pb.WakuMessage
-
-	query  *pb.HistoryQuery
-	cursor *pb.Index
-	peerId peer.ID
+	// Envelopes contains the same messages as Messages, along with their pubsub
+	// topic, receiver time and hash
+	Envelopes []*protocol.Envelope
+
+	// PeerErrors contains the error of each peer that failed to return a page when
+	// the query was sent to multiple peers. Those peers are not queried anymore
+	PeerErrors map[peer.ID]error
+
+	query           *pb.HistoryQuery
+	cursor          *pb.Index
+	compositeCursor *CompositeCursor
+	peerId          peer.ID
 }
 
 func (r *Result) Cursor() *pb.Index {
 	return r.cursor
 }
 
+// CompositeCursor returns the pagination state of the query. It's only set when the
+// query was sent to multiple peers
+func (r *Result) CompositeCursor() *CompositeCursor {
+	return r.compositeCursor
+}
+
 func (r *Result) PeerID() peer.ID {
 	return r.peerId
 }
@@ -142,9 +396,10 @@ func (r *Result) Query() *pb.HistoryQuery {
 }
 
 type WakuStore struct {
-	ctx  context.Context
-	MsgC chan *protocol.Envelope
-	wg   *sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+	MsgC   chan *protocol.Envelope
+	wg     *sync.WaitGroup
 
 	log *zap.Logger
 
@@ -153,25 +408,50 @@ type WakuStore struct {
 	msgProvider MessageProvider
 	h           host.Host
 	swap        *swap.WakuSwap
+	params      *StoreParameters
+
+	rateLimiter *peerRateLimiter
+	querySlots  chan struct{}
 }
 
 type Store interface {
 	Start(ctx context.Context)
 	Query(ctx context.Context, query Query, opts ...HistoryRequestOption) (*Result, error)
 	Next(ctx context.Context, r *Result) (*Result, error)
-	Resume(ctx context.Context, pubsubTopic string, peerList []peer.ID) (int, error)
+	QueryAll(ctx context.Context, query Query, opts ...HistoryRequestOption) (*ResultIterator, error)
+	LookupMessages(ctx context.Context, hashes [][]byte, opts ...HistoryRequestOption) (*LookupResult, error)
+	HasMessages(ctx context.Context, hashes [][]byte, opts ...HistoryRequestOption) ([]bool, error)
+	Stats(ctx context.Context, query Query, opts ...HistoryRequestOption) (*pb.HistoryStats, error)
+	LocalStats(query Query) (*pb.HistoryStats, error)
+	Resume(ctx context.Context, pubsubTopics []string, peerList []peer.ID, opts ...ResumeOption) ([]ResumeResult, error)
+	Sync(ctx context.Context, peerID peer.ID, pubsubTopic string, startTime int64, endTime int64) (*SyncResult, error)
+	Delete(criteria DeleteCriteria) (int64, error)
 	MessageChannel() chan *protocol.Envelope
 	Stop()
 }
 
 // NewWakuStore creates a WakuStore using an specific MessageProvider for storing the messages
-func NewWakuStore(host host.Host, swap *swap.WakuSwap, p MessageProvider, maxNumberOfMessages int, maxRetentionDuration time.Duration, log *zap.Logger) *WakuStore {
+func NewWakuStore(host host.Host, swap *swap.WakuSwap, p MessageProvider, maxNumberOfMessages int, maxRetentionDuration time.Duration, log *zap.Logger, opts ...Option) *WakuStore {
+	params := &StoreParameters{
+		writeBatchSize:   DefaultWriteBatchSize,
+		writeBatchWindow: DefaultWriteBatchWindow,
+		syncWindow:       DefaultSyncWindow,
+	}
+	for _, opt := range opts {
+		opt(params)
+	}
+
 	wakuStore := new(WakuStore)
+	wakuStore.params = params
 	wakuStore.msgProvider = p
 	wakuStore.h = host
 	wakuStore.swap = swap
 	wakuStore.wg = &sync.WaitGroup{}
 	wakuStore.log = log.Named("store")
+	wakuStore.rateLimiter = newPeerRateLimiter(params.rateLimit, params.rateBurst)
+	if params.maxConcurrentQueries > 0 {
+		wakuStore.querySlots = make(chan struct{}, params.maxConcurrentQueries)
+	}
 	return wakuStore
 }
 
@@ -192,37 +472,116 @@ func (store *WakuStore) Start(ctx context.Context) {
 	}
 
 	store.started = true
-	store.ctx = ctx
+	store.ctx, store.cancel = context.WithCancel(ctx)
 	store.MsgC = make(chan *protocol.Envelope, 1024)
 
+	store.h.SetStreamHandlerMatch(StoreID_v20beta5, protocol.PrefixTextMatch(string(StoreID_v20beta5)), store.onRequest)
 	store.h.SetStreamHandlerMatch(StoreID_v20beta4, protocol.PrefixTextMatch(string(StoreID_v20beta4)), store.onRequest)
+	if store.params.syncEnabled() {
+		store.h.SetStreamHandlerMatch(StoreSyncID_v10beta1, protocol.PrefixTextMatch(string(StoreSyncID_v10beta1)), store.onSyncRequest)
+	}
 
 	store.wg.Add(1)
-	go store.storeIncomingMessages(ctx)
+	go store.storeIncomingMessages(store.ctx)
+
+	if store.params.syncInterval > 0 {
+		store.wg.Add(1)
+		go store.syncPeriodically(store.ctx)
+	}
 
 	store.log.Info("Store protocol started")
 }
 
 func (store *WakuStore) storeMessage(env *protocol.Envelope) error {
-	// Ensure that messages don't "jump" to the front of the queue with future timestamps
-	if env.Index().SenderTime-env.Index().ReceiverTime > int64(MaxTimeVariance) {
-		return ErrFutureMessage
+	return store.storeMessages([]*protocol.Envelope{env})[0]
+}
+
+// storeMessages stores a list of messages with a single write, and returns the error of each message
+func (store *WakuStore) storeMessages(envs []*protocol.Envelope) []error {
+	errs := make([]error, len(envs))
+	var valid []*protocol.Envelope
+	var validIdx []int
+	for i, env := range envs {
+		// Ensure that messages don't "jump" to the front of the queue with future timestamps
+		if env.Index().SenderTime-env.Index().ReceiverTime > int64(MaxTimeVariance) {
+			errs[i] = ErrFutureMessage
+			continue
+		}
+		valid = append(valid, env)
+		validIdx = append(validIdx, i)
 	}
 
-	err := store.msgProvider.Put(env)
-	if err != nil {
-		store.log.Error("storing message", zap.Error(err))
-		metrics.RecordStoreError(store.ctx, "store_failure")
-		return err
+	if len(valid) == 0 {
+		return errs
 	}
 
-	return nil
+	start := time.Now()
+	putErrs := store.msgProvider.PutBatch(valid)
+	metrics.RecordStoreWrite(store.ctx, len(valid), time.Since(start), len(store.MsgC))
+
+	for i, err := range putErrs {
+		errs[validIdx[i]] = err
+		// Messages are identified by their hash, so a message received more than once is
+		// only stored once, and messages deleted by an operator can be received again
+		if err != nil && err != persistence.ErrDuplicateMessage && err != persistence.ErrDeletedMessage {
+			store.log.Error("storing message", zap.Error(err))
+			metrics.RecordStoreError(store.ctx, "store_failure")
+		}
+	}
+
+	return errs
 }
 
+// storeIncomingMessages stores the messages received in MsgC in batches. A batch
+// contains up to writeBatchSize messages: the ones waiting in MsgC, and the ones
+// received during writeBatchWindow after the first message of the batch
 func (store *WakuStore) storeIncomingMessages(ctx context.Context) {
 	defer store.wg.Done()
+
+	batch := make([]*protocol.Envelope, 0, store.params.writeBatchSize)
 	for envelope := range store.MsgC {
-		_ = store.storeMessage(envelope)
+		batch = batch[:0]
+		if store.params.shouldStore(envelope) {
+			batch = append(batch, envelope)
+		}
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if store.params.writeBatchWindow > 0 {
+			timer = time.NewTimer(store.params.writeBatchWindow)
+			timeout = timer.C
+		}
+
+		for len(batch) < store.params.writeBatchSize {
+			var ok bool
+			if timeout == nil {
+				select {
+				case envelope, ok = <-store.MsgC:
+				default:
+				}
+			} else {
+				select {
+				case envelope, ok = <-store.MsgC:
+				case <-timeout:
+				}
+			}
+
+			if !ok {
+				break
+			}
+
+			if store.params.shouldStore(envelope) {
+				batch = append(batch, envelope)
+			}
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+
+		if len(batch) != 0 {
+			_ = store.storeMessages(batch)
+		}
 	}
 }
 
@@ -246,11 +605,16 @@ func (store *WakuStore) onRequest(s network.Stream) {
 	}
 	logger.Info("received query")
 
+	peerID := s.Conn().RemotePeer()
 	historyResponseRPC := &pb.HistoryRPC{}
 	historyResponseRPC.RequestId = historyRPCRequest.RequestId
-	historyResponseRPC.Response = store.FindMessages(historyRPCRequest.Query)
+	if store.started {
+		historyResponseRPC.Response = store.serveQuery(peerID, historyRPCRequest.Query, s.Protocol() == StoreID_v20beta5)
+	} else {
+		historyResponseRPC.Response = &pb.HistoryResponse{Error: pb.HistoryResponse_SERVICE_UNAVAILABLE}
+	}
 
-	logger = logger.With(zap.Int("messages", len(historyResponseRPC.Response.Messages)))
+	logger = logger.With(zap.Int("messages", len(historyResponseRPC.Response.Messages)+len(historyResponseRPC.Response.StoredMessages)))
 	err = writer.WriteMsg(historyResponseRPC)
 	if err != nil {
 		logger.Error("writing response", zap.Error(err), logging.PagingInfo(historyResponseRPC.Response.PagingInfo))
@@ -258,14 +622,32 @@ func (store *WakuStore) onRequest(s network.Stream) {
 	} else {
 		logger.Info("response sent")
 	}
+
+	if store.swap != nil && !store.swap.ShouldServe(peerID.Pretty()) {
+		logger.Info("closing connections of peer that reached the disconnect threshold")
+		store.disconnectPeer(s)
+	}
+}
+
+// disconnectPeer closes the connections of the peer of a stream once the peer has read
+// the response, which would be lost if the connection was closed right after writing it
+func (store *WakuStore) disconnectPeer(s network.Stream) {
+	_ = s.CloseWrite()
+	_ = s.SetReadDeadline(time.Now().Add(disconnectTimeout))
+	_, _ = io.Copy(io.Discard, s)
+	_ = store.h.Network().ClosePeer(s.Conn().RemotePeer())
 }
 
 type HistoryRequestParameters struct {
-	selectedPeer peer.ID
-	requestId    []byte
-	cursor       *pb.Index
-	pageSize     uint64
-	asc          bool
+	selectedPeer    peer.ID
+	selectedPeers   []peer.ID
+	requestId       []byte
+	cursor          *pb.Index
+	compositeCursor *CompositeCursor
+	pageSize        uint64
+	asc             bool
+	maxMessages     int
+	err             error
 
 	s *WakuStore
 }
@@ -303,6 +685,27 @@ func WithFastestPeerSelection(ctx context.Context) HistoryRequestOption {
 	}
 }
 
+// WithPeers is an option used to send the query concurrently to a list of peers,
+// merging their results
+func WithPeers(peers ...peer.ID) HistoryRequestOption {
+	return func(params *HistoryRequestParameters) {
+		params.selectedPeers = peers
+	}
+}
+
+// WithFanOut is an option used to send the query concurrently to up to n peers
+// randomly selected from the store, merging their results
+func WithFanOut(n int) HistoryRequestOption {
+	return func(params *HistoryRequestParameters) {
+		peers, err := utils.SelectPeers(params.s.h, string(StoreID_v20beta4), n, params.s.log)
+		if err != nil {
+			params.err = err
+			return
+		}
+		params.selectedPeers = peers
+	}
+}
+
 func WithRequestId(requestId []byte) HistoryRequestOption {
 	return func(params *HistoryRequestParameters) {
 		params.requestId = requestId
@@ -321,6 +724,14 @@ func WithCursor(c *pb.Index) HistoryRequestOption {
 	}
 }
 
+// WithCompositeCursor is an option used to resume the pagination of a query
+// that was sent to multiple peers
+func WithCompositeCursor(c *CompositeCursor) HistoryRequestOption {
+	return func(params *HistoryRequestParameters) {
+		params.compositeCursor = c
+	}
+}
+
 // WithPaging is an option used to specify the order and maximum number of records to return
 func WithPaging(asc bool, pageSize uint64) HistoryRequestOption {
 	return func(params *HistoryRequestParameters) {
@@ -329,6 +740,14 @@ func WithPaging(asc bool, pageSize uint64) HistoryRequestOption {
 	}
 }
 
+// WithMaxMessages is an option used to limit the number of messages that are
+// retrieved when iterating over the pages of a query with QueryAll
+func WithMaxMessages(n int) HistoryRequestOption {
+	return func(params *HistoryRequestParameters) {
+		params.maxMessages = n
+	}
+}
+
 // Default options to be used when querying a store node for results
 func DefaultOptions() []HistoryRequestOption {
 	return []HistoryRequestOption{
@@ -349,7 +768,7 @@ func (store *WakuStore) queryFrom(ctx context.Context, q *pb.HistoryQuery, selec
 		return nil, err
 	}
 
-	connOpt, err := store.h.NewStream(ctx, selectedPeer, StoreID_v20beta4)
+	connOpt, err := store.h.NewStream(ctx, selectedPeer, StoreID_v20beta5, StoreID_v20beta4)
 	if err != nil {
 		logger.Error("creating stream to peer", zap.Error(err))
 		return nil, err
@@ -360,6 +779,11 @@ func (store *WakuStore) queryFrom(ctx context.Context, q *pb.HistoryQuery, selec
 		_ = connOpt.Reset()
 	}()
 
+	// Reading the response is not interrupted by the context otherwise
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = connOpt.SetDeadline(deadline)
+	}
+
 	historyRequest := &pb.HistoryRPC{Query: q, RequestId: hex.EncodeToString(requestId)}
 
 	writer := protoio.NewDelimitedWriter(connOpt)
@@ -379,12 +803,29 @@ func (store *WakuStore) queryFrom(ctx context.Context, q *pb.HistoryQuery, selec
 		return nil, err
 	}
 
-	metrics.RecordMessage(ctx, "retrieved", len(historyResponseRPC.Response.Messages))
+	response := historyResponseRPC.Response
+	if response == nil {
+		response = new(pb.HistoryResponse)
+	}
+
+	// StoreID_v20beta5 nodes only populate the messages with metadata
+	if len(response.StoredMessages) != 0 {
+		response.Messages = make([]*pb.WakuMessage, len(response.StoredMessages))
+		for i, storedMsg := range response.StoredMessages {
+			response.Messages[i] = storedMsg.Message
+		}
+	}
+
+	metrics.RecordMessage(ctx, "retrieved", len(response.Messages))
+
+	if store.swap != nil {
+		store.swap.Credit(selectedPeer.Pretty(), len(response.Messages))
+	}
 
-	return historyResponseRPC.Response, nil
+	return response, nil
 }
 
-func (store *WakuStore) Query(ctx context.Context, query Query, opts ...HistoryRequestOption) (*Result, error) {
+func (store *WakuStore) newHistoryQuery(query Query, opts ...HistoryRequestOption) (*pb.HistoryQuery, *HistoryRequestParameters, error) {
 	q := &pb.HistoryQuery{
 		PubsubTopic:    query.Topic,
 		ContentFilters: []*pb.ContentFilter{},
@@ -406,12 +847,16 @@ func (store *WakuStore) Query(ctx context.Context, query Query, opts ...HistoryR
 		opt(params)
 	}
 
-	if params.selectedPeer == "" {
-		return nil, ErrNoPeersAvailable
+	if params.err != nil {
+		return nil, nil, params.err
+	}
+
+	if params.selectedPeer == "" && len(params.selectedPeers) == 0 && params.compositeCursor == nil {
+		return nil, nil, ErrNoPeersAvailable
 	}
 
 	if len(params.requestId) == 0 {
-		return nil, ErrInvalidId
+		return nil, nil, ErrInvalidId
 	}
 
 	if params.cursor != nil {
@@ -426,20 +871,42 @@ func (store *WakuStore) Query(ctx context.Context, query Query, opts ...HistoryR
 
 	q.PagingInfo.PageSize = params.pageSize
 
+	return q, params, nil
+}
+
+func (store *WakuStore) Query(ctx context.Context, query Query, opts ...HistoryRequestOption) (*Result, error) {
+	q, params, err := store.newHistoryQuery(query, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return store.query(ctx, q, params)
+}
+
+func (store *WakuStore) query(ctx context.Context, q *pb.HistoryQuery, params *HistoryRequestParameters) (*Result, error) {
+	if params.compositeCursor != nil {
+		return store.queryFanOut(ctx, q, params.compositeCursor)
+	}
+
+	if len(params.selectedPeers) != 0 {
+		return store.queryFanOut(ctx, q, newCompositeCursor(params.selectedPeers))
+	}
+
 	response, err := store.queryFrom(ctx, q, params.selectedPeer, params.requestId)
 	if err != nil {
 		return nil, err
 	}
 
-	if response.Error == pb.HistoryResponse_INVALID_CURSOR {
-		return nil, errors.New("invalid cursor")
+	if err := responseError(response); err != nil {
+		return nil, err
 	}
 
 	return &Result{
-		Messages: response.Messages,
-		cursor:   response.PagingInfo.Cursor,
-		query:    q,
-		peerId:   params.selectedPeer,
+		Messages:  response.Messages,
+		Envelopes: envelopes(response, q.PubsubTopic),
+		cursor:    response.PagingInfo.Cursor,
+		query:     q,
+		peerId:    params.selectedPeer,
 	}, nil
 }
 
@@ -448,6 +915,10 @@ func (store *WakuStore) Query(ctx context.Context, query Query, opts ...HistoryR
 // This function is useful for iterating over results without having to manually
 // specify the cursor and pagination order and max number of results
 func (store *WakuStore) Next(ctx context.Context, r *Result) (*Result, error) {
+	if r.CompositeCursor() != nil {
+		return store.queryFanOut(ctx, r.Query(), r.CompositeCursor())
+	}
+
 	q := &pb.HistoryQuery{
 		PubsubTopic:    r.Query().PubsubTopic,
 		ContentFilters: r.Query().ContentFilters,
@@ -470,54 +941,85 @@ func (store *WakuStore) Next(ctx context.Context, r *Result) (*Result, error) {
 		return nil, err
 	}
 
-	if response.Error == pb.HistoryResponse_INVALID_CURSOR {
-		return nil, errors.New("invalid cursor")
+	if err := responseError(response); err != nil {
+		return nil, err
 	}
 
 	return &Result{
-		Messages: response.Messages,
-		cursor:   response.PagingInfo.Cursor,
-		query:    q,
-		peerId:   r.PeerID(),
+		Messages:  response.Messages,
+		Envelopes: envelopes(response, q.PubsubTopic),
+		cursor:    response.PagingInfo.Cursor,
+		query:     q,
+		peerId:    r.PeerID(),
 	}, nil
 }
 
-func (store *WakuStore) queryLoop(ctx context.Context, query *pb.HistoryQuery, candidateList []peer.ID) ([]*pb.WakuMessage, error) {
-	// loops through the candidateList in order and sends the query to each until one of the query gets resolved successfully
-	// returns the number of retrieved messages, or error if all the requests fail
-
-	queryWg := sync.WaitGroup{}
-	queryWg.Add(len(candidateList))
-
-	resultChan := make(chan *pb.HistoryResponse, len(candidateList))
-
+func (store *WakuStore) queryLoop(ctx context.Context, query *pb.HistoryQuery, candidateList []peer.ID, requestTimeout time.Duration) ([]*pb.WakuMessage, peer.ID, error) {
+	// loops through the candidateList in order and sends the query to each until one of the query gets resolved successfully.
+	// The query is paginated using the cursor returned by the peer, until the last page is reached.
+	// If requestTimeout is set, it limits the time to retrieve each page
+	// returns the retrieved messages and the peer that served them, or error if all the requests fail
 	for _, peer := range candidateList {
-		func() {
-			defer queryWg.Done()
-			result, err := store.queryFrom(ctx, query, peer, protocol.GenerateRequestId())
-			if err == nil {
-				resultChan <- result
-				return
-			}
-			store.log.Error("resuming history", logging.HostID("peer", peer), zap.Error(err))
-		}()
+		messages, err := store.queryAllPages(ctx, query, peer, requestTimeout)
+		if err == nil {
+			return messages, peer, nil
+		}
+		store.log.Error("resuming history", logging.HostID("peer", peer), zap.Error(err))
 	}
 
-	queryWg.Wait()
-	close(resultChan)
+	return nil, "", ErrFailedQuery
+}
 
-	var messages []*pb.WakuMessage
-	hasResults := false
-	for result := range resultChan {
-		hasResults = true
-		messages = append(messages, result.Messages...)
+func (store *WakuStore) queryAllPages(ctx context.Context, query *pb.HistoryQuery, selectedPeer peer.ID, requestTimeout time.Duration) ([]*pb.WakuMessage, error) {
+	q := &pb.HistoryQuery{
+		PubsubTopic:    query.PubsubTopic,
+		ContentFilters: query.ContentFilters,
+		StartTime:      query.StartTime,
+		EndTime:        query.EndTime,
+		PagingInfo: &pb.PagingInfo{
+			PageSize:  query.PagingInfo.PageSize,
+			Direction: query.PagingInfo.Direction,
+			Cursor:    query.PagingInfo.Cursor,
+		},
 	}
 
-	if hasResults {
-		return messages, nil
-	}
+	var messages []*pb.WakuMessage
+	seenCursors := make(map[string]struct{})
+	for {
+		requestCtx, cancel := ctx, context.CancelFunc(func() {})
+		if requestTimeout > 0 {
+			requestCtx, cancel = context.WithTimeout(ctx, requestTimeout)
+		}
+		response, err := store.queryFrom(requestCtx, q, selectedPeer, protocol.GenerateRequestId())
+		cancel()
+		if err != nil {
+			return nil, err
+		}
 
-	return nil, ErrFailedQuery
+		if err := responseError(response); err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, response.Messages...)
+
+		// A page with less messages than requested, or without a cursor, is the last one
+		if len(response.Messages) == 0 || response.PagingInfo == nil || response.PagingInfo.Cursor == nil || uint64(len(response.Messages)) < effectivePageSize(q.PagingInfo.PageSize) {
+			return messages, nil
+		}
+
+		// A peer that returns a cursor again would make the pagination loop forever
+		cursor, err := response.PagingInfo.Cursor.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seenCursors[string(cursor)]; ok {
+			store.log.Warn("peer returned a repeated cursor", logging.HostID("peer", selectedPeer))
+			return messages, nil
+		}
+		seenCursors[string(cursor)] = struct{}{}
+
+		q.PagingInfo.Cursor = response.PagingInfo.Cursor
+	}
 }
 
 func (store *WakuStore) findLastSeen() (int64, error) {
@@ -531,83 +1033,178 @@ func max(x, y int64) int64 {
 	return y
 }
 
-// Resume retrieves the history of waku messages published on the default waku pubsub topic since the last time the waku store node has been online
+// ResumeResult contains the number of messages retrieved for a pubsub topic
+// during a Resume, and the peer that served them
+type ResumeResult struct {
+	PubsubTopic string
+	PeerID      peer.ID
+	// Retrieved is the number of messages returned by the peer
+	Retrieved int
+	// Stored is the number of new messages that were persisted
+	Stored int
+	// Err is the error that prevented resuming the history of the pubsub topic
+	Err error
+}
+
+type ResumeParameters struct {
+	lastSynced     int64
+	requestTimeout time.Duration
+}
+
+type ResumeOption func(*ResumeParameters)
+
+// WithLastSynced is an option used to specify the timestamp up to which the
+// message history is known to be complete. If not specified, the timestamp of
+// the most recent stored message is used instead
+func WithLastSynced(t int64) ResumeOption {
+	return func(params *ResumeParameters) {
+		params.lastSynced = t
+	}
+}
+
+// WithRequestTimeout is an option used to limit the time to retrieve each page of the
+// history. Unlike a deadline of the context, it does not limit the whole resume, so a
+// long history is not cut short after some of its pages were retrieved
+func WithRequestTimeout(timeout time.Duration) ResumeOption {
+	return func(params *ResumeParameters) {
+		params.requestTimeout = timeout
+	}
+}
+
+// Resume retrieves the history of waku messages published on a list of pubsub topics since the last time the waku store node has been online
 // messages are stored in the store node's messages field and in the message db
 // the offline time window is measured as the difference between the current time and the timestamp of the most recent persisted waku message
 // an offset of 20 second is added to the time window to count for nodes asynchrony
-// the history is fetched from one of the peers persisted in the waku store node's peer manager unit
+// the history is fetched from one of the peers persisted in the waku store node's peer manager unit, paginating through the whole time window
 // peerList indicates the list of peers to query from. The history is fetched from the first available peer in this list. Such candidates should be found through a discovery method (to be developed).
 // if no peerList is passed, one of the peers in the underlying peer manager unit of the store protocol is picked randomly to fetch the history from. The history gets fetched successfully if the dialed peer has been online during the queried time window.
-// the resume proc returns the number of retrieved messages if no error occurs, otherwise returns the error string
-func (store *WakuStore) Resume(ctx context.Context, pubsubTopic string, peerList []peer.ID) (int, error) {
+// the resume proc returns the number of retrieved and stored messages for each pubsub topic. If the history of some topic could not be resumed, its result contains the error and ErrFailedToResumeHistory is returned along with the results of every topic
+func (store *WakuStore) Resume(ctx context.Context, pubsubTopics []string, peerList []peer.ID, opts ...ResumeOption) ([]ResumeResult, error) {
+	if store.msgProvider == nil {
+		return nil, ErrNoMessageProvider
+	}
+
 	if !store.started {
-		return 0, errors.New("can't resume: store has not started")
+		return nil, errors.New("can't resume: store has not started")
+	}
+
+	params := new(ResumeParameters)
+	for _, opt := range opts {
+		opt(params)
 	}
 
 	currentTime := utils.GetUnixEpoch()
-	lastSeenTime, err := store.findLastSeen()
-	if err != nil {
-		return 0, err
+	lastSeenTime := params.lastSynced
+	if lastSeenTime == 0 {
+		var err error
+		lastSeenTime, err = store.findLastSeen()
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	var offset int64 = int64(20 * time.Nanosecond)
+	var offset int64 = int64(20 * time.Second)
 	currentTime = currentTime + offset
 	lastSeenTime = max(lastSeenTime-offset, 0)
 
-	rpc := &pb.HistoryQuery{
-		PubsubTopic: pubsubTopic,
-		StartTime:   lastSeenTime,
-		EndTime:     currentTime,
-		PagingInfo: &pb.PagingInfo{
-			PageSize:  0,
-			Direction: pb.PagingInfo_BACKWARD,
-		},
-	}
-
 	if len(peerList) == 0 {
 		p, err := utils.SelectPeer(store.h, string(StoreID_v20beta4), store.log)
 		if err != nil {
 			store.log.Info("selecting peer", zap.Error(err))
-			return -1, ErrNoPeersAvailable
+			return nil, ErrNoPeersAvailable
 		}
 
 		peerList = append(peerList, *p)
 	}
 
-	messages, err := store.queryLoop(ctx, rpc, peerList)
-	if err != nil {
-		store.log.Error("resuming history", zap.Error(err))
-		return -1, ErrFailedToResumeHistory
-	}
+	var result []ResumeResult
+	failed := false
+	for _, pubsubTopic := range pubsubTopics {
+		rpc := &pb.HistoryQuery{
+			PubsubTopic: pubsubTopic,
+			StartTime:   lastSeenTime,
+			EndTime:     currentTime,
+			PagingInfo: &pb.PagingInfo{
+				PageSize:  MaxPageSize,
+				Direction: pb.PagingInfo_FORWARD,
+			},
+		}
 
-	msgCount := 0
-	for _, msg := range messages {
-		if err = store.storeMessage(protocol.NewEnvelope(msg, utils.GetUnixEpoch(), pubsubTopic)); err == nil {
-			msgCount++
+		messages, selectedPeer, err := store.queryLoop(ctx, rpc, peerList, params.requestTimeout)
+		if err != nil {
+			store.log.Error("resuming history", zap.String("pubsubTopic", pubsubTopic), zap.Error(err))
+			result = append(result, ResumeResult{PubsubTopic: pubsubTopic, Err: err})
+			failed = true
+			continue
+		}
+
+		var envs []*protocol.Envelope
+		seen := make(map[string]struct{})
+		for _, msg := range messages {
+			env := protocol.NewEnvelope(msg, utils.GetUnixEpoch(), pubsubTopic)
+			hash := string(env.Hash())
+			if _, ok := seen[hash]; ok {
+				continue
+			}
+			seen[hash] = struct{}{}
+
+			if !store.params.shouldStore(env) {
+				continue
+			}
+
+			envs = append(envs, env)
 		}
+
+		msgCount := 0
+		if len(envs) != 0 {
+			for _, err := range store.storeMessages(envs) {
+				if err == nil {
+					msgCount++
+				}
+			}
+		}
+
+		store.log.Info("retrieved messages since the last online time",
+			zap.String("pubsubTopic", pubsubTopic),
+			logging.HostID("peer", selectedPeer),
+			zap.Int("retrieved", len(messages)),
+			zap.Int("stored", msgCount))
+
+		result = append(result, ResumeResult{
+			PubsubTopic: pubsubTopic,
+			PeerID:      selectedPeer,
+			Retrieved:   len(messages),
+			Stored:      msgCount,
+		})
 	}
 
-	store.log.Info("retrieved messages since the last online time", zap.Int("messages", len(messages)))
+	if failed {
+		return result, ErrFailedToResumeHistory
+	}
 
-	return msgCount, nil
+	return result, nil
 }
 
 func (store *WakuStore) MessageChannel() chan *protocol.Envelope {
 	return store.MsgC
 }
 
-// TODO: queryWithAccounting
-
 // Stop closes the store message channel and removes the protocol stream handler
 func (store *WakuStore) Stop() {
 	store.started = false
 
+	if store.cancel != nil {
+		store.cancel()
+	}
+
 	if store.MsgC != nil {
 		close(store.MsgC)
 	}
 
 	if store.h != nil {
 		store.h.RemoveStreamHandler(StoreID_v20beta4)
+		store.h.RemoveStreamHandler(StoreID_v20beta5)
+		store.h.RemoveStreamHandler(StoreSyncID_v10beta1)
 	}
 
 	store.wg.Wait()
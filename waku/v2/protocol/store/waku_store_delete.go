@@ -0,0 +1,51 @@
+package store
+
+import "errors"
+
+// ErrInvalidDeleteCriteria is returned when a deletion doesn't select the messages by
+// exactly one of hashes, content topic or time range
+var ErrInvalidDeleteCriteria = errors.New("messages must be deleted by hashes, content topic or time range")
+
+// DeleteCriteria selects the stored messages removed by Delete
+type DeleteCriteria struct {
+	// Hashes of the messages to delete
+	Hashes [][]byte
+	// ContentTopic deletes every message with this content topic
+	ContentTopic string
+	// StartTime and EndTime delete the messages whose sender timestamp is in this
+	// range, both included. A time of 0 leaves the range open on that side
+	StartTime int64
+	EndTime   int64
+	// Tombstone keeps the hashes of the deleted messages, so they are not stored again
+	// when they are received, resumed or synchronized from other store nodes
+	Tombstone bool
+}
+
+// Delete removes from the local store the messages selected by a DeleteCriteria, and
+// returns the number of messages removed
+func (store *WakuStore) Delete(criteria DeleteCriteria) (int64, error) {
+	if store.msgProvider == nil {
+		return 0, ErrNoMessageProvider
+	}
+
+	return DeleteMessages(store.msgProvider, criteria)
+}
+
+// DeleteMessages removes the messages of a MessageProvider selected by a DeleteCriteria.
+// Exactly one of the hashes, the content topic or the time range must be specified
+func DeleteMessages(msgProvider MessageProvider, criteria DeleteCriteria) (int64, error) {
+	byHashes := len(criteria.Hashes) != 0
+	byContentTopic := criteria.ContentTopic != ""
+	byTimeRange := criteria.StartTime != 0 || criteria.EndTime != 0
+
+	switch {
+	case byHashes && !byContentTopic && !byTimeRange:
+		return msgProvider.DeleteByHashes(criteria.Hashes, criteria.Tombstone)
+	case byContentTopic && !byHashes && !byTimeRange:
+		return msgProvider.DeleteByContentTopic(criteria.ContentTopic, criteria.Tombstone)
+	case byTimeRange && !byHashes && !byContentTopic:
+		return msgProvider.DeleteByTimeRange(criteria.StartTime, criteria.EndTime, criteria.Tombstone)
+	default:
+		return 0, ErrInvalidDeleteCriteria
+	}
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/persistence"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreDelete(t *testing.T) {
+	db := MemoryDB(t)
+
+	s := NewWakuStore(nil, nil, db, 0, 0, utils.Logger())
+
+	env1 := protocol.NewEnvelope(tests.CreateWakuMessage("1", 1), utils.GetUnixEpoch(), "test")
+	env2 := protocol.NewEnvelope(tests.CreateWakuMessage("2", 2), utils.GetUnixEpoch(), "test")
+	require.NoError(t, s.storeMessage(env1))
+	require.NoError(t, s.storeMessage(env2))
+
+	_, err := s.Delete(DeleteCriteria{})
+	require.ErrorIs(t, err, ErrInvalidDeleteCriteria)
+
+	_, err = s.Delete(DeleteCriteria{Hashes: [][]byte{env1.Hash()}, ContentTopic: "1"})
+	require.ErrorIs(t, err, ErrInvalidDeleteCriteria)
+
+	deleted, err := s.Delete(DeleteCriteria{Hashes: [][]byte{env1.Hash()}, Tombstone: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+
+	// Tombstoned messages are skipped like duplicates
+	require.ErrorIs(t, s.storeMessage(env1), persistence.ErrDeletedMessage)
+
+	deleted, err = s.Delete(DeleteCriteria{ContentTopic: "2"})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+
+	msgs, err := db.GetAll()
+	require.NoError(t, err)
+	require.Empty(t, msgs)
+
+	_, err = NewWakuStore(nil, nil, nil, 0, 0, utils.Logger()).Delete(DeleteCriteria{ContentTopic: "1"})
+	require.ErrorIs(t, err, ErrNoMessageProvider)
+}
@@ -0,0 +1,296 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/status-im/go-waku/logging"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"go.uber.org/zap"
+)
+
+// CompositeCursor contains the pagination state of a query sent concurrently to multiple
+// store nodes: the cursor of each node that has more pages, and the messages retrieved
+// from each node that were not returned yet, because the next page of another node
+// might still contain messages that go before them
+type CompositeCursor struct {
+	// cursors contains the peers with more pages. The cursor is nil until the first
+	// page of the peer is retrieved
+	cursors map[peer.ID]*pb.Index
+	pending map[peer.ID][]*protocol.Envelope
+	// seen contains the hashes of the messages already returned, which are skipped
+	// when other peers return them too
+	seen map[string]struct{}
+}
+
+func newCompositeCursor(peers []peer.ID) *CompositeCursor {
+	c := &CompositeCursor{
+		cursors: make(map[peer.ID]*pb.Index),
+		pending: make(map[peer.ID][]*protocol.Envelope),
+		seen:    make(map[string]struct{}),
+	}
+	for _, p := range peers {
+		c.cursors[p] = nil
+	}
+	return c
+}
+
+// Peers returns the peers that have more pages of results
+func (c *CompositeCursor) Peers() []peer.ID {
+	var peers []peer.ID
+	for p := range c.cursors {
+		peers = append(peers, p)
+	}
+	return peers
+}
+
+// Done returns true once every page of every peer was retrieved and all of their
+// messages were returned
+func (c *CompositeCursor) Done() bool {
+	if len(c.cursors) != 0 {
+		return false
+	}
+	for _, envs := range c.pending {
+		if len(envs) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// clone copies the state of the cursor, so a result can be used more than once
+// to retrieve the next page
+func (c *CompositeCursor) clone() *CompositeCursor {
+	result := &CompositeCursor{
+		cursors: make(map[peer.ID]*pb.Index, len(c.cursors)),
+		pending: make(map[peer.ID][]*protocol.Envelope, len(c.pending)),
+		seen:    make(map[string]struct{}, len(c.seen)),
+	}
+	for p, cursor := range c.cursors {
+		result.cursors[p] = cursor
+	}
+	for p, envs := range c.pending {
+		result.pending[p] = append([]*protocol.Envelope(nil), envs...)
+	}
+	for hash := range c.seen {
+		result.seen[hash] = struct{}{}
+	}
+	return result
+}
+
+// FanOutError is returned when every peer a query was sent to failed. It matches
+// ErrFailedQuery, and the error of each peer, with errors.Is
+type FanOutError struct {
+	PeerErrors map[peer.ID]error
+}
+
+func (e *FanOutError) Error() string {
+	peers := make([]peer.ID, 0, len(e.PeerErrors))
+	for p := range e.PeerErrors {
+		peers = append(peers, p)
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i] < peers[j] })
+
+	var errs []string
+	for _, p := range peers {
+		errs = append(errs, fmt.Sprintf("%s: %s", p.Pretty(), e.PeerErrors[p]))
+	}
+	return fmt.Sprintf("%s: %s", ErrFailedQuery, strings.Join(errs, "; "))
+}
+
+func (e *FanOutError) Is(target error) bool {
+	if target == ErrFailedQuery {
+		return true
+	}
+	for _, err := range e.PeerErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+type peerResponse struct {
+	peer     peer.ID
+	response *pb.HistoryResponse
+	err      error
+}
+
+// fetch retrieves the next page of every peer with more pages whose pending messages
+// don't fill a page. Peers that fail are not queried anymore, and their error is returned
+func (store *WakuStore) fetch(ctx context.Context, q *pb.HistoryQuery, c *CompositeCursor, pageSize uint64) map[peer.ID]error {
+	var wg sync.WaitGroup
+	resultChan := make(chan peerResponse, len(c.cursors))
+
+	for p, cursor := range c.cursors {
+		if uint64(len(c.pending[p])) >= pageSize {
+			continue
+		}
+
+		wg.Add(1)
+		go func(p peer.ID, cursor *pb.Index) {
+			defer wg.Done()
+
+			peerQuery := &pb.HistoryQuery{
+				PubsubTopic:    q.PubsubTopic,
+				ContentFilters: q.ContentFilters,
+				StartTime:      q.StartTime,
+				EndTime:        q.EndTime,
+				PagingInfo: &pb.PagingInfo{
+					PageSize:  pageSize,
+					Direction: q.PagingInfo.Direction,
+					Cursor:    cursor,
+				},
+			}
+
+			response, err := store.queryFrom(ctx, peerQuery, p, protocol.GenerateRequestId())
+			if err == nil {
+				err = responseError(response)
+			}
+
+			resultChan <- peerResponse{peer: p, response: response, err: err}
+		}(p, cursor)
+	}
+
+	wg.Wait()
+	close(resultChan)
+
+	errs := make(map[peer.ID]error)
+	for r := range resultChan {
+		if r.err != nil {
+			store.log.Error("querying peer", logging.HostID("peer", r.peer), zap.Error(r.err))
+			errs[r.peer] = r.err
+			delete(c.cursors, r.peer)
+			continue
+		}
+
+		c.pending[r.peer] = append(c.pending[r.peer], envelopes(r.response, q.PubsubTopic)...)
+
+		// A page with less messages than requested, or without a cursor, is the last one.
+		// A peer returning the cursor it was sent would never reach it
+		pagingInfo := r.response.PagingInfo
+		if uint64(len(r.response.Messages)) < pageSize || pagingInfo == nil || pagingInfo.Cursor == nil || sameCursor(pagingInfo.Cursor, c.cursors[r.peer]) {
+			delete(c.cursors, r.peer)
+		} else {
+			c.cursors[r.peer] = pagingInfo.Cursor
+		}
+	}
+
+	return errs
+}
+
+func sameCursor(c1 *pb.Index, c2 *pb.Index) bool {
+	if c1 == nil || c2 == nil {
+		return c1 == c2
+	}
+	return bytes.Equal(c1.Digest, c2.Digest) && c1.ReceiverTime == c2.ReceiverTime &&
+		c1.SenderTime == c2.SenderTime && c1.PubsubTopic == c2.PubsubTopic
+}
+
+// before returns true if the message env1 returned by p1 goes before the message env2
+// returned by p2. Messages with the same timestamp are ordered by peer and hash, so the
+// pages of a query are always the same
+func before(q *pb.HistoryQuery, p1 peer.ID, env1 *protocol.Envelope, p2 peer.ID, env2 *protocol.Envelope) bool {
+	t1 := env1.Message().Timestamp
+	t2 := env2.Message().Timestamp
+	if t1 != t2 {
+		if q.PagingInfo.Direction == pb.PagingInfo_BACKWARD {
+			return t1 > t2
+		}
+		return t1 < t2
+	}
+
+	if p1 != p2 {
+		return p1 < p2
+	}
+
+	return bytes.Compare(env1.Hash(), env2.Hash()) < 0
+}
+
+// nextEnvelope removes and returns the pending message that goes first among every peer.
+// It returns false if a peer with more pages might still return a message that goes
+// before it, which happens once every pending message of that peer was returned
+func nextEnvelope(q *pb.HistoryQuery, c *CompositeCursor) (*protocol.Envelope, bool) {
+	var first peer.ID
+	found := false
+	for p, envs := range c.pending {
+		if len(envs) == 0 {
+			continue
+		}
+		if !found || before(q, p, envs[0], first, c.pending[first][0]) {
+			first = p
+			found = true
+		}
+	}
+
+	if !found {
+		return nil, false
+	}
+
+	// Every message of the next page of a peer goes after its last pending message, so
+	// the first pending message can't go after the next pages while the peer has some
+	for p := range c.cursors {
+		if len(c.pending[p]) == 0 {
+			return nil, false
+		}
+	}
+
+	env := c.pending[first][0]
+	c.pending[first] = c.pending[first][1:]
+	return env, true
+}
+
+// queryFanOut sends the same query concurrently to each peer of the composite cursor,
+// starting from their respective cursors. The pages of every peer are merged in sender
+// time order and deduplicated, and the messages that can't be returned yet are kept in
+// the composite cursor of the result until the next page is retrieved
+func (store *WakuStore) queryFanOut(ctx context.Context, q *pb.HistoryQuery, cursor *CompositeCursor) (*Result, error) {
+	c := cursor.clone()
+	pageSize := effectivePageSize(q.PagingInfo.PageSize)
+
+	result := &Result{
+		query:           q,
+		compositeCursor: c,
+		PeerErrors:      make(map[peer.ID]error),
+	}
+
+	for uint64(len(result.Envelopes)) < pageSize && !c.Done() {
+		for p, err := range store.fetch(ctx, q, c, pageSize) {
+			result.PeerErrors[p] = err
+		}
+
+		for uint64(len(result.Envelopes)) < pageSize {
+			env, ok := nextEnvelope(q, c)
+			if !ok {
+				break
+			}
+
+			// The hash is calculated locally, since the digest of the cursors returned
+			// by store nodes running older versions is not the canonical message hash
+			key := string(env.Hash())
+			if _, ok := c.seen[key]; ok {
+				continue
+			}
+			c.seen[key] = struct{}{}
+			result.Envelopes = append(result.Envelopes, env)
+		}
+	}
+
+	// Every peer queried failed
+	if len(result.PeerErrors) != 0 && len(result.PeerErrors) == len(cursor.cursors) && len(result.Envelopes) == 0 {
+		return nil, &FanOutError{PeerErrors: result.PeerErrors}
+	}
+
+	for _, env := range result.Envelopes {
+		result.Messages = append(result.Messages, env.Message())
+	}
+
+	return result, nil
+}
@@ -0,0 +1,194 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func addStorePeer(t *testing.T, h host.Host, storeHost host.Host) {
+	h.Peerstore().AddAddr(storeHost.ID(), tests.GetHostAddress(storeHost), peerstore.PermanentAddrTTL)
+	err := h.Peerstore().AddProtocols(storeHost.ID(), string(StoreID_v20beta5), string(StoreID_v20beta4))
+	require.NoError(t, err)
+}
+
+func TestWakuStoreProtocolFanOut(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pubsubTopic1 := "topic1"
+	topic1 := "1"
+
+	msg1 := &pb.WakuMessage{Payload: []byte{1}, ContentTopic: topic1, Timestamp: 1}
+	msg2 := &pb.WakuMessage{Payload: []byte{2}, ContentTopic: topic1, Timestamp: 2}
+	msg3 := &pb.WakuMessage{Payload: []byte{3}, ContentTopic: topic1, Timestamp: 3}
+	msg4 := &pb.WakuMessage{Payload: []byte{4}, ContentTopic: topic1, Timestamp: 4}
+
+	host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s1 := NewWakuStore(host1, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s1.Start(ctx)
+	defer s1.Stop()
+
+	// Each store node has an incomplete history
+	for _, msg := range []*pb.WakuMessage{msg1, msg2, msg3} {
+		require.NoError(t, s1.storeMessage(protocol.NewEnvelope(msg, utils.GetUnixEpoch(), pubsubTopic1)))
+	}
+
+	host2, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s2 := NewWakuStore(host2, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s2.Start(ctx)
+	defer s2.Stop()
+
+	for _, msg := range []*pb.WakuMessage{msg2, msg4} {
+		require.NoError(t, s2.storeMessage(protocol.NewEnvelope(msg, utils.GetUnixEpoch(), pubsubTopic1)))
+	}
+
+	host3, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s3 := NewWakuStore(host3, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s3.Start(ctx)
+	defer s3.Stop()
+
+	addStorePeer(t, host3, host1)
+	addStorePeer(t, host3, host2)
+
+	q := Query{
+		Topic:         pubsubTopic1,
+		ContentTopics: []string{topic1},
+	}
+
+	result, err := s3.Query(ctx, q, WithPeers(host1.ID(), host2.ID()), WithPaging(true, 2))
+	require.NoError(t, err)
+	require.Equal(t, []*pb.WakuMessage{msg1, msg2}, result.Messages)
+	require.Len(t, result.CompositeCursor().Peers(), 2)
+
+	result, err = s3.Next(ctx, result)
+	require.NoError(t, err)
+	require.Equal(t, []*pb.WakuMessage{msg3, msg4}, result.Messages)
+
+	// Depending on the peer msg2 was taken from, the last page of the second peer
+	// might not have been retrieved yet, but it's empty
+	if !result.CompositeCursor().Done() {
+		result, err = s3.Next(ctx, result)
+		require.NoError(t, err)
+		require.Empty(t, result.Messages)
+		require.True(t, result.CompositeCursor().Done())
+	}
+
+	// Resuming from a composite cursor
+	result, err = s3.Query(ctx, q, WithPeers(host1.ID(), host2.ID()), WithPaging(true, 3))
+	require.NoError(t, err)
+	require.Equal(t, []*pb.WakuMessage{msg1, msg2, msg3}, result.Messages)
+
+	result, err = s3.Query(ctx, q, WithCompositeCursor(result.CompositeCursor()), WithPaging(true, 3))
+	require.NoError(t, err)
+	require.Equal(t, []*pb.WakuMessage{msg4}, result.Messages)
+	require.True(t, result.CompositeCursor().Done())
+
+	// Automatic selection of peers in descending order
+	result, err = s3.Query(ctx, q, WithFanOut(2), WithPaging(false, 5))
+	require.NoError(t, err)
+	require.Equal(t, []*pb.WakuMessage{msg4, msg3, msg2, msg1}, result.Messages)
+
+	// A peer that fails is reported, and the results of the other peers are returned
+	require.NoError(t, host2.Close())
+	result, err = s3.Query(ctx, q, WithPeers(host1.ID(), host2.ID()), WithPaging(true, 5))
+	require.NoError(t, err)
+	require.Equal(t, []*pb.WakuMessage{msg1, msg2, msg3}, result.Messages)
+	require.Len(t, result.PeerErrors, 1)
+	require.Contains(t, result.PeerErrors, host2.ID())
+}
+
+func TestWakuStoreFanOutWithoutPeers(t *testing.T) {
+	host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s1 := NewWakuStore(host1, nil, MemoryDB(t), 0, 0, utils.Logger())
+
+	_, err = s1.Query(context.Background(), Query{Topic: "topic1"}, WithFanOut(2))
+	require.Error(t, err)
+}
+
+func TestWakuStoreFanOutPeerErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s1 := NewWakuStore(host1, nil, MemoryDB(t), 0, 0, utils.Logger(), WithDeniedPubsubTopics("topic1"))
+	s1.Start(ctx)
+	defer s1.Stop()
+
+	host2, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s2 := NewWakuStore(host2, nil, MemoryDB(t), 0, 0, utils.Logger(), WithDeniedPubsubTopics("topic1"))
+	s2.Start(ctx)
+	defer s2.Stop()
+
+	host3, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s3 := NewWakuStore(host3, nil, MemoryDB(t), 0, 0, utils.Logger())
+
+	addStorePeer(t, host3, host1)
+	addStorePeer(t, host3, host2)
+
+	// The error of each peer is returned when every peer fails
+	_, err = s3.Query(ctx, Query{Topic: "topic1"}, WithPeers(host1.ID(), host2.ID()))
+	require.ErrorIs(t, err, ErrFailedQuery)
+	require.ErrorIs(t, err, ErrTopicNotServed)
+	require.False(t, errors.Is(err, ErrPaymentRequired))
+
+	var fanOutErr *FanOutError
+	require.True(t, errors.As(err, &fanOutErr))
+	require.Len(t, fanOutErr.PeerErrors, 2)
+}
+
+func TestNextEnvelopeTieBreak(t *testing.T) {
+	q := &pb.HistoryQuery{PagingInfo: &pb.PagingInfo{Direction: pb.PagingInfo_FORWARD}}
+
+	msg1 := &pb.WakuMessage{Payload: []byte{1}, ContentTopic: "1", Timestamp: 1}
+	msg2 := &pb.WakuMessage{Payload: []byte{2}, ContentTopic: "1", Timestamp: 1}
+	msg3 := &pb.WakuMessage{Payload: []byte{3}, ContentTopic: "1", Timestamp: 1}
+
+	var order []*pb.WakuMessage
+	for i := 0; i < 20; i++ {
+		c := newCompositeCursor(nil)
+		c.pending["peerB"] = []*protocol.Envelope{protocol.NewEnvelope(msg1, 0, "topic1")}
+		c.pending["peerA"] = []*protocol.Envelope{protocol.NewEnvelope(msg2, 0, "topic1"), protocol.NewEnvelope(msg3, 0, "topic1")}
+
+		var result []*pb.WakuMessage
+		for {
+			env, ok := nextEnvelope(q, c)
+			if !ok {
+				break
+			}
+			result = append(result, env.Message())
+		}
+
+		// Messages with the same timestamp are returned in the same order every time
+		require.Len(t, result, 3)
+		if order == nil {
+			order = result
+		}
+		require.Equal(t, order, result)
+	}
+
+	require.Equal(t, []*pb.WakuMessage{msg2, msg3, msg1}, order)
+}
@@ -0,0 +1,129 @@
+package store
+
+import (
+	"context"
+
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+)
+
+// ResultIterator is used to lazily retrieve the pages of a store query.
+// Each call to Next retrieves a single page from the store node(s)
+type ResultIterator struct {
+	store  *WakuStore
+	ctx    context.Context
+	query  *pb.HistoryQuery
+	params *HistoryRequestParameters
+
+	page  *Result
+	count int
+	err   error
+	done  bool
+}
+
+// QueryAll creates a ResultIterator that retrieves every page of results of a query,
+// until the last page is reached, the context is cancelled or the maximum number
+// of messages set with WithMaxMessages is retrieved
+func (store *WakuStore) QueryAll(ctx context.Context, query Query, opts ...HistoryRequestOption) (*ResultIterator, error) {
+	q, params, err := store.newHistoryQuery(query, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ResultIterator{
+		store:  store,
+		ctx:    ctx,
+		query:  q,
+		params: params,
+	}, nil
+}
+
+// Next retrieves the next page of results. It returns false when there are no more
+// messages to retrieve or an error occurred. Err should be checked afterwards to
+// distinguish between both cases
+func (it *ResultIterator) Next() bool {
+	if it.done {
+		return false
+	}
+
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		it.done = true
+		return false
+	}
+
+	// The page size is reduced so the last page does not go over the max number of messages,
+	// which keeps the cursor of the last page valid
+	pageSize := effectivePageSize(it.query.PagingInfo.PageSize)
+	if it.params.maxMessages > 0 {
+		remaining := uint64(it.params.maxMessages - it.count)
+		if remaining < pageSize {
+			pageSize = remaining
+		}
+	}
+
+	var result *Result
+	var err error
+	if it.page == nil {
+		it.query.PagingInfo.PageSize = pageSize
+		result, err = it.store.query(it.ctx, it.query, it.params)
+	} else {
+		prev := *it.page
+		prev.query = withPageSize(prev.query, pageSize)
+		result, err = it.store.Next(it.ctx, &prev)
+	}
+
+	if err != nil {
+		it.err = err
+		it.done = true
+		return false
+	}
+
+	// Merged pages from multiple peers might contain more messages than requested
+	if it.params.maxMessages > 0 && uint64(len(result.Messages)) > pageSize {
+		result.Messages = result.Messages[:pageSize]
+		result.Envelopes = result.Envelopes[:pageSize]
+	}
+
+	it.page = result
+	it.count += len(result.Messages)
+	it.done = isLastPage(result, pageSize) || (it.params.maxMessages > 0 && it.count >= it.params.maxMessages)
+
+	return len(result.Messages) != 0
+}
+
+// Page returns the page of results retrieved by the last call to Next
+func (it *ResultIterator) Page() *Result {
+	return it.page
+}
+
+// Count returns the number of messages retrieved so far
+func (it *ResultIterator) Count() int {
+	return it.count
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *ResultIterator) Err() error {
+	return it.err
+}
+
+func withPageSize(q *pb.HistoryQuery, pageSize uint64) *pb.HistoryQuery {
+	return &pb.HistoryQuery{
+		PubsubTopic:    q.PubsubTopic,
+		ContentFilters: q.ContentFilters,
+		StartTime:      q.StartTime,
+		EndTime:        q.EndTime,
+		PagingInfo: &pb.PagingInfo{
+			PageSize:  pageSize,
+			Direction: q.PagingInfo.Direction,
+			Cursor:    q.PagingInfo.Cursor,
+		},
+	}
+}
+
+func isLastPage(r *Result, pageSize uint64) bool {
+	if r.CompositeCursor() != nil {
+		return r.CompositeCursor().Done()
+	}
+
+	return r.Cursor() == nil || uint64(len(r.Messages)) < pageSize
+}
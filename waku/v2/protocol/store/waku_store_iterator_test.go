@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWakuStoreQueryAll(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s1 := NewWakuStore(host1, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s1.Start(ctx)
+	defer s1.Stop()
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, s1.storeMessage(protocol.NewEnvelope(tests.CreateWakuMessage("1", int64(i+1)), utils.GetUnixEpoch(), "test")))
+	}
+
+	host2, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s2 := NewWakuStore(host2, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s2.Start(ctx)
+	defer s2.Stop()
+
+	addStorePeer(t, host2, host1)
+
+	q := Query{
+		Topic:         "test",
+		ContentTopics: []string{"1"},
+	}
+
+	// Every page is retrieved, and the iteration stops on the last page without an extra query
+	it, err := s2.QueryAll(ctx, q, WithPeer(host1.ID()), WithPaging(true, 4))
+	require.NoError(t, err)
+
+	var pageSizes []int
+	var timestamps []int64
+	for it.Next() {
+		require.Equal(t, host1.ID(), it.Page().PeerID())
+		pageSizes = append(pageSizes, len(it.Page().Messages))
+		for _, msg := range it.Page().Messages {
+			timestamps = append(timestamps, msg.Timestamp)
+		}
+	}
+	require.NoError(t, it.Err())
+	require.Equal(t, []int{4, 4, 2}, pageSizes)
+	require.Equal(t, []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, timestamps)
+	require.Equal(t, 10, it.Count())
+
+	// The last page is reduced to not go over the max number of messages
+	it, err = s2.QueryAll(ctx, q, WithPeer(host1.ID()), WithPaging(false, 4), WithMaxMessages(6))
+	require.NoError(t, err)
+
+	pageSizes = nil
+	for it.Next() {
+		pageSizes = append(pageSizes, len(it.Page().Messages))
+	}
+	require.NoError(t, it.Err())
+	require.Equal(t, []int{4, 2}, pageSizes)
+	require.Equal(t, int64(5), it.Page().Cursor().SenderTime)
+
+	// Cancelled contexts stop the iteration
+	cancelledCtx, cancelQuery := context.WithCancel(ctx)
+	it, err = s2.QueryAll(cancelledCtx, q, WithPeer(host1.ID()), WithPaging(true, 4))
+	require.NoError(t, err)
+	require.True(t, it.Next())
+	cancelQuery()
+	require.False(t, it.Next())
+	require.ErrorIs(t, it.Err(), context.Canceled)
+}
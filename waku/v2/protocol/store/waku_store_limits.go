@@ -0,0 +1,155 @@
+package store
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/status-im/go-waku/logging"
+	"github.com/status-im/go-waku/waku/v2/metrics"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"golang.org/x/time/rate"
+)
+
+// minLimiterCleanupInterval is the minimum time between two removals of the rate
+// limiters of the peers that stopped sending queries
+const minLimiterCleanupInterval = time.Minute
+
+type peerLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// peerRateLimiter keeps a token bucket for each peer that sends queries
+type peerRateLimiter struct {
+	sync.Mutex
+
+	limit rate.Limit
+	burst int
+
+	// refillTime is the time an empty bucket takes to be full again. A peer that didn't
+	// send queries for this long has the same tokens as a new one, so its bucket is removed
+	refillTime  time.Duration
+	lastCleanup time.Time
+	limiters    map[peer.ID]*peerLimiter
+}
+
+// newPeerRateLimiter returns nil, which allows every query, if the limit is not set
+func newPeerRateLimiter(limit rate.Limit, burst int) *peerRateLimiter {
+	if limit <= 0 || burst <= 0 {
+		return nil
+	}
+
+	return &peerRateLimiter{
+		limit:       limit,
+		burst:       burst,
+		refillTime:  time.Duration(float64(burst) / float64(limit) * float64(time.Second)),
+		lastCleanup: time.Now(),
+		limiters:    make(map[peer.ID]*peerLimiter),
+	}
+}
+
+// allow consumes a token of the bucket of a peer, and returns false if it's empty
+func (l *peerRateLimiter) allow(p peer.ID) bool {
+	if l == nil {
+		return true
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastCleanup) >= l.refillTime && now.Sub(l.lastCleanup) >= minLimiterCleanupInterval {
+		for id, pl := range l.limiters {
+			if now.Sub(pl.lastSeen) >= l.refillTime {
+				delete(l.limiters, id)
+			}
+		}
+		l.lastCleanup = now
+	}
+
+	pl, ok := l.limiters[p]
+	if !ok {
+		pl = &peerLimiter{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.limiters[p] = pl
+	}
+	pl.lastSeen = now
+
+	return pl.limiter.AllowN(now, 1)
+}
+
+// admitsQuery returns false if a query exceeds the limits of the time range, content
+// filters or page size of the store. now is used as the end of the queries without one.
+// Queries are rejected rather than narrowed, since clients would take a page smaller
+// than requested for the last one, and a shorter time range for the whole history
+func (params *StoreParameters) admitsQuery(query *pb.HistoryQuery, now int64) bool {
+	if params.maxContentFilters != 0 && len(query.ContentFilters) > params.maxContentFilters {
+		return false
+	}
+
+	// A query without a page size is served with pages of MaxPageSize messages
+	if params.maxPageSize != 0 && effectivePageSize(query.PagingInfo.GetPageSize()) > params.maxPageSize {
+		return false
+	}
+
+	if params.maxQueryWindow != 0 {
+		endTime := query.EndTime
+		if endTime == 0 || endTime > now {
+			endTime = now
+		}
+		if query.StartTime == 0 || endTime-query.StartTime > params.maxQueryWindow.Nanoseconds() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// admit returns the error code of a request from a peer that can't be served, because
+// the peer reached the swap disconnect threshold or exceeded its rate limit, or the store
+// is already serving the maximum number of queries. Otherwise, it returns NONE and a
+// function that must be called once the request is served
+func (store *WakuStore) admit(p peer.ID) (pb.HistoryResponse_Error, func()) {
+	if store.swap != nil && !store.swap.ShouldServe(p.Pretty()) {
+		store.log.Info("peer reached the swap disconnect threshold", logging.HostID("peer", p))
+		metrics.RecordStoreError(store.ctx, "paymentRequired")
+		return pb.HistoryResponse_PAYMENT_REQUIRED, func() {}
+	}
+
+	if !store.rateLimiter.allow(p) {
+		store.log.Info("rate limit exceeded", logging.HostID("peer", p))
+		metrics.RecordStoreError(store.ctx, "rateLimited")
+		return pb.HistoryResponse_TOO_MANY_REQUESTS, func() {}
+	}
+
+	if store.querySlots != nil {
+		select {
+		case store.querySlots <- struct{}{}:
+			return pb.HistoryResponse_NONE, func() { <-store.querySlots }
+		default:
+			store.log.Info("too many concurrent queries", logging.HostID("peer", p))
+			metrics.RecordStoreError(store.ctx, "tooManyConcurrentQueries")
+			return pb.HistoryResponse_TOO_MANY_REQUESTS, func() {}
+		}
+	}
+
+	return pb.HistoryResponse_NONE, func() {}
+}
+
+// serveQuery builds the response to a query received from a peer, unless the peer
+// is not admitted. When swap is enabled, the messages returned are debited to the peer,
+// and peers that reached the disconnect threshold are not served in hard mode
+func (store *WakuStore) serveQuery(p peer.ID, query *pb.HistoryQuery, includeMetadata bool) *pb.HistoryResponse {
+	errCode, release := store.admit(p)
+	if errCode != pb.HistoryResponse_NONE {
+		return &pb.HistoryResponse{Error: errCode, PagingInfo: query.GetPagingInfo()}
+	}
+	defer release()
+
+	response := store.findMessages(query, includeMetadata)
+	if store.swap != nil {
+		store.swap.Debit(p.Pretty(), len(response.Messages)+len(response.StoredMessages))
+	}
+
+	return response
+}
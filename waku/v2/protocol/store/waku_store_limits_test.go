@@ -0,0 +1,147 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdmitsQuery(t *testing.T) {
+	now := 10 * time.Hour.Nanoseconds()
+
+	params := new(StoreParameters)
+	WithQueryLimits(time.Hour, 2, 0)(params)
+
+	hour := time.Hour.Nanoseconds()
+	require.True(t, params.admitsQuery(&pb.HistoryQuery{StartTime: now - hour}, now))
+	require.True(t, params.admitsQuery(&pb.HistoryQuery{StartTime: 2 * hour, EndTime: 3 * hour}, now))
+	require.False(t, params.admitsQuery(&pb.HistoryQuery{StartTime: 2 * hour, EndTime: 3*hour + 1}, now))
+	require.False(t, params.admitsQuery(&pb.HistoryQuery{StartTime: now - 2*hour}, now))
+	require.False(t, params.admitsQuery(&pb.HistoryQuery{EndTime: now}, now))
+
+	require.True(t, params.admitsQuery(&pb.HistoryQuery{StartTime: now, ContentFilters: []*pb.ContentFilter{{ContentTopic: "1"}, {ContentTopic: "2"}}}, now))
+	require.False(t, params.admitsQuery(&pb.HistoryQuery{StartTime: now, ContentFilters: []*pb.ContentFilter{{ContentTopic: "1"}, {ContentTopic: "2"}, {ContentTopic: "3"}}}, now))
+
+	params = new(StoreParameters)
+	WithQueryLimits(0, 0, 20)(params)
+
+	require.True(t, params.admitsQuery(&pb.HistoryQuery{StartTime: now, PagingInfo: &pb.PagingInfo{PageSize: 20}}, now))
+	require.False(t, params.admitsQuery(&pb.HistoryQuery{StartTime: now, PagingInfo: &pb.PagingInfo{PageSize: 21}}, now))
+
+	// Queries without a page size ask for MaxPageSize messages per page
+	require.False(t, params.admitsQuery(&pb.HistoryQuery{StartTime: now, PagingInfo: &pb.PagingInfo{}}, now))
+	require.False(t, params.admitsQuery(&pb.HistoryQuery{StartTime: now}, now))
+
+	// Without limits, every query is admitted
+	params = new(StoreParameters)
+	require.True(t, params.admitsQuery(&pb.HistoryQuery{PagingInfo: &pb.PagingInfo{PageSize: 1000}}, now))
+	require.True(t, params.admitsQuery(&pb.HistoryQuery{ContentFilters: make([]*pb.ContentFilter, 100)}, now))
+}
+
+func TestPeerRateLimiter(t *testing.T) {
+	require.True(t, newPeerRateLimiter(0, 0).allow(peer.ID("a")))
+
+	limiter := newPeerRateLimiter(0.1, 2)
+	require.True(t, limiter.allow(peer.ID("a")))
+	require.True(t, limiter.allow(peer.ID("a")))
+	require.False(t, limiter.allow(peer.ID("a")))
+	require.True(t, limiter.allow(peer.ID("b")))
+	require.Len(t, limiter.limiters, 2)
+}
+
+func TestWakuStoreLimits(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s1 := NewWakuStore(host1, nil, MemoryDB(t), 0, 0, utils.Logger(), WithRateLimit(0.1, 2), WithQueryLimits(time.Hour, 0, 0))
+	s1.Start(ctx)
+	defer s1.Stop()
+
+	host2, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s2 := NewWakuStore(host2, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s2.Start(ctx)
+	defer s2.Stop()
+
+	host2.Peerstore().AddAddr(host1.ID(), tests.GetHostAddress(host1), peerstore.PermanentAddrTTL)
+	err = host2.Peerstore().AddProtocols(host1.ID(), string(StoreID_v20beta4))
+	require.NoError(t, err)
+
+	now := utils.GetUnixEpoch()
+
+	_, err = s2.Query(ctx, Query{Topic: "test"}, WithPeer(host1.ID()))
+	require.ErrorIs(t, err, ErrQueryTooLarge)
+
+	_, err = s2.Query(ctx, Query{Topic: "test", StartTime: now - time.Minute.Nanoseconds(), EndTime: now}, WithPeer(host1.ID()))
+	require.NoError(t, err)
+
+	_, err = s2.Query(ctx, Query{Topic: "test", StartTime: now - time.Minute.Nanoseconds(), EndTime: now}, WithPeer(host1.ID()))
+	require.ErrorIs(t, err, ErrTooManyRequests)
+	require.True(t, IsRetryable(err))
+}
+
+func TestWakuStoreMaxPageSize(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s1 := NewWakuStore(host1, nil, MemoryDB(t), 0, 0, utils.Logger(), WithQueryLimits(0, 0, 2))
+	s1.Start(ctx)
+	defer s1.Stop()
+
+	for i := 1; i <= 5; i++ {
+		msg := &pb.WakuMessage{Payload: []byte{byte(i)}, ContentTopic: "1", Timestamp: int64(i)}
+		require.NoError(t, s1.storeMessage(protocol.NewEnvelope(msg, utils.GetUnixEpoch(), "test")))
+	}
+
+	host2, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s2 := NewWakuStore(host2, nil, MemoryDB(t), 0, 0, utils.Logger())
+	addStorePeer(t, host2, host1)
+
+	// A page size larger than the limit of the store node is rejected instead of
+	// returning a shorter page, which would be taken for the last one
+	it, err := s2.QueryAll(ctx, Query{Topic: "test"}, WithPeer(host1.ID()), WithPaging(true, 3))
+	require.NoError(t, err)
+	require.False(t, it.Next())
+	require.ErrorIs(t, it.Err(), ErrQueryTooLarge)
+
+	_, err = s2.Query(ctx, Query{Topic: "test"}, WithPeer(host1.ID()), WithPaging(true, 0))
+	require.ErrorIs(t, err, ErrQueryTooLarge)
+
+	it, err = s2.QueryAll(ctx, Query{Topic: "test"}, WithPeer(host1.ID()), WithPaging(true, 2))
+	require.NoError(t, err)
+	for it.Next() {
+	}
+	require.NoError(t, it.Err())
+	require.Equal(t, 5, it.Count())
+}
+
+func TestWakuStoreMaxConcurrentQueries(t *testing.T) {
+	s := NewWakuStore(nil, nil, MemoryDB(t), 0, 0, utils.Logger(), WithMaxConcurrentQueries(1))
+
+	s.querySlots <- struct{}{}
+	response := s.serveQuery(peer.ID("a"), &pb.HistoryQuery{}, false)
+	require.Equal(t, pb.HistoryResponse_TOO_MANY_REQUESTS, response.Error)
+
+	<-s.querySlots
+	response = s.serveQuery(peer.ID("a"), &pb.HistoryQuery{}, false)
+	require.Equal(t, pb.HistoryResponse_NONE, response.Error)
+	require.Empty(t, s.querySlots)
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+)
+
+// LookupResult contains the messages a store node has from a list of message hashes
+type LookupResult struct {
+	// Found indicates, for each of the hashes, whether the store node has the message
+	Found []bool
+	// Envelopes contains the messages that were found, in the same order as the hashes.
+	// Store nodes that do not support StoreID_v20beta5 do not return the pubsub topic
+	// and receiver time of the messages
+	Envelopes []*protocol.Envelope
+
+	peerId peer.ID
+}
+
+// PeerID returns the ID of the store node that answered the lookup
+func (r *LookupResult) PeerID() peer.ID {
+	return r.peerId
+}
+
+// LookupMessages retrieves from a store node the messages whose hash is in a list.
+// Hashes are the ones returned when publishing a message. The options used to select
+// a single peer are supported, while the ones for sending a query to multiple peers
+// are ignored
+func (store *WakuStore) LookupMessages(ctx context.Context, hashes [][]byte, opts ...HistoryRequestOption) (*LookupResult, error) {
+	return store.lookup(ctx, hashes, false, opts...)
+}
+
+// HasMessages returns whether a store node has the messages whose hash is in a list,
+// without retrieving them
+func (store *WakuStore) HasMessages(ctx context.Context, hashes [][]byte, opts ...HistoryRequestOption) ([]bool, error) {
+	result, err := store.lookup(ctx, hashes, true, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Found, nil
+}
+
+func (store *WakuStore) lookup(ctx context.Context, hashes [][]byte, presenceOnly bool, opts ...HistoryRequestOption) (*LookupResult, error) {
+	if len(hashes) > MaxMessageHashes {
+		return nil, ErrQueryTooLarge
+	}
+
+	q, params, err := store.newHistoryQuery(Query{}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.selectedPeer == "" {
+		return nil, ErrNoPeersAvailable
+	}
+
+	if len(hashes) == 0 {
+		return &LookupResult{peerId: params.selectedPeer}, nil
+	}
+
+	q.MessageHashes = hashes
+	q.PresenceOnly = presenceOnly
+
+	response, err := store.queryFrom(ctx, q, params.selectedPeer, params.requestId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := responseError(response); err != nil {
+		return nil, err
+	}
+
+	// Store nodes that ignore the message hashes answer with a regular page of results
+	if len(response.Presence) != (len(hashes)+7)/8 {
+		return nil, ErrHashLookupNotSupported
+	}
+
+	result := &LookupResult{
+		Found:     make([]bool, len(hashes)),
+		Envelopes: envelopes(response, ""),
+		peerId:    params.selectedPeer,
+	}
+
+	for i := range hashes {
+		result.Found[i] = response.Presence[i/8]&(1<<(i%8)) != 0
+	}
+
+	return result, nil
+}
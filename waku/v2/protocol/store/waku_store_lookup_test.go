@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWakuStoreLookupMessages(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s1 := NewWakuStore(host1, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s1.Start(ctx)
+	defer s1.Stop()
+
+	var envs []*protocol.Envelope
+	for i := 0; i < 10; i++ {
+		msg := tests.CreateWakuMessage("1", int64(i+1))
+		msg.Proof = []byte{byte(i)}
+		env := protocol.NewEnvelope(msg, utils.GetUnixEpoch(), "test")
+		envs = append(envs, env)
+		if i%2 == 0 {
+			require.NoError(t, s1.storeMessage(env))
+		}
+	}
+
+	host2, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s2 := NewWakuStore(host2, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s2.Start(ctx)
+	defer s2.Stop()
+
+	addStorePeer(t, host2, host1)
+
+	// The hash returned when publishing a message is the one used for the lookup
+	publishedHash := pb.MessageHash("test", envs[8].Message())
+
+	hashes := [][]byte{envs[0].Hash(), envs[1].Hash(), envs[2].Hash(), envs[3].Hash(), envs[4].Hash(), envs[5].Hash(), envs[6].Hash(), envs[7].Hash(), publishedHash}
+	expectedFound := []bool{true, false, true, false, true, false, true, false, true}
+
+	result, err := s2.LookupMessages(ctx, hashes, WithPeer(host1.ID()))
+	require.NoError(t, err)
+	require.Equal(t, host1.ID(), result.PeerID())
+	require.Equal(t, expectedFound, result.Found)
+	require.Len(t, result.Envelopes, 5)
+	for i, env := range result.Envelopes {
+		require.Equal(t, envs[i*2].Hash(), env.Hash())
+		require.Equal(t, "test", env.PubsubTopic())
+	}
+
+	found, err := s2.HasMessages(ctx, hashes, WithPeer(host1.ID()))
+	require.NoError(t, err)
+	require.Equal(t, expectedFound, found)
+
+	response := s1.FindMessages(&pb.HistoryQuery{MessageHashes: hashes, PresenceOnly: true})
+	require.Empty(t, response.Messages)
+	require.Equal(t, []byte{0x55, 0x01}, response.Presence)
+
+	tooManyHashes := make([][]byte, MaxMessageHashes+1)
+	_, err = s2.LookupMessages(ctx, tooManyHashes, WithPeer(host1.ID()))
+	require.ErrorIs(t, err, ErrQueryTooLarge)
+}
@@ -0,0 +1,205 @@
+package store
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"golang.org/x/time/rate"
+)
+
+type (
+	// StoreParameters are the settings of a WakuStore
+	StoreParameters struct {
+		allowedPubsubTopics  map[string]struct{}
+		deniedPubsubTopics   map[string]struct{}
+		allowedContentTopics map[string]struct{}
+		deniedContentTopics  map[string]struct{}
+
+		writeBatchSize   int
+		writeBatchWindow time.Duration
+
+		syncInterval     time.Duration
+		syncWindow       time.Duration
+		syncPubsubTopics []string
+		syncPeers        []peer.ID
+
+		rateLimit            rate.Limit
+		rateBurst            int
+		maxConcurrentQueries int
+		maxQueryWindow       time.Duration
+		maxContentFilters    int
+		maxPageSize          uint64
+	}
+
+	// Option is used to configure a WakuStore
+	Option func(*StoreParameters)
+)
+
+func topicSet(set map[string]struct{}, topics []string) map[string]struct{} {
+	if set == nil && len(topics) != 0 {
+		set = make(map[string]struct{})
+	}
+	for _, t := range topics {
+		set[t] = struct{}{}
+	}
+	return set
+}
+
+// WithAllowedPubsubTopics is an Option that restricts the messages stored, and the
+// queries served, to the ones published on a list of pubsub topics
+func WithAllowedPubsubTopics(topics ...string) Option {
+	return func(params *StoreParameters) {
+		params.allowedPubsubTopics = topicSet(params.allowedPubsubTopics, topics)
+	}
+}
+
+// WithDeniedPubsubTopics is an Option that excludes the messages published on a list of
+// pubsub topics from being stored, and rejects the queries for those pubsub topics
+func WithDeniedPubsubTopics(topics ...string) Option {
+	return func(params *StoreParameters) {
+		params.deniedPubsubTopics = topicSet(params.deniedPubsubTopics, topics)
+	}
+}
+
+// WithAllowedContentTopics is an Option that restricts the messages stored, and the
+// queries served, to the ones with a content topic from a list
+func WithAllowedContentTopics(topics ...string) Option {
+	return func(params *StoreParameters) {
+		params.allowedContentTopics = topicSet(params.allowedContentTopics, topics)
+	}
+}
+
+// WithDeniedContentTopics is an Option that excludes the messages with a content topic
+// from a list from being stored, and rejects the queries for those content topics
+func WithDeniedContentTopics(topics ...string) Option {
+	return func(params *StoreParameters) {
+		params.deniedContentTopics = topicSet(params.deniedContentTopics, topics)
+	}
+}
+
+// DefaultWriteBatchSize is the default maximum number of received messages stored with a single write
+const DefaultWriteBatchSize = 100
+
+// DefaultWriteBatchWindow is the default maximum time a received message waits for
+// other messages to be stored with it. Messages are not delayed by default: the ones
+// that arrived while the previous batch was written are stored together
+const DefaultWriteBatchWindow = 0
+
+// WithWriteBatch is an Option that sets the maximum number of received messages that
+// are stored with a single write, and the maximum time a message waits for other
+// messages before the batch is written
+func WithWriteBatch(size int, window time.Duration) Option {
+	return func(params *StoreParameters) {
+		if size > 0 {
+			params.writeBatchSize = size
+		}
+		if window >= 0 {
+			params.writeBatchWindow = window
+		}
+	}
+}
+
+// DefaultSyncWindow is the default time range, ending now, over which the messages
+// are synchronized with other store nodes
+const DefaultSyncWindow = time.Hour
+
+// WithSync is an Option that synchronizes periodically the messages published on a
+// list of pubsub topics during the last window with other store nodes, to fill the
+// gaps caused by downtime or network partitions. An interval of 0 disables it. The
+// sync requests of other store nodes are only served when it's enabled, or when the
+// sync peers are set with WithSyncPeers
+func WithSync(interval time.Duration, window time.Duration, pubsubTopics ...string) Option {
+	return func(params *StoreParameters) {
+		params.syncInterval = interval
+		if window > 0 {
+			params.syncWindow = window
+		}
+		params.syncPubsubTopics = append(params.syncPubsubTopics, pubsubTopics...)
+	}
+}
+
+// WithSyncPeers is an Option that sets the store nodes the periodic synchronization is
+// done with. By default, a random peer that supports StoreSyncID_v10beta1 is chosen each
+// time. When set, only these peers can synchronize their messages with this store node
+func WithSyncPeers(peers ...peer.ID) Option {
+	return func(params *StoreParameters) {
+		params.syncPeers = append(params.syncPeers, peers...)
+	}
+}
+
+// WithRateLimit is an Option that limits the number of queries each peer can send to
+// the store node, with a token bucket that allows bursts of up to burst queries and
+// refills at queriesPerSecond. Queries over the limit are rejected with TOO_MANY_REQUESTS
+func WithRateLimit(queriesPerSecond float64, burst int) Option {
+	return func(params *StoreParameters) {
+		if queriesPerSecond > 0 && burst > 0 {
+			params.rateLimit = rate.Limit(queriesPerSecond)
+			params.rateBurst = burst
+		}
+	}
+}
+
+// WithMaxConcurrentQueries is an Option that sets the maximum number of queries the
+// store node serves at the same time, from all peers. Queries received while the
+// maximum is reached are rejected with TOO_MANY_REQUESTS
+func WithMaxConcurrentQueries(n int) Option {
+	return func(params *StoreParameters) {
+		if n > 0 {
+			params.maxConcurrentQueries = n
+		}
+	}
+}
+
+// WithQueryLimits is an Option that rejects with QUERY_TOO_LARGE the queries whose time
+// range is longer than maxWindow, or not bounded when maxWindow is set, the queries with
+// more than maxContentFilters content filters, and the queries for pages larger than
+// maxPageSize. A value of 0 keeps the default limit: no time window, no limit on the
+// content filters, and MaxPageSize, with larger pages truncated instead of rejected
+func WithQueryLimits(maxWindow time.Duration, maxContentFilters int, maxPageSize uint64) Option {
+	return func(params *StoreParameters) {
+		if maxWindow > 0 {
+			params.maxQueryWindow = maxWindow
+		}
+		if maxContentFilters > 0 {
+			params.maxContentFilters = maxContentFilters
+		}
+		if maxPageSize > 0 {
+			params.maxPageSize = maxPageSize
+		}
+	}
+}
+
+func inScope(topic string, allowed map[string]struct{}, denied map[string]struct{}) bool {
+	if _, ok := denied[topic]; ok {
+		return false
+	}
+	if allowed == nil {
+		return true
+	}
+	_, ok := allowed[topic]
+	return ok
+}
+
+// shouldStore returns true if a message is published on the topics archived by the store
+func (params *StoreParameters) shouldStore(env *protocol.Envelope) bool {
+	return inScope(env.PubsubTopic(), params.allowedPubsubTopics, params.deniedPubsubTopics) &&
+		inScope(env.Message().ContentTopic, params.allowedContentTopics, params.deniedContentTopics)
+}
+
+// servesQuery returns false if a query requests a pubsub topic or a content topic
+// that is not archived by the store. Queries that don't specify topics are always served
+func (params *StoreParameters) servesQuery(query *pb.HistoryQuery) bool {
+	if query.PubsubTopic != "" && !inScope(query.PubsubTopic, params.allowedPubsubTopics, params.deniedPubsubTopics) {
+		return false
+	}
+
+	for _, cf := range query.ContentFilters {
+		if cf.ContentTopic != "" && !inScope(cf.ContentTopic, params.allowedContentTopics, params.deniedContentTopics) {
+			return false
+		}
+	}
+
+	return true
+}
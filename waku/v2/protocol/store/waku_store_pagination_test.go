@@ -37,6 +37,18 @@ func TestIndexComputation(t *testing.T) {
 	idx2 := protocol.NewEnvelope(msg2, utils.GetUnixEpoch(), "test").Index()
 
 	require.Equal(t, idx1.Digest, idx2.Digest)
+
+	// Messages with the same content topic and payload are different if any other field is different
+	msg3 := &pb.WakuMessage{
+		Payload:      []byte{1, 2, 3},
+		Timestamp:    124,
+		ContentTopic: "/waku/2/default-content/proto",
+	}
+	idx3 := protocol.NewEnvelope(msg3, utils.GetUnixEpoch(), "test").Index()
+	require.NotEqual(t, idx1.Digest, idx3.Digest)
+
+	idx4 := protocol.NewEnvelope(msg1, utils.GetUnixEpoch(), "test2").Index()
+	require.NotEqual(t, idx1.Digest, idx4.Digest)
 }
 
 func createSampleList(s int) []*protocol.Envelope {
@@ -1,8 +1,13 @@
 package store
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/libp2p/go-libp2p"
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/persistence"
 	"github.com/status-im/go-waku/waku/v2/protocol"
 	"github.com/status-im/go-waku/waku/v2/protocol/pb"
 	"github.com/status-im/go-waku/waku/v2/utils"
@@ -34,3 +39,35 @@ func TestStorePersistence(t *testing.T) {
 	err = s1.storeMessage(protocol.NewEnvelope(msg, utils.GetUnixEpoch(), defaultPubSubTopic))
 	require.Error(t, err)
 }
+
+func TestStoreIncomingMessagesInBatches(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	db := MemoryDB(t)
+	s := NewWakuStore(host, nil, db, 0, 0, utils.Logger(), WithWriteBatch(3, 100*time.Millisecond), WithDeniedContentTopics("denied"))
+	s.Start(ctx)
+
+	for i := 0; i < 5; i++ {
+		s.MsgC <- protocol.NewEnvelope(tests.CreateWakuMessage("1", int64(i+1)), utils.GetUnixEpoch(), "test")
+	}
+	s.MsgC <- protocol.NewEnvelope(tests.CreateWakuMessage("denied", 6), utils.GetUnixEpoch(), "test")
+	s.MsgC <- protocol.NewEnvelope(tests.CreateWakuMessage("1", 1), utils.GetUnixEpoch(), "test")
+
+	// Stopping the store writes the messages that are waiting in the channel
+	s.Stop()
+
+	allMsgs, err := db.GetAll()
+	require.NoError(t, err)
+	require.Len(t, allMsgs, 5)
+
+	errs := s.storeMessages([]*protocol.Envelope{
+		protocol.NewEnvelope(tests.CreateWakuMessage("1", 6), utils.GetUnixEpoch(), "test"),
+		protocol.NewEnvelope(tests.CreateWakuMessage("1", utils.GetUnixEpoch()+int64(time.Minute)), utils.GetUnixEpoch(), "test"),
+		protocol.NewEnvelope(tests.CreateWakuMessage("1", 1), utils.GetUnixEpoch(), "test"),
+	})
+	require.Equal(t, []error{nil, ErrFutureMessage, persistence.ErrDuplicateMessage}, errs)
+}
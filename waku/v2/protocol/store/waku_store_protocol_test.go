@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p-core/peerstore"
@@ -125,3 +126,152 @@ func TestWakuStoreProtocolNext(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, response.Messages, 0)
 }
+
+func TestWakuStoreProtocolMessageMetadata(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s1 := NewWakuStore(host1, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s1.Start(ctx)
+	defer s1.Stop()
+
+	msg1 := tests.CreateWakuMessage("1", 1)
+	msg2 := tests.CreateWakuMessage("1", 2)
+
+	env1 := protocol.NewEnvelope(msg1, 10, "topic1")
+	env2 := protocol.NewEnvelope(msg2, 20, "topic2")
+	require.NoError(t, s1.storeMessage(env1))
+	require.NoError(t, s1.storeMessage(env2))
+
+	host2, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s2 := NewWakuStore(host2, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s2.Start(ctx)
+	defer s2.Stop()
+
+	host2.Peerstore().AddAddr(host1.ID(), tests.GetHostAddress(host1), peerstore.PermanentAddrTTL)
+	err = host2.Peerstore().AddProtocols(host1.ID(), string(StoreID_v20beta5))
+	require.NoError(t, err)
+
+	// Messages from every pubsub topic are returned with their metadata
+	result, err := s2.Query(ctx, Query{ContentTopics: []string{"1"}}, WithPeer(host1.ID()))
+	require.NoError(t, err)
+	require.Equal(t, []*pb.WakuMessage{msg1, msg2}, result.Messages)
+	require.Len(t, result.Envelopes, 2)
+	for i, env := range []*protocol.Envelope{env1, env2} {
+		require.Equal(t, env.Message(), result.Envelopes[i].Message())
+		require.Equal(t, env.PubsubTopic(), result.Envelopes[i].PubsubTopic())
+		require.Equal(t, env.Index().ReceiverTime, result.Envelopes[i].Index().ReceiverTime)
+		require.Equal(t, env.Hash(), result.Envelopes[i].Hash())
+	}
+
+	// Responses to StoreID_v20beta4 queries do not include the metadata
+	response := s1.FindMessages(&pb.HistoryQuery{PubsubTopic: "topic1"})
+	require.Equal(t, []*pb.WakuMessage{msg1}, response.Messages)
+	require.Empty(t, response.StoredMessages)
+
+	envs := envelopes(response, "topic1")
+	require.Len(t, envs, 1)
+	require.Equal(t, "topic1", envs[0].PubsubTopic())
+	require.Equal(t, int64(0), envs[0].Index().ReceiverTime)
+	require.Equal(t, env1.Hash(), envs[0].Hash())
+}
+
+func TestWakuStoreProtocolErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	db := MemoryDB(t)
+	s1 := NewWakuStore(host1, nil, db, 0, 0, utils.Logger(), WithQueryLimits(0, 10, 0))
+	s1.Start(ctx)
+	defer s1.Stop()
+
+	require.NoError(t, s1.storeMessage(protocol.NewEnvelope(tests.CreateWakuMessage("1", 1), 1, "test")))
+
+	host2, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s2 := NewWakuStore(host2, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s2.Start(ctx)
+	defer s2.Stop()
+
+	addStorePeer(t, host2, host1)
+
+	var contentTopics []string
+	for i := 0; i <= 10; i++ {
+		contentTopics = append(contentTopics, "1")
+	}
+
+	_, err = s2.Query(ctx, Query{Topic: "test", ContentTopics: contentTopics}, WithPeer(host1.ID()))
+	require.ErrorIs(t, err, ErrQueryTooLarge)
+	require.False(t, IsRetryable(err))
+
+	_, err = s2.Query(ctx, Query{Topic: "test"}, WithPeer(host1.ID()), WithCursor(&pb.Index{Digest: []byte{1}, ReceiverTime: 1, SenderTime: 1, PubsubTopic: "test"}))
+	require.ErrorIs(t, err, ErrInvalidCursor)
+	require.False(t, IsRetryable(err))
+
+	// Failures of the message provider are reported to the client
+	db.Stop()
+	_, err = s2.Query(ctx, Query{Topic: "test"}, WithPeer(host1.ID()))
+	require.ErrorIs(t, err, ErrInternal)
+	require.True(t, IsRetryable(err))
+}
+
+func TestWakuStoreTopicScope(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	db := MemoryDB(t)
+	s1 := NewWakuStore(host1, nil, db, 0, 0, utils.Logger(), WithAllowedPubsubTopics("test"), WithDeniedContentTopics("secret"))
+	s1.Start(ctx)
+	defer s1.Stop()
+
+	// Messages are processed in order, so the ones out of scope were discarded
+	// once the last message is stored
+	s1.MessageChannel() <- protocol.NewEnvelope(tests.CreateWakuMessage("1", 1), 1, "other")
+	s1.MessageChannel() <- protocol.NewEnvelope(tests.CreateWakuMessage("secret", 2), 2, "test")
+	s1.MessageChannel() <- protocol.NewEnvelope(tests.CreateWakuMessage("1", 3), 3, "test")
+	require.Eventually(t, func() bool {
+		msgs, err := db.GetAll()
+		return err == nil && len(msgs) != 0
+	}, 2*time.Second, 10*time.Millisecond)
+
+	msgs, err := db.GetAll()
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	require.Equal(t, int64(3), msgs[0].Message.Timestamp)
+
+	host2, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s2 := NewWakuStore(host2, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s2.Start(ctx)
+	defer s2.Stop()
+
+	addStorePeer(t, host2, host1)
+
+	_, err = s2.Query(ctx, Query{Topic: "other"}, WithPeer(host1.ID()))
+	require.ErrorIs(t, err, ErrTopicNotServed)
+
+	_, err = s2.Query(ctx, Query{ContentTopics: []string{"1", "secret"}}, WithPeer(host1.ID()))
+	require.ErrorIs(t, err, ErrTopicNotServed)
+
+	// Queries without topics are served
+	result, err := s2.Query(ctx, Query{}, WithPeer(host1.ID()))
+	require.NoError(t, err)
+	require.Len(t, result.Messages, 1)
+
+	result, err = s2.Query(ctx, Query{Topic: "test", ContentTopics: []string{"1"}}, WithPeer(host1.ID()))
+	require.NoError(t, err)
+	require.Len(t, result.Messages, 1)
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+)
+
+// Stats retrieves from a store node the number of messages that match a query, the
+// range of their sender timestamps, and the same statistics per content topic. The
+// options used to select a single peer are supported, while the paging options are ignored
+func (store *WakuStore) Stats(ctx context.Context, query Query, opts ...HistoryRequestOption) (*pb.HistoryStats, error) {
+	q, params, err := store.newHistoryQuery(query, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.selectedPeer == "" {
+		return nil, ErrNoPeersAvailable
+	}
+
+	q.PagingInfo = nil
+	q.StatsOnly = true
+
+	response, err := store.queryFrom(ctx, q, params.selectedPeer, params.requestId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := responseError(response); err != nil {
+		return nil, err
+	}
+
+	// Store nodes that ignore statsOnly answer with a regular page of results
+	if response.Stats == nil {
+		return nil, ErrStatsNotSupported
+	}
+
+	return response.Stats, nil
+}
+
+// LocalStats returns the statistics of the messages stored by this node that match a query
+func (store *WakuStore) LocalStats(query Query) (*pb.HistoryStats, error) {
+	if store.msgProvider == nil {
+		return nil, ErrNoMessageProvider
+	}
+
+	q := &pb.HistoryQuery{
+		PubsubTopic: query.Topic,
+		StartTime:   query.StartTime,
+		EndTime:     query.EndTime,
+	}
+	for _, cf := range query.ContentTopics {
+		q.ContentFilters = append(q.ContentFilters, &pb.ContentFilter{ContentTopic: cf})
+	}
+
+	return store.msgProvider.Stats(q)
+}
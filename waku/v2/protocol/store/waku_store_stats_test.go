@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWakuStoreStats(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s1 := NewWakuStore(host1, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s1.Start(ctx)
+	defer s1.Stop()
+
+	for i := 1; i <= 10; i++ {
+		contentTopic := "1"
+		if i%3 == 0 {
+			contentTopic = "2"
+		}
+		pubsubTopic := "test"
+		if i > 8 {
+			pubsubTopic = "other"
+		}
+		require.NoError(t, s1.storeMessage(protocol.NewEnvelope(tests.CreateWakuMessage(contentTopic, int64(i)), utils.GetUnixEpoch(), pubsubTopic)))
+	}
+
+	host2, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s2 := NewWakuStore(host2, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s2.Start(ctx)
+	defer s2.Stop()
+
+	addStorePeer(t, host2, host1)
+
+	stats, err := s2.Stats(ctx, Query{Topic: "test"}, WithPeer(host1.ID()))
+	require.NoError(t, err)
+	require.Equal(t, uint64(8), stats.Count)
+	require.Equal(t, int64(1), stats.OldestTimestamp)
+	require.Equal(t, int64(8), stats.NewestTimestamp)
+	require.Equal(t, []*pb.ContentTopicStats{
+		{ContentTopic: "1", Count: 6, OldestTimestamp: 1, NewestTimestamp: 8},
+		{ContentTopic: "2", Count: 2, OldestTimestamp: 3, NewestTimestamp: 6},
+	}, stats.ContentTopics)
+
+	stats, err = s2.Stats(ctx, Query{ContentTopics: []string{"2"}, StartTime: 4}, WithPeer(host1.ID()))
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), stats.Count)
+	require.Equal(t, int64(6), stats.OldestTimestamp)
+	require.Equal(t, int64(9), stats.NewestTimestamp)
+
+	// Queries without matching messages return empty statistics
+	stats, err = s2.Stats(ctx, Query{Topic: "unknown"}, WithPeer(host1.ID()))
+	require.NoError(t, err)
+	require.Zero(t, stats.Count)
+	require.Empty(t, stats.ContentTopics)
+
+	stats, err = s1.LocalStats(Query{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), stats.Count)
+
+	_, err = NewWakuStore(host2, nil, nil, 0, 0, utils.Logger()).LocalStats(Query{})
+	require.ErrorIs(t, err, ErrNoMessageProvider)
+}
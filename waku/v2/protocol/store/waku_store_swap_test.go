@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/swap"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWakuStoreSwap(t *testing.T) {
+	for _, mode := range []int{swap.SoftMode, swap.MockMode, swap.HardMode} {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+		require.NoError(t, err)
+
+		swap1 := swap.NewWakuSwap(nil, utils.Logger(), swap.WithMode(mode), swap.WithThreshold(10, -5))
+		s1 := NewWakuStore(host1, swap1, MemoryDB(t), 0, 0, utils.Logger())
+		s1.Start(ctx)
+		defer s1.Stop()
+
+		for i := 1; i <= 3; i++ {
+			require.NoError(t, s1.storeMessage(protocol.NewEnvelope(tests.CreateWakuMessage("test", int64(i)), utils.GetUnixEpoch(), "test")))
+		}
+
+		host2, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+		require.NoError(t, err)
+
+		swap2 := swap.NewWakuSwap(nil, utils.Logger(), swap.WithMode(mode), swap.WithThreshold(10, -5))
+		s2 := NewWakuStore(host2, swap2, MemoryDB(t), 0, 0, utils.Logger())
+		s2.Start(ctx)
+		defer s2.Stop()
+
+		host2.Peerstore().AddAddr(host1.ID(), tests.GetHostAddress(host1), peerstore.PermanentAddrTTL)
+		err = host2.Peerstore().AddProtocols(host1.ID(), string(StoreID_v20beta4))
+		require.NoError(t, err)
+
+		result, err := s2.Query(ctx, Query{Topic: "test"}, WithPeer(host1.ID()))
+		require.NoError(t, err)
+		require.Len(t, result.Messages, 3)
+
+		// The store node debits the requester, and the requester credits the store node
+		require.Equal(t, 3, swap1.Accounting[host2.ID().Pretty()])
+		require.Equal(t, -3, swap2.Accounting[host1.ID().Pretty()])
+
+		// The balance of the requester reaches the disconnect threshold. In hard mode,
+		// the store node closes the connection after sending the response
+		_, err = s2.Query(ctx, Query{Topic: "test"}, WithPeer(host1.ID()))
+		require.NoError(t, err)
+		require.Equal(t, 6, swap1.Accounting[host2.ID().Pretty()])
+
+		disconnected := func() bool {
+			return host1.Network().Connectedness(host2.ID()) != network.Connected
+		}
+		if mode == swap.HardMode {
+			require.Eventually(t, disconnected, 5*time.Second, 50*time.Millisecond)
+		}
+
+		_, err = s2.Query(ctx, Query{Topic: "test"}, WithPeer(host1.ID()))
+		if mode != swap.HardMode {
+			require.NoError(t, err)
+			require.Equal(t, 9, swap1.Accounting[host2.ID().Pretty()])
+			require.Equal(t, -9, swap2.Accounting[host1.ID().Pretty()])
+			continue
+		}
+
+		// In hard mode, the requester is refused and disconnected again
+		require.ErrorIs(t, err, ErrPaymentRequired)
+		require.Equal(t, 6, swap1.Accounting[host2.ID().Pretty()])
+		require.Equal(t, -6, swap2.Accounting[host1.ID().Pretty()])
+		require.Eventually(t, disconnected, 5*time.Second, 50*time.Millisecond)
+	}
+}
@@ -0,0 +1,635 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	libp2pProtocol "github.com/libp2p/go-libp2p-core/protocol"
+	"github.com/libp2p/go-msgio/protoio"
+	"go.uber.org/zap"
+
+	"github.com/status-im/go-waku/logging"
+	"github.com/status-im/go-waku/waku/persistence"
+	"github.com/status-im/go-waku/waku/v2/metrics"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/utils"
+)
+
+// StoreSyncID_v10beta1 is the protocol identifier used by store nodes to reconcile
+// the messages they have stored
+const StoreSyncID_v10beta1 = libp2pProtocol.ID("/vac/waku/store-sync/1.0.0-beta1")
+
+// MaxSyncRanges is the maximum number of ranges compared in a single sync request
+const MaxSyncRanges = 64
+
+// syncHashListSize is the maximum number of messages in a range whose hashes are
+// listed by the responder, instead of splitting the range
+const syncHashListSize = 64
+
+// syncSplit is the number of subranges a range is split into
+const syncSplit = 8
+
+// MaxSyncMissingHashes is the maximum number of hashes of missing messages a store
+// node fetches at the end of a sync. The remaining ones are fetched by the next sync
+const MaxSyncMissingHashes = 1000
+
+// MaxSyncRounds is the maximum number of sync requests sent to a store node during a
+// Sync, which bounds the syncs with a peer that keeps splitting the ranges
+const MaxSyncRounds = 1024
+
+// MaxSyncPendingRanges is the maximum number of ranges that remain to be compared during
+// a Sync. Time ranges with more differences should be synchronized in shorter ones
+const MaxSyncPendingRanges = 4096
+
+// ErrInvalidSyncResponse is returned when the response of a store node to a sync
+// request does not correspond to the request
+var ErrInvalidSyncResponse = errors.New("invalid sync response")
+
+// SyncResult contains the number of messages exchanged with a store node during a Sync
+type SyncResult struct {
+	PubsubTopic string
+	PeerID      peer.ID
+	// Fetched is the number of messages that were missing and have been stored
+	Fetched int
+	// Offered is the number of messages the peer was missing, which it fetches from this node
+	Offered int
+	// Rounds is the number of requests needed to find the missing messages
+	Rounds int
+}
+
+// rangeMessages calls fn with the sender timestamp and hash of each stored message
+// published on a pubsub topic, with a sender timestamp in [start, end). The bounds are
+// checked here since the time range of a query doesn't include every message sent at
+// its start and end time
+func (store *WakuStore) rangeMessages(pubsubTopic string, start int64, end int64, fn func(timestamp int64, hash []byte)) error {
+	query := &pb.HistoryQuery{
+		PubsubTopic: pubsubTopic,
+		StartTime:   start - 1,
+		EndTime:     end,
+	}
+	if start <= 1 {
+		query.StartTime = 0
+	}
+
+	return store.msgProvider.Iterate(query, func(msg persistence.StoredMessage) error {
+		if msg.Message.Timestamp >= start && msg.Message.Timestamp < end {
+			fn(msg.Message.Timestamp, pb.MessageHash(msg.PubsubTopic, msg.Message))
+		}
+		return nil
+	})
+}
+
+// fingerprints returns the number of stored messages, and the XOR of their hashes, for
+// each range of a list sorted by start time whose ranges don't overlap
+func (store *WakuStore) fingerprints(pubsubTopic string, ranges []*pb.SyncRange) ([]*pb.SyncRange, error) {
+	result := make([]*pb.SyncRange, len(ranges))
+	for i, r := range ranges {
+		result[i] = &pb.SyncRange{Start: r.Start, End: r.End, Fingerprint: make([]byte, 32)}
+	}
+
+	if len(ranges) == 0 {
+		return result, nil
+	}
+
+	err := store.rangeMessages(pubsubTopic, ranges[0].Start, ranges[len(ranges)-1].End, func(timestamp int64, hash []byte) {
+		i := sort.Search(len(result), func(i int) bool { return result[i].End > timestamp })
+		if i == len(result) || result[i].Start > timestamp {
+			return
+		}
+		result[i].Count++
+		for j := range hash {
+			result[i].Fingerprint[j] ^= hash[j]
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// rangeHashes returns the hashes of the stored messages of a range
+func (store *WakuStore) rangeHashes(pubsubTopic string, r *pb.SyncRange) ([][]byte, error) {
+	var result [][]byte
+	err := store.rangeMessages(pubsubTopic, r.Start, r.End, func(timestamp int64, hash []byte) {
+		result = append(result, hash)
+	})
+	return result, err
+}
+
+// splitRange splits a range in up to syncSplit subranges of the same duration
+func splitRange(r *pb.SyncRange) []*pb.SyncRange {
+	n := int64(syncSplit)
+	duration := r.End - r.Start
+	if duration < n {
+		n = duration
+	}
+
+	result := make([]*pb.SyncRange, n)
+	start := r.Start
+	for i := int64(0); i < n; i++ {
+		end := r.Start + duration/n*(i+1)
+		if i == n-1 {
+			end = r.End
+		}
+		result[i] = &pb.SyncRange{Start: start, End: end}
+		start = end
+	}
+	return result
+}
+
+func sameFingerprint(a *pb.SyncRange, b *pb.SyncRange) bool {
+	return a.Count == b.Count && bytes.Equal(a.Fingerprint, b.Fingerprint)
+}
+
+// compareRange compares a range of a sync request with the stored messages. Ranges
+// that differ are split, unless they contain few messages, whose hashes are returned
+func (store *WakuStore) compareRange(pubsubTopic string, r *pb.SyncRange) (*pb.SyncRangeResult, error) {
+	subranges := splitRange(r)
+	local, err := store.fingerprints(pubsubTopic, subranges)
+	if err != nil {
+		return nil, err
+	}
+
+	total := &pb.SyncRange{Fingerprint: make([]byte, 32)}
+	for _, s := range local {
+		total.Count += s.Count
+		for j := range s.Fingerprint {
+			total.Fingerprint[j] ^= s.Fingerprint[j]
+		}
+	}
+
+	if sameFingerprint(total, r) {
+		return &pb.SyncRangeResult{Match: true}, nil
+	}
+
+	if total.Count <= syncHashListSize || len(local) == 1 {
+		hashes, err := store.rangeHashes(pubsubTopic, r)
+		if err != nil {
+			return nil, err
+		}
+		return &pb.SyncRangeResult{Hashes: hashes}, nil
+	}
+
+	return &pb.SyncRangeResult{Subranges: local}, nil
+}
+
+// syncResponse compares the ranges of a sync request with the stored messages
+func (store *WakuStore) syncResponse(request *pb.SyncRequest) *pb.SyncResponse {
+	if len(request.Ranges) > MaxSyncRanges {
+		metrics.RecordStoreError(store.ctx, "queryTooLarge")
+		return &pb.SyncResponse{Error: pb.HistoryResponse_QUERY_TOO_LARGE}
+	}
+
+	if !store.params.servesQuery(&pb.HistoryQuery{PubsubTopic: request.PubsubTopic}) {
+		metrics.RecordStoreError(store.ctx, "topicNotServed")
+		return &pb.SyncResponse{Error: pb.HistoryResponse_TOPIC_NOT_SERVED}
+	}
+
+	response := new(pb.SyncResponse)
+	for _, r := range request.Ranges {
+		if r.End <= r.Start {
+			return &pb.SyncResponse{Error: pb.HistoryResponse_QUERY_TOO_LARGE}
+		}
+
+		result, err := store.compareRange(request.PubsubTopic, r)
+		if err != nil {
+			store.log.Error("comparing sync range", zap.Error(err))
+			metrics.RecordStoreError(store.ctx, "syncFailure")
+			return &pb.SyncResponse{Error: pb.HistoryResponse_INTERNAL}
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	return response
+}
+
+// syncEnabled returns true if the store synchronizes its messages with other store
+// nodes, periodically or with the configured peers, in which case it serves sync requests
+func (params *StoreParameters) syncEnabled() bool {
+	return params.syncInterval > 0 || len(params.syncPeers) != 0
+}
+
+// acceptsSyncPeer returns false if the sync peers are configured and a peer is not one of them
+func (params *StoreParameters) acceptsSyncPeer(p peer.ID) bool {
+	if len(params.syncPeers) == 0 {
+		return true
+	}
+
+	for _, syncPeer := range params.syncPeers {
+		if syncPeer == p {
+			return true
+		}
+	}
+	return false
+}
+
+// inSyncRanges returns true if a timestamp is in one of a list of ranges
+func inSyncRanges(ranges []*pb.SyncRange, timestamp int64) bool {
+	for _, r := range ranges {
+		if timestamp >= r.Start && timestamp < r.End {
+			return true
+		}
+	}
+	return false
+}
+
+func (store *WakuStore) onSyncRequest(s network.Stream) {
+	defer s.Close()
+	peerID := s.Conn().RemotePeer()
+	logger := store.log.With(logging.HostID("peer", peerID))
+
+	if !store.params.acceptsSyncPeer(peerID) {
+		logger.Info("rejecting sync request from peer that is not a sync peer")
+		metrics.RecordStoreError(store.ctx, "syncPeerNotAllowed")
+		_ = s.Reset()
+		return
+	}
+
+	writer := protoio.NewDelimitedWriter(s)
+	reader := protoio.NewDelimitedReader(s, math.MaxInt32)
+
+	// The ranges whose hashes were sent to the peer during this session, by pubsub
+	// topic. Only the messages in those ranges can be missing from this node
+	reported := make(map[string][]*pb.SyncRange)
+
+	for {
+		syncRPC := &pb.SyncRPC{}
+		err := reader.ReadMsg(syncRPC)
+		if err != nil {
+			if err != io.EOF {
+				logger.Error("reading sync request", zap.Error(err))
+				metrics.RecordStoreError(store.ctx, "decodeRPCFailure")
+			}
+			return
+		}
+
+		request := syncRPC.Request
+		if request == nil {
+			request = new(pb.SyncRequest)
+		}
+
+		errCode := pb.HistoryResponse_SERVICE_UNAVAILABLE
+		release := func() {}
+		if store.started {
+			errCode, release = store.admit(peerID)
+		}
+
+		// The last request contains the hashes of the messages this node is missing
+		if len(request.Ranges) == 0 {
+			release()
+			ranges := reported[request.PubsubTopic]
+			if len(request.MissingHashes) != 0 && len(ranges) != 0 && errCode == pb.HistoryResponse_NONE {
+				hashes := request.MissingHashes
+				if len(hashes) > MaxSyncMissingHashes {
+					hashes = hashes[:MaxSyncMissingHashes]
+				}
+
+				store.wg.Add(1)
+				go func() {
+					defer store.wg.Done()
+					store.fetchMissing(store.ctx, peerID, request.PubsubTopic, hashes, ranges)
+				}()
+			}
+			return
+		}
+
+		response := &pb.SyncResponse{Error: errCode}
+		if errCode == pb.HistoryResponse_NONE {
+			response = store.syncResponse(request)
+			for i, result := range response.Results {
+				if !result.Match && len(result.Subranges) == 0 {
+					reported[request.PubsubTopic] = append(reported[request.PubsubTopic], request.Ranges[i])
+				}
+			}
+		}
+		release()
+
+		err = writer.WriteMsg(&pb.SyncRPC{RequestId: syncRPC.RequestId, Response: response})
+		if err != nil {
+			logger.Error("writing sync response", zap.Error(err))
+			_ = s.Reset()
+			return
+		}
+	}
+}
+
+// fetchMissing retrieves from a store node the messages published on a pubsub topic
+// whose hash is in a list, and stores them. Messages whose hash is not in the list, or
+// with a sender timestamp out of the ranges, when they are specified, are discarded.
+// It returns the number of messages stored
+func (store *WakuStore) fetchMissing(ctx context.Context, peerID peer.ID, pubsubTopic string, hashes [][]byte, ranges []*pb.SyncRange) int {
+	stored := 0
+
+	requested := make(map[string]struct{}, len(hashes))
+	for _, h := range hashes {
+		requested[string(h)] = struct{}{}
+	}
+
+	// Messages deleted by an operator are not fetched again
+	deleted, err := store.msgProvider.Tombstoned(hashes)
+	if err != nil {
+		store.log.Error("checking deleted messages", zap.Error(err))
+		return 0
+	}
+
+	var notDeleted [][]byte
+	for i, h := range hashes {
+		if !deleted[i] {
+			notDeleted = append(notDeleted, h)
+		}
+	}
+	hashes = notDeleted
+
+	for len(hashes) != 0 {
+		n := len(hashes)
+		if n > MaxMessageHashes {
+			n = MaxMessageHashes
+		}
+
+		result, err := store.LookupMessages(ctx, hashes[:n], WithPeer(peerID))
+		if err != nil {
+			store.log.Error("fetching missing messages", logging.HostID("peer", peerID), zap.Error(err))
+			metrics.RecordStoreError(store.ctx, "syncFailure")
+			break
+		}
+		hashes = hashes[n:]
+
+		var envs []*protocol.Envelope
+		for _, env := range result.Envelopes {
+			topic := env.PubsubTopic()
+			if topic == "" {
+				topic = pubsubTopic
+			}
+
+			// The peer could return messages that were not requested
+			hash := pb.MessageHash(topic, env.Message())
+			if _, ok := requested[string(hash)]; !ok || topic != pubsubTopic || (ranges != nil && !inSyncRanges(ranges, env.Message().Timestamp)) {
+				store.log.Warn("discarding message that was not requested", logging.HostID("peer", peerID))
+				metrics.RecordStoreError(store.ctx, "syncInvalidMessage")
+				continue
+			}
+			delete(requested, string(hash))
+
+			env = protocol.NewEnvelope(env.Message(), utils.GetUnixEpoch(), topic)
+			if store.params.shouldStore(env) {
+				envs = append(envs, env)
+			}
+		}
+
+		if len(envs) != 0 {
+			for _, err := range store.storeMessages(envs) {
+				if err == nil {
+					stored++
+				}
+			}
+		}
+	}
+
+	metrics.RecordStoreSync(store.ctx, "fetched", stored)
+
+	return stored
+}
+
+// validSubranges returns whether the subranges returned by a store node for a range
+// are sorted, don't overlap, and are strictly included in the range
+func validSubranges(r *pb.SyncRange, subranges []*pb.SyncRange) bool {
+	if len(subranges) > MaxSyncRanges {
+		return false
+	}
+
+	start := r.Start
+	for _, s := range subranges {
+		if s.Start < start || s.End <= s.Start || s.End > r.End || s.End-s.Start >= r.End-r.Start {
+			return false
+		}
+		start = s.End
+	}
+	return true
+}
+
+// Sync reconciles the messages published on a pubsub topic, with a sender timestamp in
+// [startTime, endTime), that are stored by this node and by another store node. The
+// fingerprints of ranges of messages are compared, and the ranges that differ are split
+// until they contain few enough messages to compare their hashes. Each node then fetches
+// only the messages it's missing from the other
+func (store *WakuStore) Sync(ctx context.Context, peerID peer.ID, pubsubTopic string, startTime int64, endTime int64) (*SyncResult, error) {
+	if !store.started {
+		return nil, errors.New("can't sync: store has not started")
+	}
+
+	if endTime <= startTime {
+		return nil, errors.New("can't sync: invalid time range")
+	}
+
+	logger := store.log.With(logging.HostID("peer", peerID), zap.String("pubsubTopic", pubsubTopic))
+
+	err := store.h.Connect(ctx, store.h.Peerstore().PeerInfo(peerID))
+	if err != nil {
+		logger.Error("connecting to peer", zap.Error(err))
+		return nil, err
+	}
+
+	stream, err := store.h.NewStream(ctx, peerID, StoreSyncID_v10beta1)
+	if err != nil {
+		logger.Error("creating stream to peer", zap.Error(err))
+		return nil, err
+	}
+	defer stream.Close()
+
+	writer := protoio.NewDelimitedWriter(stream)
+	reader := protoio.NewDelimitedReader(stream, math.MaxInt32)
+
+	result := &SyncResult{PubsubTopic: pubsubTopic, PeerID: peerID}
+
+	pending, err := store.fingerprints(pubsubTopic, []*pb.SyncRange{{Start: startTime, End: endTime}})
+	if err != nil {
+		_ = stream.Reset()
+		return nil, err
+	}
+
+	var missing, offered [][]byte
+	for len(pending) != 0 {
+		if result.Rounds >= MaxSyncRounds {
+			logger.Warn("too many sync rounds")
+			_ = stream.Reset()
+			return nil, ErrInvalidSyncResponse
+		}
+
+		n := len(pending)
+		if n > MaxSyncRanges {
+			n = MaxSyncRanges
+		}
+		ranges := pending[:n]
+		pending = pending[n:]
+
+		result.Rounds++
+		err = writer.WriteMsg(&pb.SyncRPC{
+			RequestId: hex.EncodeToString(protocol.GenerateRequestId()),
+			Request:   &pb.SyncRequest{PubsubTopic: pubsubTopic, Ranges: ranges},
+		})
+		if err != nil {
+			logger.Error("writing sync request", zap.Error(err))
+			_ = stream.Reset()
+			return nil, err
+		}
+
+		syncRPC := &pb.SyncRPC{}
+		err = reader.ReadMsg(syncRPC)
+		if err != nil {
+			logger.Error("reading sync response", zap.Error(err))
+			metrics.RecordStoreError(store.ctx, "decodeRPCFailure")
+			_ = stream.Reset()
+			return nil, err
+		}
+
+		response := syncRPC.Response
+		if response == nil {
+			response = new(pb.SyncResponse)
+		}
+
+		if err := responseError(&pb.HistoryResponse{Error: response.Error}); err != nil {
+			_ = stream.Reset()
+			return nil, err
+		}
+
+		if len(response.Results) != len(ranges) {
+			_ = stream.Reset()
+			return nil, ErrInvalidSyncResponse
+		}
+
+		for i, rangeResult := range response.Results {
+			r := ranges[i]
+			switch {
+			case rangeResult.Match:
+				continue
+
+			case len(rangeResult.Subranges) != 0:
+				if !validSubranges(r, rangeResult.Subranges) {
+					_ = stream.Reset()
+					return nil, ErrInvalidSyncResponse
+				}
+
+				local, err := store.fingerprints(pubsubTopic, rangeResult.Subranges)
+				if err != nil {
+					_ = stream.Reset()
+					return nil, err
+				}
+
+				for j, s := range local {
+					if !sameFingerprint(s, rangeResult.Subranges[j]) {
+						pending = append(pending, s)
+					}
+				}
+
+				if len(pending) > MaxSyncPendingRanges {
+					logger.Warn("too many sync ranges to compare")
+					_ = stream.Reset()
+					return nil, ErrInvalidSyncResponse
+				}
+
+			default:
+				hashes, err := store.rangeHashes(pubsubTopic, r)
+				if err != nil {
+					_ = stream.Reset()
+					return nil, err
+				}
+
+				remote := make(map[string]struct{}, len(rangeResult.Hashes))
+				for _, h := range rangeResult.Hashes {
+					remote[string(h)] = struct{}{}
+				}
+
+				local := make(map[string]struct{}, len(hashes))
+				for _, h := range hashes {
+					local[string(h)] = struct{}{}
+					if _, ok := remote[string(h)]; !ok {
+						offered = append(offered, h)
+					}
+				}
+
+				for _, h := range rangeResult.Hashes {
+					if _, ok := local[string(h)]; !ok {
+						missing = append(missing, h)
+					}
+				}
+			}
+		}
+	}
+
+	// The peer only fetches up to MaxSyncMissingHashes messages
+	if len(offered) > MaxSyncMissingHashes {
+		offered = offered[:MaxSyncMissingHashes]
+	}
+
+	err = writer.WriteMsg(&pb.SyncRPC{
+		RequestId: hex.EncodeToString(protocol.GenerateRequestId()),
+		Request:   &pb.SyncRequest{PubsubTopic: pubsubTopic, MissingHashes: offered},
+	})
+	if err != nil {
+		logger.Error("writing missing hashes", zap.Error(err))
+		_ = stream.Reset()
+		return nil, err
+	}
+
+	result.Offered = len(offered)
+	metrics.RecordStoreSync(store.ctx, "offered", len(offered))
+
+	if len(missing) != 0 {
+		result.Fetched = store.fetchMissing(ctx, peerID, pubsubTopic, missing, nil)
+	}
+
+	logger.Info("synchronized messages",
+		zap.Int("rounds", result.Rounds),
+		zap.Int("fetched", result.Fetched),
+		zap.Int("offered", result.Offered))
+
+	return result, nil
+}
+
+// syncPeriodically synchronizes the messages of the configured pubsub topics with the
+// configured peers, or with a random store node, every sync interval
+func (store *WakuStore) syncPeriodically(ctx context.Context) {
+	defer store.wg.Done()
+
+	ticker := time.NewTicker(store.params.syncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			peers := store.params.syncPeers
+			if len(peers) == 0 {
+				p, err := utils.SelectPeer(store.h, string(StoreSyncID_v10beta1), store.log)
+				if err != nil {
+					store.log.Info("selecting peer to synchronize with", zap.Error(err))
+					continue
+				}
+				peers = []peer.ID{*p}
+			}
+
+			endTime := utils.GetUnixEpoch()
+			startTime := endTime - int64(store.params.syncWindow)
+			for _, p := range peers {
+				for _, pubsubTopic := range store.params.syncPubsubTopics {
+					_, err := store.Sync(ctx, p, pubsubTopic, startTime, endTime)
+					if err != nil {
+						store.log.Error("synchronizing messages", logging.HostID("peer", p), zap.String("pubsubTopic", pubsubTopic), zap.Error(err))
+						metrics.RecordStoreError(store.ctx, "syncFailure")
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package store
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-msgio/protoio"
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWakuStoreSync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	host2, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	db1 := MemoryDB(t)
+	s1 := NewWakuStore(host1, nil, db1, 0, 0, utils.Logger(), WithDeniedPubsubTopics("denied"), WithSyncPeers(host2.ID()))
+	s1.Start(ctx)
+	defer s1.Stop()
+
+	db2 := MemoryDB(t)
+	s2 := NewWakuStore(host2, nil, db2, 0, 0, utils.Logger())
+	s2.Start(ctx)
+	defer s2.Stop()
+
+	addStorePeer(t, host2, host1)
+	addStorePeer(t, host1, host2)
+
+	// Each store misses some of the messages, and both store messages outside of the synchronized range
+	missing1, missing2, stored := 0, 0, 0
+	for i := 1; i <= 500; i++ {
+		env := protocol.NewEnvelope(tests.CreateWakuMessage("1", int64(i)), utils.GetUnixEpoch(), "test")
+		in1 := i%7 != 0 || i > 450
+		in2 := i%11 != 0 || i > 450
+		if in1 {
+			require.NoError(t, s1.storeMessage(env))
+		} else if in2 {
+			missing1++
+		}
+		if in2 {
+			require.NoError(t, s2.storeMessage(env))
+		} else if in1 {
+			missing2++
+		}
+		if in1 || in2 {
+			stored++
+		}
+	}
+	require.NoError(t, s1.storeMessage(protocol.NewEnvelope(tests.CreateWakuMessage("1", 10), utils.GetUnixEpoch(), "other")))
+
+	result, err := s2.Sync(ctx, host1.ID(), "test", 1, 451)
+	require.NoError(t, err)
+	require.Equal(t, missing2, result.Fetched)
+	require.Equal(t, missing1, result.Offered)
+	require.Greater(t, result.Rounds, 1)
+
+	msgs, err := db2.GetAll()
+	require.NoError(t, err)
+	require.Len(t, msgs, stored)
+
+	// The other store fetches the messages it's missing after the synchronization
+	require.Eventually(t, func() bool {
+		msgs, err := db1.GetAll()
+		return err == nil && len(msgs) == stored+1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	result, err = s2.Sync(ctx, host1.ID(), "test", 1, 451)
+	require.NoError(t, err)
+	require.Equal(t, SyncResult{PubsubTopic: "test", PeerID: host1.ID(), Rounds: 1}, *result)
+
+	_, err = s2.Sync(ctx, host1.ID(), "denied", 1, 451)
+	require.ErrorIs(t, err, ErrTopicNotServed)
+}
+
+func TestSplitRange(t *testing.T) {
+	r := &pb.SyncRange{Start: 10, End: 30}
+	subranges := splitRange(r)
+	require.Len(t, subranges, syncSplit)
+	require.Equal(t, int64(10), subranges[0].Start)
+	require.Equal(t, int64(30), subranges[len(subranges)-1].End)
+	require.True(t, validSubranges(r, subranges))
+
+	require.Len(t, splitRange(&pb.SyncRange{Start: 10, End: 13}), 3)
+
+	require.False(t, validSubranges(r, []*pb.SyncRange{r}))
+	require.False(t, validSubranges(r, []*pb.SyncRange{{Start: 20, End: 25}, {Start: 15, End: 20}}))
+	require.False(t, validSubranges(r, []*pb.SyncRange{{Start: 25, End: 35}}))
+}
+
+func TestWakuStoreSyncPeers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s1 := NewWakuStore(host1, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s1.Start(ctx)
+	defer s1.Stop()
+
+	host2, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s2 := NewWakuStore(host2, nil, MemoryDB(t), 0, 0, utils.Logger(), WithSyncPeers(host1.ID()))
+	s2.Start(ctx)
+	defer s2.Stop()
+
+	host3, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s3 := NewWakuStore(host3, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s3.Start(ctx)
+	defer s3.Stop()
+
+	addStorePeer(t, host3, host1)
+	addStorePeer(t, host3, host2)
+
+	// Store nodes that don't synchronize their messages don't serve sync requests
+	_, err = s3.Sync(ctx, host1.ID(), "test", 1, 100)
+	require.Error(t, err)
+
+	// Store nodes with sync peers only serve their requests
+	_, err = s3.Sync(ctx, host2.ID(), "test", 1, 100)
+	require.Error(t, err)
+}
+
+func TestWakuStoreSyncMissingHashes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	host2, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	db1 := MemoryDB(t)
+	s1 := NewWakuStore(host1, nil, db1, 0, 0, utils.Logger(), WithSyncPeers(host2.ID()))
+	s1.Start(ctx)
+	defer s1.Stop()
+
+	s2 := NewWakuStore(host2, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s2.Start(ctx)
+	defer s2.Stop()
+
+	addStorePeer(t, host1, host2)
+	addStorePeer(t, host2, host1)
+
+	env := protocol.NewEnvelope(tests.CreateWakuMessage("1", 10), utils.GetUnixEpoch(), "test")
+	require.NoError(t, s2.storeMessage(env))
+
+	// A peer can't make the store node fetch messages from ranges it didn't compare
+	stream, err := host2.NewStream(ctx, host1.ID(), StoreSyncID_v10beta1)
+	require.NoError(t, err)
+	writer := protoio.NewDelimitedWriter(stream)
+	err = writer.WriteMsg(&pb.SyncRPC{
+		RequestId: "1",
+		Request:   &pb.SyncRequest{PubsubTopic: "test", MissingHashes: [][]byte{env.Hash()}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, stream.Close())
+
+	time.Sleep(500 * time.Millisecond)
+	msgs, err := db1.GetAll()
+	require.NoError(t, err)
+	require.Empty(t, msgs)
+
+	// The messages returned by a peer that were not requested are discarded
+	other := protocol.NewEnvelope(tests.CreateWakuMessage("1", 20), utils.GetUnixEpoch(), "test")
+	require.NoError(t, s2.storeMessage(other))
+	require.Equal(t, 0, s1.fetchMissing(ctx, host2.ID(), "other", [][]byte{env.Hash()}, nil))
+	require.Equal(t, 0, s1.fetchMissing(ctx, host2.ID(), "test", [][]byte{env.Hash()}, []*pb.SyncRange{{Start: 15, End: 30}}))
+	require.Equal(t, 1, s1.fetchMissing(ctx, host2.ID(), "test", [][]byte{env.Hash()}, []*pb.SyncRange{{Start: 1, End: 15}}))
+
+	msgs, err = db1.GetAll()
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	require.Equal(t, env.Message(), msgs[0].Message)
+}
+
+// splittingPeer answers every range of a sync request with subranges that don't match,
+// so the ranges to compare never run out
+func splittingPeer(t *testing.T, split func(r *pb.SyncRange) []*pb.SyncRange) host.Host {
+	h, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	h.SetStreamHandler(StoreSyncID_v10beta1, func(s network.Stream) {
+		defer s.Close()
+		reader := protoio.NewDelimitedReader(s, math.MaxInt32)
+		writer := protoio.NewDelimitedWriter(s)
+		for {
+			syncRPC := &pb.SyncRPC{}
+			if err := reader.ReadMsg(syncRPC); err != nil || syncRPC.Request == nil {
+				return
+			}
+
+			response := new(pb.SyncResponse)
+			for _, r := range syncRPC.Request.Ranges {
+				response.Results = append(response.Results, &pb.SyncRangeResult{Subranges: split(r)})
+			}
+			if err := writer.WriteMsg(&pb.SyncRPC{RequestId: syncRPC.RequestId, Response: response}); err != nil {
+				return
+			}
+		}
+	})
+
+	return h
+}
+
+func TestWakuStoreSyncSplittingPeer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	s1 := NewWakuStore(host1, nil, MemoryDB(t), 0, 0, utils.Logger())
+	s1.Start(ctx)
+	defer s1.Stop()
+
+	fake := func(start int64, end int64) *pb.SyncRange {
+		return &pb.SyncRange{Start: start, End: end, Count: 1, Fingerprint: []byte{1}}
+	}
+
+	// A peer that splits every range in as many subranges as possible exceeds the
+	// maximum number of ranges to compare
+	host2 := splittingPeer(t, func(r *pb.SyncRange) []*pb.SyncRange {
+		var subranges []*pb.SyncRange
+		step := (r.End - r.Start) / MaxSyncRanges
+		for i := int64(0); i < MaxSyncRanges; i++ {
+			subranges = append(subranges, fake(r.Start+i*step, r.Start+(i+1)*step))
+		}
+		return subranges
+	})
+	addStorePeer(t, host1, host2)
+
+	_, err = s1.Sync(ctx, host2.ID(), "test", 0, 1<<50)
+	require.ErrorIs(t, err, ErrInvalidSyncResponse)
+
+	// A peer that shrinks every range as little as possible exceeds the maximum number
+	// of rounds
+	host3 := splittingPeer(t, func(r *pb.SyncRange) []*pb.SyncRange {
+		return []*pb.SyncRange{fake(r.Start, r.End-1)}
+	})
+	addStorePeer(t, host1, host3)
+
+	_, err = s1.Sync(ctx, host3.ID(), "test", 0, 1<<50)
+	require.ErrorIs(t, err, ErrInvalidSyncResponse)
+}
@@ -1,30 +1,63 @@
 package swap
 
 import (
+	"context"
+	"math"
 	"sync"
 
-	"github.com/libp2p/go-libp2p-core/protocol"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/libp2p/go-libp2p-core/event"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	libp2pProtocol "github.com/libp2p/go-libp2p-core/protocol"
+	"github.com/libp2p/go-msgio/protoio"
+	"github.com/status-im/go-waku/logging"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
 	"go.uber.org/zap"
 )
 
 const (
+	// SoftMode only keeps the accounting of the service exchanged with each peer
 	SoftMode int = 0
+	// MockMode also sends cheques to the peers the node owes, which are settled with
+	// the ledger without transferring funds
 	MockMode int = 1
+	// HardMode also refuses to serve the peers whose balance reaches the disconnect threshold
 	HardMode int = 2
 )
 
-const WakuSwapID_v200 = protocol.ID("/vac/waku/swap/2.0.0-beta1")
+const WakuSwapID_v200 = libp2pProtocol.ID("/vac/waku/swap/2.0.0-beta1")
 
 type WakuSwap struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     *sync.WaitGroup
+
+	h      host.Host
 	params *SwapParameters
 
 	log *zap.Logger
 
 	Accounting      map[string]int
 	accountingMutex sync.RWMutex
+
+	// beneficiaries are the addresses received in the handshake of each peer
+	beneficiaries map[string][]byte
+	// issued is the amount of the last cheque sent to each peer
+	issued map[string]uint32
+	// received is the amount of the last cheque received from each issuer address
+	received map[string]uint32
+	// sending contains the peers a cheque is being sent to
+	sending map[string]struct{}
+
+	identificationSub event.Subscription
 }
 
-func NewWakuSwap(log *zap.Logger, opts ...SwapOption) *WakuSwap {
+// NewWakuSwap creates a WakuSwap. The host can be nil when swap is only used for the
+// accounting of the service exchanged with other peers
+func NewWakuSwap(h host.Host, log *zap.Logger, opts ...SwapOption) *WakuSwap {
 	params := &SwapParameters{}
 
 	optList := DefaultOptions()
@@ -34,30 +67,189 @@ func NewWakuSwap(log *zap.Logger, opts ...SwapOption) *WakuSwap {
 	}
 
 	return &WakuSwap{
-		params:     params,
-		log:        log.Named("swap"),
-		Accounting: make(map[string]int),
+		h:             h,
+		wg:            &sync.WaitGroup{},
+		params:        params,
+		log:           log.Named("swap"),
+		Accounting:    make(map[string]int),
+		beneficiaries: make(map[string][]byte),
+		issued:        make(map[string]uint32),
+		received:      make(map[string]uint32),
+		sending:       make(map[string]struct{}),
 	}
 }
 
-func (s *WakuSwap) sendCheque(peerId string) {
-	s.log.Debug("not yet implemented")
+// Start mounts the swap protocol, and exchanges a handshake with every peer that
+// supports it once it is identified
+func (s *WakuSwap) Start(ctx context.Context) error {
+	if s.h == nil || s.ctx != nil {
+		return nil
+	}
+
+	sub, err := s.h.EventBus().Subscribe(new(event.EvtPeerIdentificationCompleted))
+	if err != nil {
+		return err
+	}
+
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.identificationSub = sub
+	s.h.SetStreamHandlerMatch(WakuSwapID_v200, protocol.PrefixTextMatch(string(WakuSwapID_v200)), s.onRequest)
+
+	s.wg.Add(1)
+	go s.handshakeIdentifiedPeers()
+
+	s.log.Info("Swap protocol started")
+
+	return nil
+}
+
+// Stop removes the swap protocol stream handler and waits for the cheques being sent
+func (s *WakuSwap) Stop() {
+	if s.cancel == nil {
+		return
+	}
+
+	s.cancel()
+	s.h.RemoveStreamHandler(WakuSwapID_v200)
+	_ = s.identificationSub.Close()
+	s.wg.Wait()
+}
+
+// Beneficiary returns the address the cheques sent to the node are paid to
+func (s *WakuSwap) Beneficiary() []byte {
+	if s.params.privKey == nil {
+		return nil
+	}
+	return crypto.PubkeyToAddress(s.params.privKey.PublicKey).Bytes()
+}
+
+func (s *WakuSwap) handshakeIdentifiedPeers() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case e, ok := <-s.identificationSub.Out():
+			if !ok {
+				return
+			}
+
+			peerID := e.(event.EvtPeerIdentificationCompleted).Peer
+			protocols, err := s.h.Peerstore().SupportsProtocols(peerID, string(WakuSwapID_v200))
+			if err != nil || len(protocols) == 0 {
+				continue
+			}
+
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				if err := s.Handshake(s.ctx, peerID); err != nil {
+					s.log.Info("swap handshake", logging.HostID("peer", peerID), zap.Error(err))
+				}
+			}()
+		}
+	}
+}
+
+// Handshake sends the beneficiary address of the node to a peer, and records the one
+// of the peer, which is required to send it cheques and to verify the ones it sends
+func (s *WakuSwap) Handshake(ctx context.Context, peerID peer.ID) error {
+	stream, err := s.h.NewStream(ctx, peerID, WakuSwapID_v200)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	err = protoio.NewDelimitedWriter(stream).WriteMsg(&pb.SwapRPC{Handshake: &pb.Handshake{Beneficiary: s.Beneficiary()}})
+	if err != nil {
+		_ = stream.Reset()
+		return err
+	}
+
+	response := &pb.SwapRPC{}
+	err = protoio.NewDelimitedReader(stream, math.MaxInt32).ReadMsg(response)
+	if err != nil {
+		_ = stream.Reset()
+		return err
+	}
+
+	if response.Handshake == nil {
+		return ErrInvalidHandshake
+	}
+
+	s.setBeneficiary(peerID, response.Handshake.Beneficiary)
+
+	return nil
+}
+
+func (s *WakuSwap) setBeneficiary(peerID peer.ID, beneficiary []byte) {
+	s.accountingMutex.Lock()
+	defer s.accountingMutex.Unlock()
+
+	if len(beneficiary) != 0 {
+		s.beneficiaries[peerID.Pretty()] = beneficiary
+	}
+}
+
+func (s *WakuSwap) onRequest(stream network.Stream) {
+	defer stream.Close()
+
+	peerID := stream.Conn().RemotePeer()
+	logger := s.log.With(logging.HostID("peer", peerID))
+
+	request := &pb.SwapRPC{}
+	err := protoio.NewDelimitedReader(stream, math.MaxInt32).ReadMsg(request)
+	if err != nil {
+		logger.Error("reading request", zap.Error(err))
+		return
+	}
+
+	switch {
+	case request.Handshake != nil:
+		s.setBeneficiary(peerID, request.Handshake.Beneficiary)
+		err = protoio.NewDelimitedWriter(stream).WriteMsg(&pb.SwapRPC{Handshake: &pb.Handshake{Beneficiary: s.Beneficiary()}})
+		if err != nil {
+			logger.Error("writing handshake", zap.Error(err))
+			_ = stream.Reset()
+		}
+	case request.Cheque != nil:
+		amount, err := s.receiveCheque(peerID, request.Cheque)
+		if err != nil {
+			logger.Warn("rejected cheque", zap.String("issuer", request.Cheque.IssuerAddress), zap.Error(err))
+			return
+		}
+		logger.Info("received cheque", zap.String("issuer", request.Cheque.IssuerAddress), zap.Uint32("amount", amount))
+	}
+}
+
+// balance returns the balance of the account of a peer, which decreases when the node
+// serves the peer and increases when the peer serves the node
+func (s *WakuSwap) balance(peerId string) int {
+	return -s.Accounting[peerId]
 }
 
 func (s *WakuSwap) applyPolicy(peerId string) {
 	logger := s.log.With(zap.String("peer", peerId))
-	if s.Accounting[peerId] <= s.params.disconnectThreshold {
-		logger.Warn("disconnect threshold reached", zap.Int("value", s.Accounting[peerId]))
+	balance := s.balance(peerId)
+	if balance <= s.params.disconnectThreshold {
+		logger.Warn("disconnect threshold reached", zap.Int("value", balance))
 	}
 
-	if s.Accounting[peerId] >= s.params.paymentThreshold {
-		logger.Warn("payment threshold reached", zap.Int("value", s.Accounting[peerId]))
-		if s.params.mode != HardMode {
-			s.sendCheque(peerId)
+	if balance >= s.params.paymentThreshold {
+		logger.Warn("payment threshold reached", zap.Int("value", balance))
+		if s.params.mode != SoftMode && s.cancel != nil {
+			if _, ok := s.sending[peerId]; !ok {
+				s.sending[peerId] = struct{}{}
+				s.wg.Add(1)
+				go s.sendCheque(peerId)
+			}
 		}
 	}
 }
 
+// Credit records that a peer provided n units of service to the node, e.g. the
+// messages returned by a store node to a query sent by the node
 func (s *WakuSwap) Credit(peerId string, n int) {
 	s.accountingMutex.Lock()
 	defer s.accountingMutex.Unlock()
@@ -66,6 +258,8 @@ func (s *WakuSwap) Credit(peerId string, n int) {
 	s.applyPolicy(peerId)
 }
 
+// Debit records that the node provided n units of service to a peer, e.g. the messages
+// returned to a query received from the peer
 func (s *WakuSwap) Debit(peerId string, n int) {
 	s.accountingMutex.Lock()
 	defer s.accountingMutex.Unlock()
@@ -73,3 +267,17 @@ func (s *WakuSwap) Debit(peerId string, n int) {
 	s.Accounting[peerId] += n
 	s.applyPolicy(peerId)
 }
+
+// ShouldServe returns false if the node runs in HardMode and the balance of a peer
+// reached the disconnect threshold, in which case the peer must not be served anymore
+// and its connections should be closed
+func (s *WakuSwap) ShouldServe(peerId string) bool {
+	if s.params.mode != HardMode {
+		return true
+	}
+
+	s.accountingMutex.RLock()
+	defer s.accountingMutex.RUnlock()
+
+	return s.balance(peerId) > s.params.disconnectThreshold
+}
@@ -0,0 +1,231 @@
+package swap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-msgio/protoio"
+	"github.com/status-im/go-waku/logging"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrNoPrivateKey is returned when a cheque is issued by a node without a private key
+	ErrNoPrivateKey = errors.New("no private key to sign cheques")
+
+	// ErrInvalidHandshake is returned when a peer does not answer a handshake with its own
+	ErrInvalidHandshake = errors.New("invalid swap handshake")
+
+	// ErrNoBeneficiary is returned when a cheque is issued to a peer whose beneficiary
+	// address is unknown, because it did not complete a handshake
+	ErrNoBeneficiary = errors.New("unknown beneficiary address")
+
+	// ErrUnknownIssuer is returned when a cheque is not issued by the address received
+	// in the handshake of the peer that sent it
+	ErrUnknownIssuer = errors.New("cheque issuer does not match the peer")
+
+	// ErrWrongBeneficiary is returned when a cheque is not payable to the node
+	ErrWrongBeneficiary = errors.New("cheque beneficiary is not the node")
+
+	// ErrInvalidSignature is returned when a cheque is not signed by its issuer
+	ErrInvalidSignature = errors.New("invalid cheque signature")
+
+	// ErrAmountNotIncreased is returned when the amount of a cheque is not larger than
+	// the amount of the last cheque received from the same issuer
+	ErrAmountNotIncreased = errors.New("cheque amount did not increase")
+
+	// ErrAmountOverflow is returned when the amount owed to a peer can't be added to
+	// the amount of the cheques already sent to it
+	ErrAmountOverflow = errors.New("cheque amount overflow")
+)
+
+// chequeHash returns the hash signed by the issuer of a cheque
+func chequeHash(cheque *pb.Cheque) []byte {
+	var date, amount [4]byte
+	binary.BigEndian.PutUint32(date[:], cheque.Date)
+	binary.BigEndian.PutUint32(amount[:], cheque.Amount)
+	return crypto.Keccak256(common.HexToAddress(cheque.IssuerAddress).Bytes(), cheque.Beneficiary, date[:], amount[:])
+}
+
+// VerifyCheque returns ErrInvalidSignature if a cheque is not signed with the key of
+// its issuer address
+func VerifyCheque(cheque *pb.Cheque) error {
+	if !common.IsHexAddress(cheque.IssuerAddress) {
+		return ErrInvalidSignature
+	}
+
+	pubKey, err := crypto.SigToPub(chequeHash(cheque), cheque.Signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if crypto.PubkeyToAddress(*pubKey) != common.HexToAddress(cheque.IssuerAddress) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// newCheque returns a cheque payable to a beneficiary, signed with the key of the node.
+// The amount is the total paid to the beneficiary, including the previous cheques
+func (s *WakuSwap) newCheque(beneficiary []byte, amount uint32) (*pb.Cheque, error) {
+	if s.params.privKey == nil {
+		return nil, ErrNoPrivateKey
+	}
+
+	cheque := &pb.Cheque{
+		IssuerAddress: crypto.PubkeyToAddress(s.params.privKey.PublicKey).Hex(),
+		Beneficiary:   beneficiary,
+		Date:          uint32(time.Now().Unix()),
+		Amount:        amount,
+	}
+
+	signature, err := crypto.Sign(chequeHash(cheque), s.params.privKey)
+	if err != nil {
+		return nil, err
+	}
+	cheque.Signature = signature
+
+	return cheque, nil
+}
+
+// sendCheque pays a peer the amount the node owes it, with a cheque for the total paid
+// to the peer so far. The accounting is settled once the cheque is sent
+func (s *WakuSwap) sendCheque(peerId string) {
+	defer s.wg.Done()
+
+	logger := s.log.With(zap.String("peer", peerId))
+
+	err := s.payPeer(peerId)
+
+	s.accountingMutex.Lock()
+	delete(s.sending, peerId)
+	if err == nil {
+		// The peer may have provided more service while the cheque was being sent
+		s.applyPolicy(peerId)
+	}
+	s.accountingMutex.Unlock()
+
+	if err != nil {
+		logger.Error("sending cheque", zap.Error(err))
+	}
+}
+
+func (s *WakuSwap) payPeer(peerId string) error {
+	peerID, err := peer.Decode(peerId)
+	if err != nil {
+		return err
+	}
+
+	s.accountingMutex.RLock()
+	owed := s.balance(peerId)
+	beneficiary := s.beneficiaries[peerId]
+	issued := s.issued[peerId]
+	s.accountingMutex.RUnlock()
+
+	if owed <= 0 {
+		return nil
+	}
+
+	if uint64(issued)+uint64(owed) > math.MaxUint32 {
+		return ErrAmountOverflow
+	}
+
+	if beneficiary == nil {
+		if err := s.Handshake(s.ctx, peerID); err != nil {
+			return err
+		}
+
+		s.accountingMutex.RLock()
+		beneficiary = s.beneficiaries[peerId]
+		s.accountingMutex.RUnlock()
+
+		if beneficiary == nil {
+			return ErrNoBeneficiary
+		}
+	}
+
+	cheque, err := s.newCheque(beneficiary, issued+uint32(owed))
+	if err != nil {
+		return err
+	}
+
+	stream, err := s.h.NewStream(s.ctx, peerID, WakuSwapID_v200)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	err = protoio.NewDelimitedWriter(stream).WriteMsg(&pb.SwapRPC{Cheque: cheque})
+	if err != nil {
+		_ = stream.Reset()
+		return err
+	}
+
+	s.settleCheque(peerId, cheque.Amount)
+
+	s.log.Info("sent cheque", logging.HostID("peer", peerID), zap.Uint32("amount", cheque.Amount))
+
+	return nil
+}
+
+// settleCheque records a cheque of the given cumulative amount sent to a peer.
+// Only the part of the amount that was not issued already is settled, against
+// the current balance, as the node may have been credited or debited while the
+// cheque was being sent
+func (s *WakuSwap) settleCheque(peerId string, amount uint32) {
+	s.accountingMutex.Lock()
+	defer s.accountingMutex.Unlock()
+
+	issued := s.issued[peerId]
+	if amount <= issued {
+		return
+	}
+
+	s.issued[peerId] = amount
+	s.Accounting[peerId] += int(amount - issued)
+}
+
+// receiveCheque verifies a cheque sent by a peer, cashes it with the ledger, and
+// settles the amount it pays against the account of the peer. It returns the amount
+// paid, which is the difference with the last cheque received from the same issuer
+func (s *WakuSwap) receiveCheque(peerID peer.ID, cheque *pb.Cheque) (uint32, error) {
+	if err := VerifyCheque(cheque); err != nil {
+		return 0, err
+	}
+
+	beneficiary := s.Beneficiary()
+	if beneficiary == nil || !bytes.Equal(cheque.Beneficiary, beneficiary) {
+		return 0, ErrWrongBeneficiary
+	}
+
+	s.accountingMutex.Lock()
+	defer s.accountingMutex.Unlock()
+
+	issuer := common.HexToAddress(cheque.IssuerAddress)
+	if !bytes.Equal(s.beneficiaries[peerID.Pretty()], issuer.Bytes()) {
+		return 0, ErrUnknownIssuer
+	}
+
+	last := s.received[issuer.Hex()]
+	if cheque.Amount <= last {
+		return 0, ErrAmountNotIncreased
+	}
+
+	amount := cheque.Amount - last
+	if err := s.params.ledger.Cash(cheque, amount); err != nil {
+		return 0, err
+	}
+
+	s.received[issuer.Hex()] = cheque.Amount
+	s.Accounting[peerID.Pretty()] -= int(amount)
+
+	return amount, nil
+}
@@ -0,0 +1,206 @@
+package swap
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiveCheque(t *testing.T) {
+	issuerKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	beneficiaryKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	ledger := NewLocalLedger()
+	issuer := NewWakuSwap(nil, utils.Logger(), WithPrivateKey(issuerKey))
+	beneficiary := NewWakuSwap(nil, utils.Logger(), WithPrivateKey(beneficiaryKey), WithLedger(ledger))
+
+	issuerID := peer.ID("issuer")
+	beneficiary.Debit(issuerID.Pretty(), 15)
+
+	cheque, err := issuer.newCheque(beneficiary.Beneficiary(), 10)
+	require.NoError(t, err)
+	require.NoError(t, VerifyCheque(cheque))
+
+	// The issuer must be the address received in the handshake of the peer
+	_, err = beneficiary.receiveCheque(issuerID, cheque)
+	require.ErrorIs(t, err, ErrUnknownIssuer)
+	beneficiary.setBeneficiary(issuerID, issuer.Beneficiary())
+
+	amount, err := beneficiary.receiveCheque(issuerID, cheque)
+	require.NoError(t, err)
+	require.Equal(t, uint32(10), amount)
+	require.Equal(t, 5, beneficiary.Accounting[issuerID.Pretty()])
+	require.Equal(t, int64(10), ledger.Balance(beneficiary.Beneficiary()))
+	require.Equal(t, int64(-10), ledger.Balance(issuer.Beneficiary()))
+
+	_, err = beneficiary.receiveCheque(issuerID, cheque)
+	require.ErrorIs(t, err, ErrAmountNotIncreased)
+
+	cheque, err = issuer.newCheque(beneficiary.Beneficiary(), 15)
+	require.NoError(t, err)
+	amount, err = beneficiary.receiveCheque(issuerID, cheque)
+	require.NoError(t, err)
+	require.Equal(t, uint32(5), amount)
+	require.Equal(t, 0, beneficiary.Accounting[issuerID.Pretty()])
+	require.Equal(t, int64(15), ledger.Balance(beneficiary.Beneficiary()))
+
+	cheque, err = issuer.newCheque(beneficiary.Beneficiary(), 20)
+	require.NoError(t, err)
+	cheque.Amount = 30
+	require.ErrorIs(t, VerifyCheque(cheque), ErrInvalidSignature)
+	_, err = beneficiary.receiveCheque(issuerID, cheque)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	cheque, err = issuer.newCheque(issuer.Beneficiary(), 20)
+	require.NoError(t, err)
+	_, err = beneficiary.receiveCheque(issuerID, cheque)
+	require.ErrorIs(t, err, ErrWrongBeneficiary)
+
+	_, err = NewWakuSwap(nil, utils.Logger()).newCheque(beneficiary.Beneficiary(), 1)
+	require.ErrorIs(t, err, ErrNoPrivateKey)
+}
+
+func TestSwapCheques(t *testing.T) {
+	for _, mode := range []int{SoftMode, MockMode} {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		key1, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		key2, err := crypto.GenerateKey()
+		require.NoError(t, err)
+
+		host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+		require.NoError(t, err)
+		swap1 := NewWakuSwap(host1, utils.Logger(), WithMode(mode), WithThreshold(5, -100), WithPrivateKey(key1))
+		require.NoError(t, swap1.Start(ctx))
+		defer swap1.Stop()
+
+		host2, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+		require.NoError(t, err)
+		ledger2 := NewLocalLedger()
+		swap2 := NewWakuSwap(host2, utils.Logger(), WithMode(mode), WithThreshold(5, -100), WithPrivateKey(key2), WithLedger(ledger2))
+		require.NoError(t, swap2.Start(ctx))
+		defer swap2.Stop()
+
+		// The handshake happens once the peers are connected and identified
+		host1.Peerstore().AddAddr(host2.ID(), tests.GetHostAddress(host2), peerstore.PermanentAddrTTL)
+		require.NoError(t, host1.Connect(ctx, host1.Peerstore().PeerInfo(host2.ID())))
+
+		beneficiary := func(s *WakuSwap, p peer.ID) []byte {
+			s.accountingMutex.RLock()
+			defer s.accountingMutex.RUnlock()
+			return s.beneficiaries[p.Pretty()]
+		}
+		require.Eventually(t, func() bool {
+			return beneficiary(swap1, host2.ID()) != nil && beneficiary(swap2, host1.ID()) != nil
+		}, 5*time.Second, 50*time.Millisecond)
+		require.Equal(t, swap2.Beneficiary(), beneficiary(swap1, host2.ID()))
+		require.Equal(t, swap1.Beneficiary(), beneficiary(swap2, host1.ID()))
+
+		accounting := func(s *WakuSwap, p peer.ID) int {
+			s.accountingMutex.RLock()
+			defer s.accountingMutex.RUnlock()
+			return s.Accounting[p.Pretty()]
+		}
+
+		// host2 serves host1 beyond the payment threshold
+		swap2.Debit(host1.ID().Pretty(), 6)
+		swap1.Credit(host2.ID().Pretty(), 6)
+
+		if mode == SoftMode {
+			time.Sleep(200 * time.Millisecond)
+			require.Equal(t, -6, accounting(swap1, host2.ID()))
+			require.Equal(t, 6, accounting(swap2, host1.ID()))
+			require.Equal(t, int64(0), ledger2.Balance(swap2.Beneficiary()))
+			continue
+		}
+
+		require.Eventually(t, func() bool {
+			return accounting(swap1, host2.ID()) == 0 && accounting(swap2, host1.ID()) == 0
+		}, 5*time.Second, 50*time.Millisecond)
+		require.Equal(t, int64(6), ledger2.Balance(swap2.Beneficiary()))
+
+		// The next cheque includes the amount of the previous one
+		swap2.Debit(host1.ID().Pretty(), 5)
+		swap1.Credit(host2.ID().Pretty(), 5)
+		require.Eventually(t, func() bool {
+			return accounting(swap1, host2.ID()) == 0 && accounting(swap2, host1.ID()) == 0
+		}, 5*time.Second, 50*time.Millisecond)
+		require.Equal(t, int64(11), ledger2.Balance(swap2.Beneficiary()))
+		require.Equal(t, int64(-11), ledger2.Balance(swap1.Beneficiary()))
+	}
+}
+
+func TestSwapChequesConcurrentAccounting(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	key1, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	key2, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+	swap1 := NewWakuSwap(host1, utils.Logger(), WithMode(MockMode), WithThreshold(5, -1000), WithPrivateKey(key1))
+	require.NoError(t, swap1.Start(ctx))
+	defer swap1.Stop()
+
+	host2, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+	swap2 := NewWakuSwap(host2, utils.Logger(), WithMode(MockMode), WithThreshold(5, -1000), WithPrivateKey(key2))
+	require.NoError(t, swap2.Start(ctx))
+	defer swap2.Stop()
+
+	host1.Peerstore().AddAddr(host2.ID(), tests.GetHostAddress(host2), peerstore.PermanentAddrTTL)
+	require.NoError(t, host1.Connect(ctx, host1.Peerstore().PeerInfo(host2.ID())))
+
+	// host2 serves host1 while host1 serves host2 back, so the balance changes
+	// while the cheques are being sent
+	peerID := host2.ID().Pretty()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				swap1.Credit(peerID, 3)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				swap1.Debit(peerID, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	state := func() (int, uint32, bool) {
+		swap1.accountingMutex.RLock()
+		defer swap1.accountingMutex.RUnlock()
+		_, sending := swap1.sending[peerID]
+		return swap1.Accounting[peerID], swap1.issued[peerID], sending
+	}
+	require.Eventually(t, func() bool {
+		accounting, _, sending := state()
+		return !sending && -accounting < 5
+	}, 10*time.Second, 50*time.Millisecond)
+
+	// Every unit paid with a cheque is settled exactly once
+	accounting, issued, _ := state()
+	require.NotZero(t, issued)
+	require.Equal(t, -10*50*3+10*50*1+int(issued), accounting)
+}
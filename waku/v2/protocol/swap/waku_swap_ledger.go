@@ -0,0 +1,50 @@
+package swap
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+)
+
+// Ledger settles the cheques received from other peers
+type Ledger interface {
+	// Cash transfers an amount from the issuer of a verified cheque to its beneficiary.
+	// The amount is the difference with the last cheque cashed from the same issuer,
+	// since the amount of a cheque is the total paid by the issuer to the beneficiary
+	Cash(cheque *pb.Cheque, amount uint32) error
+}
+
+// LocalLedger is a Ledger that keeps the balance of each address in memory. It settles
+// the cheques without transferring funds, e.g. in MockMode or in tests
+type LocalLedger struct {
+	sync.RWMutex
+	balances map[common.Address]int64
+}
+
+// NewLocalLedger creates a LocalLedger where every address has a balance of 0
+func NewLocalLedger() *LocalLedger {
+	return &LocalLedger{
+		balances: make(map[common.Address]int64),
+	}
+}
+
+// Cash transfers an amount from the issuer of a cheque to its beneficiary
+func (l *LocalLedger) Cash(cheque *pb.Cheque, amount uint32) error {
+	l.Lock()
+	defer l.Unlock()
+
+	l.balances[common.HexToAddress(cheque.IssuerAddress)] -= int64(amount)
+	l.balances[common.BytesToAddress(cheque.Beneficiary)] += int64(amount)
+
+	return nil
+}
+
+// Balance returns the balance of an address, which is negative for the issuers of the
+// cheques cashed with the ledger
+func (l *LocalLedger) Balance(address []byte) int64 {
+	l.RLock()
+	defer l.RUnlock()
+
+	return l.balances[common.BytesToAddress(address)]
+}
@@ -1,9 +1,14 @@
 package swap
 
+import "crypto/ecdsa"
+
 type SwapParameters struct {
 	mode                int
 	paymentThreshold    int
 	disconnectThreshold int
+
+	privKey *ecdsa.PrivateKey
+	ledger  Ledger
 }
 
 type SwapOption func(*SwapParameters)
@@ -21,9 +26,26 @@ func WithThreshold(payment, disconnect int) SwapOption {
 	}
 }
 
+// WithPrivateKey is a SwapOption that sets the secp256k1 key used to sign the cheques
+// sent by the node. The beneficiary address of the node is derived from it
+func WithPrivateKey(privKey *ecdsa.PrivateKey) SwapOption {
+	return func(params *SwapParameters) {
+		params.privKey = privKey
+	}
+}
+
+// WithLedger is a SwapOption that sets the Ledger the cheques received are cashed with.
+// A LocalLedger is used by default
+func WithLedger(ledger Ledger) SwapOption {
+	return func(params *SwapParameters) {
+		params.ledger = ledger
+	}
+}
+
 func DefaultOptions() []SwapOption {
 	return []SwapOption{
 		WithMode(SoftMode),
 		WithThreshold(100, -100),
+		WithLedger(NewLocalLedger()),
 	}
 }
@@ -8,7 +8,7 @@ import (
 )
 
 func TestSwapCreditDebit(t *testing.T) {
-	swap := NewWakuSwap(utils.Logger(), []SwapOption{
+	swap := NewWakuSwap(nil, utils.Logger(), []SwapOption{
 		WithMode(SoftMode),
 		WithThreshold(0, 0),
 	}...)
@@ -19,3 +19,18 @@ func TestSwapCreditDebit(t *testing.T) {
 	swap.Debit("1", 2)
 	require.Equal(t, 1, swap.Accounting["1"])
 }
+
+func TestSwapShouldServe(t *testing.T) {
+	for _, mode := range []int{SoftMode, MockMode, HardMode} {
+		swap := NewWakuSwap(nil, utils.Logger(), WithMode(mode), WithThreshold(10, -2))
+
+		swap.Debit("1", 1)
+		require.True(t, swap.ShouldServe("1"))
+
+		swap.Debit("1", 1)
+		require.Equal(t, mode != HardMode, swap.ShouldServe("1"))
+
+		swap.Credit("1", 1)
+		require.True(t, swap.ShouldServe("1"))
+	}
+}
@@ -1,14 +1,20 @@
 package rpc
 
 import (
+	"crypto/subtle"
+	"errors"
 	"net/http"
+	"strings"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/libp2p/go-libp2p-core/peer"
 	ma "github.com/multiformats/go-multiaddr"
 	"go.uber.org/zap"
 
 	"github.com/status-im/go-waku/waku/v2/node"
 	"github.com/status-im/go-waku/waku/v2/protocol/filter"
 	"github.com/status-im/go-waku/waku/v2/protocol/lightpush"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
 	"github.com/status-im/go-waku/waku/v2/protocol/relay"
 	"github.com/status-im/go-waku/waku/v2/protocol/store"
 )
@@ -16,8 +22,15 @@ import (
 type AdminService struct {
 	node *node.WakuNode
 	log  *zap.Logger
+	// token must be sent as a bearer token by the methods that modify the stored
+	// messages, which are disabled if it's empty
+	token string
 }
 
+// ErrUnauthorized is returned by the admin methods that require the admin token when
+// the request doesn't contain it
+var ErrUnauthorized = errors.New("unauthorized")
+
 type GetPeersArgs struct {
 }
 
@@ -80,3 +93,114 @@ func (a *AdminService) GetV1Peers(req *http.Request, args *GetPeersArgs, reply *
 	}
 	return nil
 }
+
+type StoreStatsArgs struct {
+	PeerID         string   `json:"peerID,omitempty"`
+	Topic          string   `json:"pubsubTopic,omitempty"`
+	ContentFilters []string `json:"contentFilters,omitempty"`
+	StartTime      int64    `json:"startTime,omitempty"`
+	EndTime        int64    `json:"endTime,omitempty"`
+}
+
+type StoreStatsReply struct {
+	Stats *pb.HistoryStats `json:"stats,omitempty"`
+	Error string           `json:"error,omitempty"`
+}
+
+// GetV1StoreStats returns the number of messages that match a query, the range of
+// their sender timestamps, and the same statistics per content topic. The messages
+// stored by this node are used, unless the ID of a store node is specified
+func (a *AdminService) GetV1StoreStats(req *http.Request, args *StoreStatsArgs, reply *StoreStatsReply) error {
+	query := store.Query{
+		Topic:         args.Topic,
+		ContentTopics: args.ContentFilters,
+		StartTime:     args.StartTime,
+		EndTime:       args.EndTime,
+	}
+
+	var stats *pb.HistoryStats
+	var err error
+	if args.PeerID == "" {
+		stats, err = a.node.Store().LocalStats(query)
+	} else {
+		var peerID peer.ID
+		peerID, err = peer.Decode(args.PeerID)
+		if err == nil {
+			stats, err = a.node.Store().Stats(req.Context(), query, store.WithAutomaticRequestId(), store.WithPeer(peerID))
+		}
+	}
+
+	if err != nil {
+		a.log.Error("obtaining store stats", zap.Error(err))
+		reply.Error = err.Error()
+		return nil
+	}
+
+	reply.Stats = stats
+	return nil
+}
+
+// authorized returns whether a request contains the admin token in its Authorization
+// header, as a bearer token
+func (a *AdminService) authorized(req *http.Request) bool {
+	if a.token == "" {
+		return false
+	}
+
+	header := req.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return false
+	}
+
+	token := strings.TrimPrefix(header, "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) == 1
+}
+
+type StoreDeleteArgs struct {
+	Hashes       []string `json:"hashes,omitempty"`
+	ContentTopic string   `json:"contentTopic,omitempty"`
+	StartTime    int64    `json:"startTime,omitempty"`
+	EndTime      int64    `json:"endTime,omitempty"`
+	Tombstone    bool     `json:"tombstone,omitempty"`
+}
+
+type StoreDeleteReply struct {
+	Deleted int64  `json:"deleted"`
+	Error   string `json:"error,omitempty"`
+}
+
+// DeleteV1StoreMessages removes from the local store the messages whose hex encoded
+// hash is in a list, the messages with a content topic, or the messages sent during a
+// time range. The request must contain the admin token as a bearer token
+func (a *AdminService) DeleteV1StoreMessages(req *http.Request, args *StoreDeleteArgs, reply *StoreDeleteReply) error {
+	if !a.authorized(req) {
+		a.log.Warn("unauthorized deletion of stored messages", zap.String("remoteAddr", req.RemoteAddr))
+		return ErrUnauthorized
+	}
+
+	criteria := store.DeleteCriteria{
+		ContentTopic: args.ContentTopic,
+		StartTime:    args.StartTime,
+		EndTime:      args.EndTime,
+		Tombstone:    args.Tombstone,
+	}
+	for _, h := range args.Hashes {
+		hash, err := hexutil.Decode(h)
+		if err != nil {
+			reply.Error = err.Error()
+			return nil
+		}
+		criteria.Hashes = append(criteria.Hashes, hash)
+	}
+
+	deleted, err := a.node.Store().Delete(criteria)
+	if err != nil {
+		a.log.Error("deleting stored messages", zap.Error(err))
+		reply.Error = err.Error()
+		return nil
+	}
+
+	a.log.Info("deleted stored messages", zap.String("remoteAddr", req.RemoteAddr), zap.Int64("count", deleted))
+	reply.Deleted = deleted
+	return nil
+}
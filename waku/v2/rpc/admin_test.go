@@ -12,7 +12,9 @@ import (
 	"github.com/multiformats/go-multiaddr"
 
 	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/persistence"
 	"github.com/status-im/go-waku/waku/v2/node"
+	"github.com/status-im/go-waku/waku/v2/protocol"
 	"github.com/status-im/go-waku/waku/v2/protocol/relay"
 	"github.com/status-im/go-waku/waku/v2/utils"
 	"github.com/stretchr/testify/require"
@@ -24,7 +26,7 @@ func makeAdminService(t *testing.T) *AdminService {
 	require.NoError(t, err)
 	err = n.Start()
 	require.NoError(t, err)
-	return &AdminService{n, utils.Logger()}
+	return &AdminService{n, utils.Logger(), ""}
 }
 
 func TestV1Peers(t *testing.T) {
@@ -68,3 +70,75 @@ func TestV1Peers(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, reply.Peers, 2)
 }
+
+func TestV1StoreStats(t *testing.T) {
+	memoryStore := persistence.NewMemoryStore(utils.Logger())
+	require.NoError(t, memoryStore.Put(protocol.NewEnvelope(tests.CreateWakuMessage("a", 1), utils.GetUnixEpoch(), "test")))
+	require.NoError(t, memoryStore.Put(protocol.NewEnvelope(tests.CreateWakuMessage("b", 2), utils.GetUnixEpoch(), "test")))
+
+	n, err := node.New(context.Background(), node.WithWakuStore(true, false), node.WithMessageProvider(memoryStore))
+	require.NoError(t, err)
+	require.NoError(t, n.Start())
+	defer n.Stop()
+
+	a := &AdminService{n, utils.Logger(), ""}
+
+	var reply StoreStatsReply
+	err = a.GetV1StoreStats(makeRequest(t), &StoreStatsArgs{ContentFilters: []string{"b"}}, &reply)
+	require.NoError(t, err)
+	require.Empty(t, reply.Error)
+	require.Equal(t, uint64(1), reply.Stats.Count)
+	require.Equal(t, int64(2), reply.Stats.OldestTimestamp)
+
+	reply = StoreStatsReply{}
+	err = a.GetV1StoreStats(makeRequest(t), &StoreStatsArgs{PeerID: "invalid"}, &reply)
+	require.NoError(t, err)
+	require.NotEmpty(t, reply.Error)
+}
+
+func TestV1StoreDelete(t *testing.T) {
+	memoryStore := persistence.NewMemoryStore(utils.Logger())
+	env := protocol.NewEnvelope(tests.CreateWakuMessage("a", 1), utils.GetUnixEpoch(), "test")
+	require.NoError(t, memoryStore.Put(env))
+	require.NoError(t, memoryStore.Put(protocol.NewEnvelope(tests.CreateWakuMessage("b", 2), utils.GetUnixEpoch(), "test")))
+
+	n, err := node.New(context.Background(), node.WithWakuStore(true, false), node.WithMessageProvider(memoryStore))
+	require.NoError(t, err)
+	require.NoError(t, n.Start())
+	defer n.Stop()
+
+	a := &AdminService{n, utils.Logger(), "secret"}
+
+	var reply StoreDeleteReply
+	request := makeRequest(t)
+	err = a.DeleteV1StoreMessages(request, &StoreDeleteArgs{ContentTopic: "a"}, &reply)
+	require.ErrorIs(t, err, ErrUnauthorized)
+
+	request.Header.Set("Authorization", "Bearer wrong")
+	err = a.DeleteV1StoreMessages(request, &StoreDeleteArgs{ContentTopic: "a"}, &reply)
+	require.ErrorIs(t, err, ErrUnauthorized)
+
+	// The token must be sent as a bearer token
+	request.Header.Set("Authorization", "secret")
+	err = a.DeleteV1StoreMessages(request, &StoreDeleteArgs{ContentTopic: "a"}, &reply)
+	require.ErrorIs(t, err, ErrUnauthorized)
+
+	request.Header.Set("Authorization", "Bearer secret")
+	err = a.DeleteV1StoreMessages(request, &StoreDeleteArgs{ContentTopic: "a", StartTime: 1}, &reply)
+	require.NoError(t, err)
+	require.NotEmpty(t, reply.Error)
+
+	reply = StoreDeleteReply{}
+	err = a.DeleteV1StoreMessages(request, &StoreDeleteArgs{ContentTopic: "a", Tombstone: true}, &reply)
+	require.NoError(t, err)
+	require.Empty(t, reply.Error)
+	require.Equal(t, int64(1), reply.Deleted)
+
+	require.ErrorIs(t, memoryStore.Put(env), persistence.ErrDeletedMessage)
+
+	// The deletion methods are disabled without an admin token
+	a = &AdminService{n, utils.Logger(), ""}
+	request.Header.Set("Authorization", "Bearer ")
+	err = a.DeleteV1StoreMessages(request, &StoreDeleteArgs{ContentTopic: "b"}, &reply)
+	require.ErrorIs(t, err, ErrUnauthorized)
+}
@@ -3,6 +3,7 @@ package rpc
 import (
 	"net/http"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/status-im/go-waku/waku/v2/node"
 	"github.com/status-im/go-waku/waku/v2/protocol/pb"
 	"github.com/status-im/go-waku/waku/v2/protocol/store"
@@ -30,6 +31,7 @@ type StoreMessagesArgs struct {
 	StartTime      int64              `json:"startTime,omitempty"`
 	EndTime        int64              `json:"endTime,omitempty"`
 	PagingOptions  StorePagingOptions `json:"pagingOptions,omitempty"`
+	MaxMessages    int                `json:"maxMessages,omitempty"`
 }
 
 type StoreMessagesReply struct {
@@ -38,14 +40,17 @@ type StoreMessagesReply struct {
 	Error      string             `json:"error,omitempty"`
 }
 
+// GetV1Messages retrieves a single page of messages, unless MaxMessages is
+// set, in which case pages are retrieved until that number of messages is reached
 func (s *StoreService) GetV1Messages(req *http.Request, args *StoreMessagesArgs, reply *StoreMessagesReply) error {
 	options := []store.HistoryRequestOption{
 		store.WithAutomaticRequestId(),
 		store.WithAutomaticPeerSelection(),
 		store.WithPaging(args.PagingOptions.Forward, args.PagingOptions.PageSize),
 		store.WithCursor(args.PagingOptions.Cursor),
+		store.WithMaxMessages(args.MaxMessages),
 	}
-	res, err := s.node.Store().Query(
+	it, err := s.node.Store().QueryAll(
 		req.Context(),
 		store.Query{
 			Topic:         args.Topic,
@@ -61,15 +66,86 @@ func (s *StoreService) GetV1Messages(req *http.Request, args *StoreMessagesArgs,
 		return nil
 	}
 
-	reply.Messages = make([]RPCWakuMessage, len(res.Messages))
-	for i := range res.Messages {
-		reply.Messages[i] = *ProtoWakuMessageToRPCWakuMessage(res.Messages[i])
+	reply.Messages = []RPCWakuMessage{}
+	for it.Next() {
+		for _, msg := range it.Page().Messages {
+			reply.Messages = append(reply.Messages, *ProtoWakuMessageToRPCWakuMessage(msg))
+		}
+
+		if args.MaxMessages == 0 {
+			break
+		}
+	}
+
+	if err := it.Err(); err != nil {
+		s.log.Error("querying messages", zap.Error(err))
+		reply.Error = err.Error()
+		return nil
 	}
 
 	reply.PagingInfo = StorePagingOptions{
 		PageSize: args.PagingOptions.PageSize,
-		Cursor:   res.Cursor(),
 		Forward:  args.PagingOptions.Forward,
 	}
+
+	if it.Page() != nil {
+		reply.PagingInfo.Cursor = it.Page().Cursor()
+	}
+
+	return nil
+}
+
+type StoreMessagesByHashArgs struct {
+	Hashes       []string `json:"hashes"`
+	PresenceOnly bool     `json:"presenceOnly,omitempty"`
+}
+
+type StoreMessagesByHashReply struct {
+	Found    []bool           `json:"found"`
+	Messages []RPCWakuMessage `json:"messages,omitempty"`
+	Error    string           `json:"error,omitempty"`
+}
+
+// GetV1MessagesByHash looks up in a store node the messages whose hex encoded
+// hash is in a list. Found indicates for each hash whether the message is stored
+func (s *StoreService) GetV1MessagesByHash(req *http.Request, args *StoreMessagesByHashArgs, reply *StoreMessagesByHashReply) error {
+	var hashes [][]byte
+	for _, h := range args.Hashes {
+		hash, err := hexutil.Decode(h)
+		if err != nil {
+			reply.Error = err.Error()
+			return nil
+		}
+		hashes = append(hashes, hash)
+	}
+
+	options := []store.HistoryRequestOption{
+		store.WithAutomaticRequestId(),
+		store.WithAutomaticPeerSelection(),
+	}
+
+	if args.PresenceOnly {
+		found, err := s.node.Store().HasMessages(req.Context(), hashes, options...)
+		if err != nil {
+			s.log.Error("looking up messages", zap.Error(err))
+			reply.Error = err.Error()
+			return nil
+		}
+		reply.Found = found
+		return nil
+	}
+
+	result, err := s.node.Store().LookupMessages(req.Context(), hashes, options...)
+	if err != nil {
+		s.log.Error("looking up messages", zap.Error(err))
+		reply.Error = err.Error()
+		return nil
+	}
+
+	reply.Found = result.Found
+	for _, env := range result.Envelopes {
+		reply.Messages = append(reply.Messages, *ProtoWakuMessageToRPCWakuMessage(env.Message()))
+	}
+
 	return nil
 }
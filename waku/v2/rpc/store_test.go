@@ -31,3 +31,27 @@ func TestStoreGetV1Messages(t *testing.T) {
 	require.NoError(t, err)
 	require.NotEmpty(t, reply.Error)
 }
+
+func TestStoreGetV1MessagesByHash(t *testing.T) {
+	var reply StoreMessagesByHashReply
+
+	s := makeStoreService(t)
+
+	err := s.GetV1MessagesByHash(
+		makeRequest(t),
+		&StoreMessagesByHashArgs{Hashes: []string{"invalid"}},
+		&reply,
+	)
+	require.NoError(t, err)
+	require.NotEmpty(t, reply.Error)
+
+	// No store peers are available
+	reply = StoreMessagesByHashReply{}
+	err = s.GetV1MessagesByHash(
+		makeRequest(t),
+		&StoreMessagesByHashArgs{Hashes: []string{"0x0102"}, PresenceOnly: true},
+		&reply,
+	)
+	require.NoError(t, err)
+	require.NotEmpty(t, reply.Error)
+}
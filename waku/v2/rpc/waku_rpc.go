@@ -23,7 +23,9 @@ type WakuRpc struct {
 	adminService   *AdminService
 }
 
-func NewWakuRpc(node *node.WakuNode, address string, port int, enableAdmin bool, enablePrivate bool, log *zap.Logger) *WakuRpc {
+// NewWakuRpc creates a JSON-RPC server. The admin methods that modify the stored
+// messages require adminToken, and are disabled if it's empty
+func NewWakuRpc(node *node.WakuNode, address string, port int, enableAdmin bool, enablePrivate bool, adminToken string, log *zap.Logger) *WakuRpc {
 	wrpc := new(WakuRpc)
 	wrpc.log = log.Named("rpc")
 
@@ -48,7 +50,7 @@ func NewWakuRpc(node *node.WakuNode, address string, port int, enableAdmin bool,
 	}
 
 	if enableAdmin {
-		adminService := &AdminService{node, log.Named("admin")}
+		adminService := &AdminService{node, log.Named("admin"), adminToken}
 		err = s.RegisterService(adminService, "Admin")
 		if err != nil {
 			wrpc.log.Error("registering admin service", zap.Error(err))
@@ -47,6 +47,33 @@ func SelectPeer(host host.Host, protocolId string, log *zap.Logger) (*peer.ID, e
 	return nil, ErrNoPeersAvailable
 }
 
+// SelectPeers is used to return up to n random peers that support a given protocol.
+func SelectPeers(host host.Host, protocolId string, n int, log *zap.Logger) (peer.IDSlice, error) {
+	var peers peer.IDSlice
+	for _, peer := range host.Peerstore().Peers() {
+		protocols, err := host.Peerstore().SupportsProtocols(peer, protocolId)
+		if err != nil {
+			log.Error("obtaining protocols supported by peers", zap.Error(err), logging.HostID("peer", peer))
+			return nil, err
+		}
+
+		if len(protocols) > 0 {
+			peers = append(peers, peer)
+		}
+	}
+
+	if len(peers) == 0 {
+		return nil, ErrNoPeersAvailable
+	}
+
+	rand.Shuffle(len(peers), func(i, j int) { peers[i], peers[j] = peers[j], peers[i] }) // nolint: gosec
+	if len(peers) > n {
+		peers = peers[:n]
+	}
+
+	return peers, nil
+}
+
 type pingResult struct {
 	p   peer.ID
 	rtt time.Duration
@@ -40,6 +40,14 @@ func TestSelectPeer(t *testing.T) {
 	_ = h1.Peerstore().AddProtocols(h2.ID(), proto)
 	_ = h1.Peerstore().AddProtocols(h3.ID(), proto)
 
+	peers, err := SelectPeers(h1, proto, 1, Logger())
+	require.NoError(t, err)
+	require.Len(t, peers, 1)
+
+	peers, err = SelectPeers(h1, proto, 5, Logger())
+	require.NoError(t, err)
+	require.Len(t, peers, 2)
+
 	_, err = SelectPeerWithLowestRTT(ctx, h1, proto, Logger())
 	require.NoError(t, err)
 